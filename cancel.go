@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// methodNotificationCancelled is sent by a client that no longer wants the
+// result of one of its requests
+const methodNotificationCancelled = "notifications/cancelled"
+
+// callIDField is the request metadata field tool calls are stamped with, so
+// the middleware can tell which JSON-RPC request it is running
+const callIDField = "filez/requestId"
+
+// callKey identifies a running tool call. Request IDs are only unique within
+// a session.
+type callKey struct {
+	session string
+	id      string
+}
+
+// callTracker cancels the context of a running tool call when its client
+// sends notifications/cancelled, which mcp-go does not act on itself. Calls
+// in stateless HTTP mode have no session to scope their IDs; clients there
+// cancel by closing the request instead.
+type callTracker struct {
+	mu      sync.Mutex
+	running map[callKey]context.CancelFunc
+}
+
+// newCallTracker returns a tracker with no running calls
+func newCallTracker() *callTracker {
+	return &callTracker{running: make(map[callKey]context.CancelFunc)}
+}
+
+// addHooks adds the server hook that stamps tool calls with their request ID
+func (c *callTracker) addHooks(hooks *server.Hooks) {
+	hooks.AddBeforeCallTool(func(ctx context.Context, id any, request *mcp.CallToolRequest) {
+		if request.Params.Meta == nil {
+			request.Params.Meta = &mcp.Meta{}
+		}
+		if request.Params.Meta.AdditionalFields == nil {
+			request.Params.Meta.AdditionalFields = make(map[string]any)
+		}
+		request.Params.Meta.AdditionalFields[callIDField] = fmt.Sprint(id)
+	})
+}
+
+// middleware runs each tool call with a context that handleCancelled can cancel
+func (c *callTracker) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		session := server.ClientSessionFromContext(ctx)
+		if session == nil || session.SessionID() == "" || request.Params.Meta == nil {
+			return next(ctx, request)
+		}
+		id, ok := request.Params.Meta.AdditionalFields[callIDField].(string)
+		if !ok {
+			return next(ctx, request)
+		}
+
+		key := callKey{session: session.SessionID(), id: id}
+		ctx, cancel := context.WithCancel(ctx)
+		c.mu.Lock()
+		c.running[key] = cancel
+		c.mu.Unlock()
+
+		defer func() {
+			c.mu.Lock()
+			delete(c.running, key)
+			c.mu.Unlock()
+			cancel()
+		}()
+		return next(ctx, request)
+	}
+}
+
+// handleCancelled cancels the call named by a notifications/cancelled from
+// the same session. Unknown and finished requests are ignored.
+func (c *callTracker) handleCancelled(ctx context.Context, notification mcp.JSONRPCNotification) {
+	session := server.ClientSessionFromContext(ctx)
+	id, ok := notification.Params.AdditionalFields["requestId"]
+	if session == nil || !ok {
+		return
+	}
+
+	c.mu.Lock()
+	cancel := c.running[callKey{session: session.SessionID(), id: fmt.Sprint(id)}]
+	c.mu.Unlock()
+
+	if cancel != nil {
+		log.Printf("[TOOL CANCELLED] request %v: %v", id, notification.Params.AdditionalFields["reason"])
+		cancel()
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// newCancelTestServer returns a server with call tracking and a "block" tool
+// that signals started and then waits for its context to be done
+func newCancelTestServer(started chan<- struct{}) *server.MCPServer {
+	calls := newCallTracker()
+	hooks := &server.Hooks{}
+	calls.addHooks(hooks)
+	mcpServer := server.NewMCPServer("test", "1.0.0",
+		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(calls.middleware),
+	)
+	mcpServer.AddNotificationHandler(methodNotificationCancelled, calls.handleCancelled)
+	mcpServer.AddTool(mcp.NewTool("block"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		started <- struct{}{}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(2 * time.Second):
+			return mcp.NewToolResultText("not cancelled"), nil
+		}
+	})
+	return mcpServer
+}
+
+// sendCancelled delivers a notifications/cancelled for requestID from session
+func sendCancelled(mcpServer *server.MCPServer, session *testSession, requestID any) {
+	msg, _ := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"method":  methodNotificationCancelled,
+		"params":  map[string]any{"requestId": requestID, "reason": "user abort"},
+	})
+	mcpServer.HandleMessage(mcpServer.WithContext(context.Background(), session), msg)
+}
+
+func TestCallTracker_Cancel(t *testing.T) {
+	started := make(chan struct{}, 1)
+	mcpServer := newCancelTestServer(started)
+	session := &testSession{id: "cancel-session", notifications: make(chan mcp.JSONRPCNotification, 10)}
+
+	done := make(chan mcp.JSONRPCMessage, 1)
+	go func() {
+		msg := []byte(`{"jsonrpc":"2.0","id":42,"method":"tools/call","params":{"name":"block"}}`)
+		done <- mcpServer.HandleMessage(mcpServer.WithContext(context.Background(), session), msg)
+	}()
+	<-started
+
+	// Another session's request with the same ID is left alone
+	sendCancelled(mcpServer, &testSession{id: "other-session"}, 42)
+	sendCancelled(mcpServer, session, 41)
+	select {
+	case response := <-done:
+		t.Fatalf("Call ended before it was cancelled: %+v", response)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	sendCancelled(mcpServer, session, 42)
+	select {
+	case response := <-done:
+		rpcErr, ok := response.(mcp.JSONRPCError)
+		if !ok || !contains(rpcErr.Error.Message, "context canceled") {
+			t.Errorf("Expected the call to fail with context canceled, got %+v", response)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Cancellation did not stop the call")
+	}
+}
+
+func TestCallTracker_WithoutSession(t *testing.T) {
+	started := make(chan struct{}, 1)
+	mcpServer := newCancelTestServer(started)
+
+	// Calls without a session still run; they just cannot be cancelled by ID
+	msg := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"block"}}`)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	response := mcpServer.HandleMessage(ctx, msg)
+	if rpcErr, ok := response.(mcp.JSONRPCError); !ok || !contains(rpcErr.Error.Message, "context canceled") {
+		t.Errorf("Expected the request context to still cancel the call, got %+v", response)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// errStopWalk is returned from a walk callback to end the walk early without error
+var errStopWalk = errors.New("stop walk")
+
+// walkCursor is the resume position encoded in walk_directory's next_cursor.
+// It records the requested path and the last entry returned, so the next page
+// can be produced by re-walking the tree without any per-session server state.
+type walkCursor struct {
+	Path  string `json:"p"` // walk_directory "path" argument the cursor belongs to
+	After string `json:"a"` // root-relative path of the last entry already returned
+}
+
+// encodeCursor returns the opaque string form of c
+func encodeCursor(c walkCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor produced by encodeCursor
+func decodeCursor(s string) (walkCursor, error) {
+	var c walkCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.After == "" {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
+
+// compareWalkOrder compares two root-relative slash paths in the order
+// filepath.WalkDir visits them: a parent before its children, and siblings
+// sorted by name. It returns -1, 0 or +1.
+func compareWalkOrder(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "." {
+		return -1
+	}
+	if b == "." {
+		return 1
+	}
+
+	as := strings.Split(a, "/")
+	bs := strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := strings.Compare(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+
+	// One path is an ancestor of the other; the ancestor is visited first
+	if len(as) < len(bs) {
+		return -1
+	}
+	return 1
+}
+
+// isAncestorPath reports whether the root-relative path dir contains p
+func isAncestorPath(dir, p string) bool {
+	return dir == "." || strings.HasPrefix(p, dir+"/")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	c := walkCursor{Path: "/subdir", After: "subdir/deep/file3.json"}
+
+	decoded, err := decodeCursor(encodeCursor(c))
+	if err != nil {
+		t.Fatalf("decodeCursor returned error: %v", err)
+	}
+	if decoded != c {
+		t.Errorf("Round trip mismatch: got %+v, want %+v", decoded, c)
+	}
+}
+
+func TestCursor_Invalid(t *testing.T) {
+	for _, s := range []string{"", "not base64!", encodeCursor(walkCursor{Path: "/"})} {
+		if _, err := decodeCursor(s); err == nil {
+			t.Errorf("Expected error decoding cursor %q", s)
+		}
+	}
+}
+
+func TestCompareWalkOrder(t *testing.T) {
+	// The order filepath.WalkDir visits these paths in
+	ordered := []string{
+		".",
+		"a",
+		"a/b",
+		"a/b/c",
+		"a/c",
+		"a-b",
+		"ab",
+		"b",
+	}
+
+	shuffled := []string{"b", "a/c", "ab", ".", "a/b/c", "a-b", "a", "a/b"}
+	sort.Slice(shuffled, func(i, j int) bool {
+		return compareWalkOrder(shuffled[i], shuffled[j]) < 0
+	})
+
+	for i := range ordered {
+		if shuffled[i] != ordered[i] {
+			t.Fatalf("Walk order mismatch: got %v, want %v", shuffled, ordered)
+		}
+	}
+}
+
+func TestIsAncestorPath(t *testing.T) {
+	tests := []struct {
+		dir, p string
+		want   bool
+	}{
+		{".", "a/b", true},
+		{"a", "a/b", true},
+		{"a", "ab/c", false},
+		{"a/b", "a/b", false},
+	}
+
+	for _, tt := range tests {
+		if got := isAncestorPath(tt.dir, tt.p); got != tt.want {
+			t.Errorf("isAncestorPath(%q, %q) = %v, want %v", tt.dir, tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+	newText := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nL\nm\n"
+
+	expected := `--- a/x
++++ b/x
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -9,4 +9,5 @@
+ i
+ j
+ k
+-l
++L
++m
+`
+	if diff := unifiedDiff("a/x", "b/x", oldText, newText); diff != expected {
+		t.Errorf("Unexpected diff:\n%s\nexpected:\n%s", diff, expected)
+	}
+}
+
+func TestUnifiedDiff_MergesNearbyChanges(t *testing.T) {
+	diff := unifiedDiff("a", "b", "1\n2\n3\n4\n5\n", "1\nX\n3\nY\n5\n")
+	if strings.Count(diff, "@@ ") != 1 {
+		t.Errorf("Expected a single hunk, got:\n%s", diff)
+	}
+	if !contains(diff, "@@ -1,5 +1,5 @@") {
+		t.Errorf("Unexpected hunk header in:\n%s", diff)
+	}
+}
+
+func TestUnifiedDiff_EdgeCases(t *testing.T) {
+	tests := []struct {
+		name, oldText, newText, expected string
+	}{
+		{"equal", "x\n", "x\n", ""},
+		{"new file", "", "x\n", "--- a\n+++ b\n@@ -0,0 +1 @@\n+x\n"},
+		{"deleted content", "x\ny\n", "", "--- a\n+++ b\n@@ -1,2 +0,0 @@\n-x\n-y\n"},
+		{"no trailing newline", "x\ny", "x\nz", "--- a\n+++ b\n@@ -1,2 +1,2 @@\n x\n-y\n\\ No newline at end of file\n+z\n\\ No newline at end of file\n"},
+	}
+
+	for _, tt := range tests {
+		if diff := unifiedDiff("a", "b", tt.oldText, tt.newText); diff != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, diff)
+		}
+	}
+}
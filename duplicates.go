@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// duplicatePartialBytes is how much of each same-size candidate is hashed
+// before committing to a full hash
+const duplicatePartialBytes = 4 << 10
+
+// defaultMaxDuplicateGroups caps find_duplicates results when the caller sets no limit
+const defaultMaxDuplicateGroups = 100
+
+// duplicateGroup is a set of files with identical contents
+type duplicateGroup struct {
+	Size        int64    `json:"size"`
+	Digest      string   `json:"digest"` // sha256 of the contents
+	Paths       []string `json:"paths"`  // in walk order
+	WastedBytes int64    `json:"wasted_bytes"`
+}
+
+// duplicatesResult is the structured content returned by find_duplicates
+type duplicatesResult struct {
+	Groups         []duplicateGroup `json:"groups"`
+	FilesScanned   int              `json:"files_scanned"`
+	FilesHashed    int              `json:"files_hashed"`    // files that needed a full hash
+	DuplicateFiles int              `json:"duplicate_files"` // copies beyond the first in every group
+	WastedBytes    int64            `json:"wasted_bytes"`    // over every group, including those cut by max_groups
+	Truncated      bool             `json:"truncated"`       // max_groups was reached
+}
+
+// duplicateCandidate is a file that may have duplicates
+type duplicateCandidate struct {
+	index  int // walk order
+	path   string
+	rel    string
+	size   int64
+	digest string
+}
+
+// findDuplicatesTool implements the find_duplicates tool handler
+func findDuplicatesTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		// Parse the arguments
+		var args struct {
+			Path          string   `json:"path"`
+			MinSize       int64    `json:"min_size"`
+			Include       []string `json:"include"`
+			Exclude       []string `json:"exclude"`
+			MaxGroups     int      `json:"max_groups"`
+			IncludeHidden bool     `json:"include_hidden"`
+			RespectIgnore bool     `json:"respect_ignore_files"`
+		}
+		args.Path = "/"
+		args.MinSize = 1
+		args.MaxGroups = defaultMaxDuplicateGroups
+		args.IncludeHidden = true
+		args.RespectIgnore = cfg.RespectIgnoreFiles
+
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+
+		if args.MinSize < 0 || args.MaxGroups <= 0 {
+			return nil, fmt.Errorf("min_size must not be negative and max_groups must be positive")
+		}
+
+		opts := defaultWalkOptions()
+		opts.Type = entryTypeFiles
+		opts.IncludeHidden = args.IncludeHidden
+		opts.RespectIgnore = args.RespectIgnore
+		opts.Ignore = cfg.IgnoreRules
+
+		var err error
+		if opts.Include, err = newPathMatcher(args.Include, patternSyntaxGlob); err != nil {
+			return nil, fmt.Errorf("invalid include: %w", err)
+		}
+		if opts.Exclude, err = newPathMatcher(args.Exclude, patternSyntaxGlob); err != nil {
+			return nil, fmt.Errorf("invalid exclude: %w", err)
+		}
+
+		// Map the input path to actual filesystem path, enforcing root containment
+		target, err := resolvePath(cfg, args.Path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Lstat(target); os.IsNotExist(err) {
+			return nil, fmt.Errorf("path does not exist: %s", args.Path)
+		}
+
+		result, err := findDuplicates(ctx, cfg.RootDir, target, opts, args.MinSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(result.Groups) > args.MaxGroups {
+			result.Groups = result.Groups[:args.MaxGroups]
+			result.Truncated = true
+		}
+
+		formatter, _ := newPathFormatter(cfg.PathStyle, cfg.RootDir, relPath(cfg.RootDir, target))
+		for _, group := range result.Groups {
+			for i, rel := range group.Paths {
+				group.Paths[i] = formatter.format(rel)
+			}
+		}
+
+		summary := fmt.Sprintf("Found %d duplicate groups (%d redundant files, %d bytes wasted) among %d files scanned",
+			len(result.Groups), result.DuplicateFiles, result.WastedBytes, result.FilesScanned)
+		if result.Truncated {
+			summary += fmt.Sprintf(" (showing the largest %d groups)", args.MaxGroups)
+		}
+
+		log.Printf("[TOOL COMPLETED] %s - %s", request.Params.Name, summary)
+
+		return mcp.NewToolResultStructured(result, formatDuplicates(summary, result.Groups)), nil
+	}
+}
+
+// findDuplicates walks target and groups files with identical contents.
+// Candidates are narrowed by size, then by a hash of their first
+// duplicatePartialBytes, and only the survivors are hashed in full. Groups
+// are returned largest waste first, with root-relative paths.
+func findDuplicates(ctx context.Context, rootDir, target string, opts walkOptions, minSize int64) (duplicatesResult, error) {
+	var result duplicatesResult
+
+	bySize := make(map[int64][]duplicateCandidate)
+	err := walkTree(rootDir, target, opts, func(entry walkEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		info, err := entry.Entry.Info()
+		if err != nil {
+			return nil
+		}
+		result.FilesScanned++
+		if info.Size() < minSize {
+			return nil
+		}
+		c := duplicateCandidate{index: result.FilesScanned, path: entry.Path, rel: entry.Rel, size: info.Size()}
+		bySize[c.size] = append(bySize[c.size], c)
+		return nil
+	})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return duplicatesResult{}, ctxErr
+		}
+		return duplicatesResult{}, fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	// Files with a unique size cannot have duplicates
+	var candidates []duplicateCandidate
+	for _, group := range bySize {
+		if len(group) > 1 {
+			candidates = append(candidates, group...)
+		}
+	}
+
+	// Small files are read completely by the partial pass, so its digest is final
+	partial, err := hashCandidates(ctx, candidates, duplicatePartialBytes)
+	if err != nil {
+		return duplicatesResult{}, err
+	}
+
+	var final, full []duplicateCandidate
+	for _, group := range groupCandidates(partial) {
+		if group[0].size <= duplicatePartialBytes {
+			final = append(final, group...)
+		} else {
+			full = append(full, group...)
+		}
+	}
+
+	hashed, err := hashCandidates(ctx, full, 0)
+	if err != nil {
+		return duplicatesResult{}, err
+	}
+	result.FilesHashed = len(hashed)
+
+	result.Groups = []duplicateGroup{}
+	for _, group := range groupCandidates(append(final, hashed...)) {
+		dup := duplicateGroup{
+			Size:        group[0].size,
+			Digest:      group[0].digest,
+			WastedBytes: group[0].size * int64(len(group)-1),
+		}
+		for _, c := range group {
+			dup.Paths = append(dup.Paths, c.rel)
+		}
+		result.Groups = append(result.Groups, dup)
+		result.DuplicateFiles += len(group) - 1
+		result.WastedBytes += dup.WastedBytes
+	}
+
+	sort.Slice(result.Groups, func(i, j int) bool {
+		a, b := result.Groups[i], result.Groups[j]
+		if a.WastedBytes != b.WastedBytes {
+			return a.WastedBytes > b.WastedBytes
+		}
+		return compareWalkOrder(a.Paths[0], b.Paths[0]) < 0
+	})
+	return result, nil
+}
+
+// hashCandidates sets the sha256 digest of each candidate's first limit bytes
+// (the whole file when limit is 0). Files that cannot be read are dropped.
+func hashCandidates(ctx context.Context, candidates []duplicateCandidate, limit int64) ([]duplicateCandidate, error) {
+	jobs := make([]hashJob, len(candidates))
+	for i, c := range candidates {
+		jobs[i] = hashJob{index: i, path: c.path, rel: c.rel, algorithm: hashSHA256, limit: limit}
+	}
+
+	outcomes, err := hashJobs(ctx, jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	hashed := make([]duplicateCandidate, 0, len(candidates))
+	for _, out := range outcomes {
+		if out.err != nil {
+			log.Printf("Failed to hash %s: %v", out.path, out.err)
+			continue
+		}
+		c := candidates[out.index]
+		c.digest = out.digest
+		hashed = append(hashed, c)
+	}
+	return hashed, nil
+}
+
+// groupCandidates returns the sets of two or more candidates sharing a size
+// and digest, each in walk order
+func groupCandidates(candidates []duplicateCandidate) [][]duplicateCandidate {
+	type key struct {
+		size   int64
+		digest string
+	}
+	groups := make(map[key][]duplicateCandidate)
+	for _, c := range candidates {
+		k := key{c.size, c.digest}
+		groups[k] = append(groups[k], c)
+	}
+
+	var result [][]duplicateCandidate
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return group[i].index < group[j].index })
+		result = append(result, group)
+	}
+	return result
+}
+
+// formatDuplicates renders duplicate groups as text, one block per group
+func formatDuplicates(summary string, groups []duplicateGroup) string {
+	text := summary + "\n"
+	for _, group := range groups {
+		text += fmt.Sprintf("\n%d copies of %d bytes (%d wasted), sha256 %s\n", len(group.Paths), group.Size, group.WastedBytes, group.Digest)
+		for _, p := range group.Paths {
+			text += "  " + p + "\n"
+		}
+	}
+	return text
+}
+
+// findDuplicatesOutputSchema describes duplicatesResult for clients that validate structured content
+const findDuplicatesOutputSchema = `{
+	"type": "object",
+	"properties": {
+		"groups": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"size": {"type": "integer"},
+					"digest": {"type": "string"},
+					"paths": {"type": "array", "items": {"type": "string"}},
+					"wasted_bytes": {"type": "integer"}
+				},
+				"required": ["size", "digest", "paths", "wasted_bytes"]
+			}
+		},
+		"files_scanned": {"type": "integer"},
+		"files_hashed": {"type": "integer"},
+		"duplicate_files": {"type": "integer"},
+		"wasted_bytes": {"type": "integer"},
+		"truncated": {"type": "boolean"}
+	},
+	"required": ["groups", "files_scanned", "files_hashed", "duplicate_files", "wasted_bytes", "truncated"]
+}`
+
+// findDuplicatesServerTool defines the find_duplicates tool
+func findDuplicatesServerTool(cfg *serverConfig) server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "find_duplicates",
+			Description: "Finds files with identical contents under the specified path and reports the bytes they waste",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Directory to search (use '/' for root directory)",
+						"default":     "/",
+					},
+					"min_size": map[string]interface{}{
+						"type":        "integer",
+						"description": "Ignore files smaller than this many bytes (the default skips empty files)",
+						"minimum":     0,
+						"default":     1,
+					},
+					"include": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Only consider files whose root-relative path matches one of these globs (e.g. '*.parquet')",
+					},
+					"exclude": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Skip files and directories matching these globs",
+					},
+					"max_groups": map[string]interface{}{
+						"type":        "integer",
+						"description": "Return at most this many groups, largest waste first",
+						"minimum":     1,
+						"default":     defaultMaxDuplicateGroups,
+					},
+					"include_hidden": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Consider dot-files and dot-directories",
+						"default":     true,
+					},
+					"respect_ignore_files": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Skip files matched by .gitignore/.ignore files and the server ignore file",
+						"default":     cfg.RespectIgnoreFiles,
+					},
+				},
+			},
+			RawOutputSchema: json.RawMessage(findDuplicatesOutputSchema),
+		},
+		Handler: findDuplicatesTool(cfg),
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindDuplicatesTool(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	big := strings.Repeat("0123456789", 1000)
+	writeTestFile(t, filepath.Join(tempDir, "a.bin"), big)
+	writeTestFile(t, filepath.Join(tempDir, "subdir", "b.bin"), big)
+	writeTestFile(t, filepath.Join(tempDir, "emptydir", "c.bin"), big)
+	// Same size and same first block, different tail: only the full hash tells them apart
+	writeTestFile(t, filepath.Join(tempDir, "d.bin"), big[:len(big)-1]+"x")
+	writeTestFile(t, filepath.Join(tempDir, "copy.txt"), "test content")
+	writeTestFile(t, filepath.Join(tempDir, "empty1"), "")
+	writeTestFile(t, filepath.Join(tempDir, "empty2"), "")
+
+	cfg := newServerConfig(tempDir)
+	cfg.PathStyle = pathStyleVirtual
+	handler := findDuplicatesTool(cfg)
+
+	result, err := callTool(handler, "find_duplicates", `{}`)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	dups := result.StructuredContent.(duplicatesResult)
+
+	if len(dups.Groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %+v", dups.Groups)
+	}
+	if got := dups.Groups[0]; !reflect.DeepEqual(got.Paths, []string{"/a.bin", "/emptydir/c.bin", "/subdir/b.bin"}) || got.Size != 10000 || got.WastedBytes != 20000 || len(got.Digest) != 64 {
+		t.Errorf("Unexpected largest group %+v", got)
+	}
+	if got := dups.Groups[1]; !reflect.DeepEqual(got.Paths, []string{"/copy.txt", "/file1.txt"}) || got.WastedBytes != 12 {
+		t.Errorf("Unexpected small group %+v", got)
+	}
+	if dups.FilesScanned != 10 || dups.FilesHashed != 4 || dups.DuplicateFiles != 3 || dups.WastedBytes != 20012 || dups.Truncated {
+		t.Errorf("Unexpected totals %+v", dups)
+	}
+
+	// Empty files are duplicates once min_size allows them
+	result, _ = callTool(handler, "find_duplicates", `{"min_size": 0, "include": ["empty*"]}`)
+	if groups := result.StructuredContent.(duplicatesResult).Groups; len(groups) != 1 || len(groups[0].Paths) != 2 {
+		t.Errorf("Expected the empty files to be grouped, got %+v", groups)
+	}
+
+	// Filters and max_groups
+	result, _ = callTool(handler, "find_duplicates", `{"exclude": ["subdir"], "max_groups": 1}`)
+	dups = result.StructuredContent.(duplicatesResult)
+	if len(dups.Groups) != 1 || len(dups.Groups[0].Paths) != 2 || !dups.Truncated || dups.WastedBytes != 10012 {
+		t.Errorf("Expected the largest of two groups after filtering, got %+v", dups)
+	}
+
+	if _, err := callTool(handler, "find_duplicates", `{"min_size": -1}`); err == nil {
+		t.Error("Expected error for negative min_size")
+	}
+}
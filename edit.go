@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// errStaleFile is returned when an edit's optimistic lock does not match the file
+var errStaleFile = errors.New("file has changed since it was read")
+
+// textEdit is one exact-match replacement requested by edit_file
+type textEdit struct {
+	OldString string `json:"old_string"`
+	NewString string `json:"new_string"`
+}
+
+// editResult is the structured content returned by edit_file
+type editResult struct {
+	Path         string `json:"path"`
+	Replacements int    `json:"replacements"`
+	SHA256       string `json:"sha256"` // hash of the new content, for chaining edits
+	Diff         string `json:"diff"`   // unified diff of the change
+}
+
+// editFileTool implements the edit_file tool handler
+func editFileTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		if !cfg.AllowWrite {
+			return nil, errWriteDisabled
+		}
+
+		// Parse the arguments
+		var args struct {
+			Path           string     `json:"path"`
+			Edits          []textEdit `json:"edits"`
+			ExpectedSHA256 string     `json:"expected_sha256"`
+			ExpectedMtime  string     `json:"expected_mtime"`
+		}
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+
+		if args.Path == "" {
+			return nil, fmt.Errorf("path is required")
+		}
+		if len(args.Edits) == 0 {
+			return nil, fmt.Errorf("edits must contain at least one old_string/new_string pair")
+		}
+
+		var expectedMtime time.Time
+		if args.ExpectedMtime != "" {
+			t, err := time.Parse(time.RFC3339Nano, args.ExpectedMtime)
+			if err != nil {
+				return nil, fmt.Errorf("invalid expected_mtime %q: must be an RFC 3339 timestamp", args.ExpectedMtime)
+			}
+			expectedMtime = t
+		}
+
+		// Map the input path to actual filesystem path, enforcing root containment
+		target, err := resolveWritePath(cfg, args.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := os.Stat(target)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("path does not exist: %s", args.Path)
+			}
+			return nil, fmt.Errorf("failed to stat file: %w", err)
+		}
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("path is not a regular file: %s", args.Path)
+		}
+
+		data, err := os.ReadFile(target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		if isBinary(data) {
+			return nil, fmt.Errorf("cannot edit binary file: %s", args.Path)
+		}
+
+		// Optimistic locking: refuse to edit a file that changed since the caller read it
+		if args.ExpectedSHA256 != "" && !strings.EqualFold(args.ExpectedSHA256, sha256Hex(data)) {
+			return nil, fmt.Errorf("%w: sha256 is %s, expected %s", errStaleFile, sha256Hex(data), args.ExpectedSHA256)
+		}
+		if !expectedMtime.IsZero() && !info.ModTime().Truncate(time.Second).Equal(expectedMtime.Truncate(time.Second)) {
+			return nil, fmt.Errorf("%w: mtime is %s, expected %s", errStaleFile, info.ModTime().UTC().Format(time.RFC3339), args.ExpectedMtime)
+		}
+
+		oldContent := string(data)
+		newContent, err := applyTextEdits(oldContent, args.Edits)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, _, err := atomicWriteFile(target, []byte(newContent), writeModeOverwrite); err != nil {
+			return nil, err
+		}
+
+		formatter, _ := newPathFormatter(cfg.PathStyle, cfg.RootDir, ".")
+		rel := relPath(cfg.RootDir, target)
+		result := editResult{
+			Path:         formatter.format(rel),
+			Replacements: len(args.Edits),
+			SHA256:       sha256Hex([]byte(newContent)),
+			Diff:         unifiedDiff("a/"+rel, "b/"+rel, oldContent, newContent),
+		}
+
+		log.Printf("[TOOL COMPLETED] %s - applied %d edits to %s", request.Params.Name, result.Replacements, args.Path)
+
+		return mcp.NewToolResultStructured(result, result.Diff), nil
+	}
+}
+
+// applyTextEdits applies edits in order, each to the result of the previous
+// one. Every old_string must occur exactly once.
+func applyTextEdits(content string, edits []textEdit) (string, error) {
+	for i, edit := range edits {
+		if edit.OldString == "" {
+			return "", fmt.Errorf("edit %d: old_string must not be empty", i+1)
+		}
+		if edit.OldString == edit.NewString {
+			return "", fmt.Errorf("edit %d: old_string and new_string are identical", i+1)
+		}
+
+		switch n := strings.Count(content, edit.OldString); n {
+		case 0:
+			return "", fmt.Errorf("edit %d: old_string not found in file", i+1)
+		case 1:
+			content = strings.Replace(content, edit.OldString, edit.NewString, 1)
+		default:
+			return "", fmt.Errorf("edit %d: old_string matches %d times; include more surrounding text to make it unique", i+1, n)
+		}
+	}
+	return content, nil
+}
+
+// sha256Hex returns the lowercase hex SHA-256 of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// editFileOutputSchema describes editResult for clients that validate structured content
+const editFileOutputSchema = `{
+	"type": "object",
+	"properties": {
+		"path": {"type": "string"},
+		"replacements": {"type": "integer"},
+		"sha256": {"type": "string", "description": "SHA-256 of the new file content"},
+		"diff": {"type": "string", "description": "Unified diff of the change"}
+	},
+	"required": ["path", "replacements", "sha256", "diff"]
+}`
+
+// editFileServerTool defines the edit_file tool
+func editFileServerTool(cfg *serverConfig) server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "edit_file",
+			Description: "Edits a text file under the root by replacing exact, unique strings and returns a unified diff",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "File path to edit (relative to the root, e.g. '/src/main.go')",
+					},
+					"edits": map[string]interface{}{
+						"type":        "array",
+						"description": "Replacements applied in order; each old_string must match exactly once",
+						"minItems":    1,
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"old_string": map[string]interface{}{
+									"type":        "string",
+									"description": "Exact text to replace",
+								},
+								"new_string": map[string]interface{}{
+									"type":        "string",
+									"description": "Replacement text",
+								},
+							},
+							"required": []string{"old_string", "new_string"},
+						},
+					},
+					"expected_sha256": map[string]interface{}{
+						"type":        "string",
+						"description": "Fail unless the current content has this SHA-256 (hex)",
+					},
+					"expected_mtime": map[string]interface{}{
+						"type":        "string",
+						"description": "Fail unless the file's modification time matches this RFC 3339 timestamp (second precision)",
+					},
+				},
+				Required: []string{"path", "edits"},
+			},
+			RawOutputSchema: json.RawMessage(editFileOutputSchema),
+		},
+		Handler: editFileTool(cfg),
+	}
+}
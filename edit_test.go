@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// editFile calls edit_file and returns its structured result
+func editFile(t *testing.T, cfg *serverConfig, arguments string) editResult {
+	t.Helper()
+
+	result, err := callTool(editFileTool(cfg), "edit_file", arguments)
+	if err != nil {
+		t.Fatalf("Handler returned error for %s: %v", arguments, err)
+	}
+
+	edited, ok := result.StructuredContent.(editResult)
+	if !ok {
+		t.Fatalf("StructuredContent is not editResult, got %T", result.StructuredContent)
+	}
+	return edited
+}
+
+func TestEditFileTool_Replacements(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	target := filepath.Join(tempDir, "main.go")
+	writeTestFile(t, target, "package main\n\nfunc a() {}\n\nfunc b() {}\n")
+
+	edited := editFile(t, writeConfig(tempDir), `{"path": "/main.go", "edits": [
+		{"old_string": "func a() {}", "new_string": "func a() { b() }"},
+		{"old_string": "func b() {}", "new_string": "func c() {}"}
+	]}`)
+
+	expected := "package main\n\nfunc a() { b() }\n\nfunc c() {}\n"
+	if data, _ := os.ReadFile(target); string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+	if edited.Replacements != 2 || edited.SHA256 != sha256Hex([]byte(expected)) {
+		t.Errorf("Unexpected result %+v", edited)
+	}
+	if !contains(edited.Diff, "--- a/main.go\n+++ b/main.go\n") || !contains(edited.Diff, "-func a() {}\n+func a() { b() }\n") {
+		t.Errorf("Unexpected diff:\n%s", edited.Diff)
+	}
+}
+
+func TestEditFileTool_OptimisticLock(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := writeConfig(tempDir)
+	target := filepath.Join(tempDir, "file1.txt")
+	hash := sha256Hex([]byte("test content"))
+
+	edited := editFile(t, cfg, `{"path": "/file1.txt", "expected_sha256": "`+hash+`", "edits": [{"old_string": "test", "new_string": "new"}]}`)
+
+	// The old hash no longer matches
+	_, err := callTool(editFileTool(cfg), "edit_file", `{"path": "/file1.txt", "expected_sha256": "`+hash+`", "edits": [{"old_string": "new", "new_string": "old"}]}`)
+	if !errors.Is(err, errStaleFile) {
+		t.Errorf("Expected errStaleFile for stale hash, got %v", err)
+	}
+
+	// Chaining with the returned hash works
+	editFile(t, cfg, `{"path": "/file1.txt", "expected_sha256": "`+edited.SHA256+`", "edits": [{"old_string": "new", "new_string": "old"}]}`)
+
+	past := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(target, past, past); err != nil {
+		t.Fatal(err)
+	}
+	_, err = callTool(editFileTool(cfg), "edit_file", `{"path": "/file1.txt", "expected_mtime": "2021-01-01T00:00:00Z", "edits": [{"old_string": "old", "new_string": "x"}]}`)
+	if !errors.Is(err, errStaleFile) {
+		t.Errorf("Expected errStaleFile for stale mtime, got %v", err)
+	}
+	editFile(t, cfg, `{"path": "/file1.txt", "expected_mtime": "2020-01-02T03:04:05Z", "edits": [{"old_string": "old", "new_string": "x"}]}`)
+
+	if data, _ := os.ReadFile(target); string(data) != "x content" {
+		t.Errorf("Unexpected content %q", data)
+	}
+}
+
+func TestEditFileTool_Errors(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	writeTestFile(t, filepath.Join(tempDir, "dup.txt"), "x x\n")
+	handler := editFileTool(writeConfig(tempDir))
+
+	tests := []struct {
+		arguments string
+		errText   string
+	}{
+		{`{"path": "/file1.txt", "edits": [{"old_string": "missing", "new_string": "y"}]}`, "not found"},
+		{`{"path": "/dup.txt", "edits": [{"old_string": "x", "new_string": "y"}]}`, "matches 2 times"},
+		{`{"path": "/file1.txt", "edits": [{"old_string": "", "new_string": "y"}]}`, "must not be empty"},
+		{`{"path": "/file1.txt", "edits": [{"old_string": "test", "new_string": "best"}, {"old_string": "test", "new_string": "x"}]}`, "edit 2: old_string not found"},
+		{`{"path": "/file1.txt", "edits": []}`, "at least one"},
+		{`{"path": "/file1.txt", "expected_mtime": "yesterday", "edits": [{"old_string": "test", "new_string": "x"}]}`, "invalid expected_mtime"},
+		{`{"path": "/missing.txt", "edits": [{"old_string": "a", "new_string": "b"}]}`, "does not exist"},
+		{`{"path": "/../x.txt", "edits": [{"old_string": "a", "new_string": "b"}]}`, "outside root directory"},
+	}
+
+	for _, tt := range tests {
+		_, err := callTool(handler, "edit_file", tt.arguments)
+		if err == nil {
+			t.Fatalf("Expected error for %s, but got none", tt.arguments)
+		}
+		if !contains(err.Error(), tt.errText) {
+			t.Errorf("Expected '%s' error for %s, got: %v", tt.errText, tt.arguments, err)
+		}
+	}
+
+	// A failed edit leaves the file untouched
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "file1.txt")); string(data) != "test content" {
+		t.Errorf("File modified by failed edit: %q", data)
+	}
+}
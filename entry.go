@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// Metadata fields that can be requested through walk_directory's "fields" argument
+const (
+	fieldSize          = "size"
+	fieldMode          = "mode"
+	fieldModTime       = "mtime"
+	fieldType          = "type"
+	fieldSymlinkTarget = "symlink_target"
+	fieldChildCount    = "child_count"
+)
+
+// entryFields lists every supported metadata field in output order
+var entryFields = []string{fieldSize, fieldMode, fieldModTime, fieldType, fieldSymlinkTarget, fieldChildCount}
+
+// walkItem is a single walk_directory entry with the requested metadata.
+// Fields that were not requested (or do not apply) are omitted.
+type walkItem struct {
+	Path          string `json:"path"`
+	Type          string `json:"type,omitempty"`           // file, dir, symlink or other
+	Size          *int64 `json:"size,omitempty"`           // bytes, as reported by lstat
+	Mode          string `json:"mode,omitempty"`           // octal permission bits, e.g. "0644"
+	ModTime       string `json:"mtime,omitempty"`          // RFC 3339 modification time
+	SymlinkTarget string `json:"symlink_target,omitempty"` // raw link contents for symlinks
+	ChildCount    *int   `json:"child_count,omitempty"`    // immediate children of directories
+}
+
+// entryFieldSet is the set of metadata fields requested for each entry
+type entryFieldSet map[string]bool
+
+// newEntryFieldSet validates the requested field names
+func newEntryFieldSet(fields []string) (entryFieldSet, error) {
+	set := make(entryFieldSet, len(fields))
+	for _, field := range fields {
+		valid := false
+		for _, known := range entryFields {
+			if field == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid field %q: must be one of size, mode, mtime, type, symlink_target, child_count", field)
+		}
+		set[field] = true
+	}
+	return set, nil
+}
+
+// entryTypeName returns the short type name used in entry metadata
+func entryTypeName(mode fs.FileMode) string {
+	switch {
+	case mode.IsRegular():
+		return "file"
+	case mode.IsDir():
+		return "dir"
+	case mode&fs.ModeSymlink != 0:
+		return "symlink"
+	}
+	return "other"
+}
+
+// newWalkItem builds the metadata object for an entry. Metadata that cannot be
+// read (e.g. the entry vanished mid-walk) is left out rather than failing the walk.
+func newWalkItem(outPath string, entry walkEntry, fields entryFieldSet) walkItem {
+	item := walkItem{Path: outPath}
+
+	if fields[fieldType] {
+		item.Type = entryTypeName(entry.Entry.Type())
+	}
+
+	if fields[fieldSize] || fields[fieldMode] || fields[fieldModTime] {
+		if info, err := entry.Entry.Info(); err == nil {
+			if fields[fieldSize] {
+				size := info.Size()
+				item.Size = &size
+			}
+			if fields[fieldMode] {
+				item.Mode = fmt.Sprintf("%04o", info.Mode().Perm())
+			}
+			if fields[fieldModTime] {
+				item.ModTime = info.ModTime().UTC().Format(time.RFC3339)
+			}
+		}
+	}
+
+	if fields[fieldSymlinkTarget] && entry.Entry.Type()&fs.ModeSymlink != 0 {
+		if target, err := os.Readlink(entry.Path); err == nil {
+			item.SymlinkTarget = target
+		}
+	}
+
+	if fields[fieldChildCount] && entry.Entry.IsDir() {
+		if count, err := countChildren(entry.Path); err == nil {
+			item.ChildCount = &count
+		}
+	}
+
+	return item
+}
+
+// countChildren returns the number of immediate children of dir
+func countChildren(dir string) (int, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	names, err := f.Readdirnames(-1)
+	return len(names), err
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewEntryFieldSet_Invalid(t *testing.T) {
+	if _, err := newEntryFieldSet([]string{"size", "owner"}); err == nil {
+		t.Fatal("Expected error for unknown field")
+	}
+}
+
+func TestNewWalkItem_Fields(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	link := filepath.Join(tempDir, "link")
+	if err := os.Symlink("file1.txt", link); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	fields, err := newEntryFieldSet(entryFields)
+	if err != nil {
+		t.Fatalf("newEntryFieldSet returned error: %v", err)
+	}
+
+	items := map[string]walkItem{}
+	err = walkTree(tempDir, tempDir, defaultWalkOptions(), func(entry walkEntry) error {
+		items[entry.Rel] = newWalkItem(entry.Rel, entry, fields)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walkTree returned error: %v", err)
+	}
+
+	file := items["file1.txt"]
+	if file.Type != "file" {
+		t.Errorf("Expected type file, got %q", file.Type)
+	}
+	if file.Size == nil || *file.Size != int64(len("test content")) {
+		t.Errorf("Unexpected size %v", file.Size)
+	}
+	if file.Mode != "0644" {
+		t.Errorf("Expected mode 0644, got %q", file.Mode)
+	}
+	if file.ModTime == "" {
+		t.Error("Expected mtime to be set")
+	}
+	if file.ChildCount != nil {
+		t.Error("Files should not have a child count")
+	}
+
+	subdir := items["subdir"]
+	if subdir.Type != "dir" {
+		t.Errorf("Expected type dir, got %q", subdir.Type)
+	}
+	if subdir.ChildCount == nil || *subdir.ChildCount != 2 {
+		t.Errorf("Expected 2 children in subdir, got %v", subdir.ChildCount)
+	}
+
+	symlink := items["link"]
+	if symlink.Type != "symlink" {
+		t.Errorf("Expected type symlink, got %q", symlink.Type)
+	}
+	if symlink.SymlinkTarget != "file1.txt" {
+		t.Errorf("Expected symlink target file1.txt, got %q", symlink.SymlinkTarget)
+	}
+}
+
+func TestNewWalkItem_OnlyRequestedFields(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	fields, err := newEntryFieldSet([]string{fieldType})
+	if err != nil {
+		t.Fatalf("newEntryFieldSet returned error: %v", err)
+	}
+
+	err = walkTree(tempDir, tempDir, defaultWalkOptions(), func(entry walkEntry) error {
+		item := newWalkItem(entry.Rel, entry, fields)
+		if item.Size != nil || item.Mode != "" || item.ModTime != "" || item.ChildCount != nil {
+			t.Errorf("Unrequested metadata set for %s: %+v", entry.Rel, item)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walkTree returned error: %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "errors"
+
+// makeFIFO reports that named pipes are not available on this platform
+func makeFIFO(path string) error {
+	return errors.ErrUnsupported
+}
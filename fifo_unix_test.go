@@ -0,0 +1,10 @@
+//go:build unix
+
+package main
+
+import "syscall"
+
+// makeFIFO creates a named pipe at path
+func makeFIFO(path string) error {
+	return syscall.Mkfifo(path, 0644)
+}
@@ -0,0 +1,683 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultDirPerm is used for directories created by the mutating tools
+const defaultDirPerm = 0755
+
+// fsOpResult is the structured content returned by create_directory,
+// move_path, copy_path and delete_path
+type fsOpResult struct {
+	Operation string `json:"operation"`
+	Path      string `json:"path"`             // the path acted on, or the destination of a move or copy
+	Source    string `json:"source,omitempty"` // source of a move or copy
+	Entries   int    `json:"entries"`          // files, directories and links affected
+	Replaced  bool   `json:"replaced"`         // an existing destination was overwritten
+	DryRun    bool   `json:"dry_run"`          // nothing was changed on disk
+}
+
+// fsOpVerbs names each operation in the past tense and as a dry-run intention
+var fsOpVerbs = map[string][2]string{
+	"create_directory": {"Created directory", "Dry run: would create directory"},
+	"move_path":        {"Moved", "Dry run: would move"},
+	"copy_path":        {"Copied", "Dry run: would copy"},
+	"delete_path":      {"Deleted", "Dry run: would delete"},
+}
+
+// summary describes the result in one line of text
+func (r fsOpResult) summary() string {
+	verb := fsOpVerbs[r.Operation][0]
+	if r.DryRun {
+		verb = fsOpVerbs[r.Operation][1]
+	}
+
+	text := verb + " " + r.Path
+	if r.Source != "" {
+		text = fmt.Sprintf("%s %s to %s", verb, r.Source, r.Path)
+	}
+	text += fmt.Sprintf(" (%d entries)", r.Entries)
+	if r.Replaced {
+		text += ", replacing the existing destination"
+	}
+	return text
+}
+
+// createDirectoryTool implements the create_directory tool handler
+func createDirectoryTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		if !cfg.AllowWrite {
+			return nil, errWriteDisabled
+		}
+
+		// Parse the arguments
+		var args struct {
+			Path          string `json:"path"`
+			CreateParents bool   `json:"create_parents"`
+			DryRun        bool   `json:"dry_run"`
+		}
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+		if args.Path == "" {
+			return nil, fmt.Errorf("path is required")
+		}
+
+		target, err := resolveWritePath(cfg, args.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		// Count the directories that will be created, from the target upwards
+		missing := 0
+		for dir := target; ; dir = filepath.Dir(dir) {
+			info, err := os.Stat(dir)
+			if err == nil {
+				if !info.IsDir() {
+					return nil, fmt.Errorf("path is not a directory: %s", virtualPath(relPath(cfg.RootDir, dir)))
+				}
+				break
+			}
+			missing++
+		}
+		if missing == 0 && !args.CreateParents {
+			return nil, fmt.Errorf("directory already exists: %s", args.Path)
+		}
+		if missing > 1 && !args.CreateParents {
+			return nil, fmt.Errorf("parent directory does not exist: %s (set create_parents to create it)", filepath.Dir(args.Path))
+		}
+
+		if !args.DryRun {
+			if err := os.MkdirAll(target, defaultDirPerm); err != nil {
+				return nil, fmt.Errorf("failed to create directory: %w", err)
+			}
+		}
+
+		result := newFSOpResult(cfg, request.Params.Name, "", target, args.DryRun)
+		result.Entries = missing
+		return fsOpToolResult(request, result), nil
+	}
+}
+
+// movePathTool implements the move_path tool handler
+func movePathTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		if !cfg.AllowWrite {
+			return nil, errWriteDisabled
+		}
+
+		// Parse the arguments
+		var args struct {
+			Source      string `json:"source"`
+			Destination string `json:"destination"`
+			Overwrite   bool   `json:"overwrite"`
+			DryRun      bool   `json:"dry_run"`
+		}
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+
+		src, dst, err := resolveSourceAndDestination(cfg, args.Source, args.Destination)
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := countEntries(src)
+		if err != nil {
+			return nil, err
+		}
+		replaced, err := checkDestination(dst, args.Overwrite)
+		if err != nil {
+			return nil, err
+		}
+
+		if !args.DryRun {
+			if err := movePath(src, dst); err != nil {
+				return nil, err
+			}
+		}
+
+		result := newFSOpResult(cfg, request.Params.Name, src, dst, args.DryRun)
+		result.Entries = entries
+		result.Replaced = replaced
+		return fsOpToolResult(request, result), nil
+	}
+}
+
+// copyPathTool implements the copy_path tool handler
+func copyPathTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		if !cfg.AllowWrite {
+			return nil, errWriteDisabled
+		}
+
+		// Parse the arguments
+		var args struct {
+			Source      string `json:"source"`
+			Destination string `json:"destination"`
+			Recursive   bool   `json:"recursive"`
+			Overwrite   bool   `json:"overwrite"`
+			DryRun      bool   `json:"dry_run"`
+		}
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+
+		src, dst, err := resolveSourceAndDestination(cfg, args.Source, args.Destination)
+		if err != nil {
+			return nil, err
+		}
+
+		// The source can vanish between resolving and here
+		info, err := os.Lstat(src)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("path does not exist: %s", args.Source)
+			}
+			return nil, fmt.Errorf("failed to stat source: %w", err)
+		}
+		if info.IsDir() && !args.Recursive {
+			return nil, fmt.Errorf("source is a directory: %s (set recursive to copy it)", args.Source)
+		}
+
+		entries, err := countEntries(src)
+		if err != nil {
+			return nil, err
+		}
+		replaced, err := checkDestination(dst, args.Overwrite)
+		if err != nil {
+			return nil, err
+		}
+
+		if !args.DryRun {
+			if err := copyIntoPlace(src, dst); err != nil {
+				return nil, err
+			}
+		}
+
+		result := newFSOpResult(cfg, request.Params.Name, src, dst, args.DryRun)
+		result.Entries = entries
+		result.Replaced = replaced
+		return fsOpToolResult(request, result), nil
+	}
+}
+
+// deletePathTool implements the delete_path tool handler
+func deletePathTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		if !cfg.AllowWrite {
+			return nil, errWriteDisabled
+		}
+
+		// Parse the arguments
+		var args struct {
+			Path      string `json:"path"`
+			Recursive bool   `json:"recursive"`
+			DryRun    bool   `json:"dry_run"`
+		}
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+		if args.Path == "" {
+			return nil, fmt.Errorf("path is required")
+		}
+
+		target, err := resolveEntryPath(cfg, args.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := os.Lstat(target)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("path does not exist: %s", args.Path)
+			}
+			return nil, fmt.Errorf("failed to stat path: %w", err)
+		}
+
+		entries, err := countEntries(target)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() && entries > 1 && !args.Recursive {
+			return nil, fmt.Errorf("directory is not empty: %s (set recursive to delete it and its contents)", args.Path)
+		}
+
+		if !args.DryRun {
+			if err := os.RemoveAll(target); err != nil {
+				return nil, fmt.Errorf("failed to delete path: %w", err)
+			}
+		}
+
+		result := newFSOpResult(cfg, request.Params.Name, "", target, args.DryRun)
+		result.Entries = entries
+		return fsOpToolResult(request, result), nil
+	}
+}
+
+// resolveSourceAndDestination resolves and validates the paths of a move or copy
+func resolveSourceAndDestination(cfg *serverConfig, source, destination string) (string, string, error) {
+	if source == "" || destination == "" {
+		return "", "", fmt.Errorf("source and destination are required")
+	}
+
+	src, err := resolveEntryPath(cfg, source)
+	if err != nil {
+		return "", "", fmt.Errorf("source: %w", err)
+	}
+	dst, err := resolveEntryPath(cfg, destination)
+	if err != nil {
+		return "", "", fmt.Errorf("destination: %w", err)
+	}
+
+	if _, err := os.Lstat(src); err != nil {
+		if os.IsNotExist(err) {
+			return "", "", fmt.Errorf("source does not exist: %s", source)
+		}
+		return "", "", fmt.Errorf("failed to stat source: %w", err)
+	}
+	if src == dst {
+		return "", "", fmt.Errorf("source and destination are the same path")
+	}
+	if withinRoot(src, dst) {
+		return "", "", fmt.Errorf("destination is inside the source: %s", destination)
+	}
+
+	info, err := os.Stat(filepath.Dir(dst))
+	if err != nil || !info.IsDir() {
+		return "", "", fmt.Errorf("parent directory of destination does not exist: %s", filepath.Dir(destination))
+	}
+	return src, dst, nil
+}
+
+// checkDestination checks whether dst may be written and reports whether
+// something there will be replaced. An existing file or empty directory may be
+// replaced when overwrite is set; a non-empty directory never is. Nothing is
+// removed here: the new entry is moved over the old one once it is complete.
+func checkDestination(dst string, overwrite bool) (bool, error) {
+	info, err := os.Lstat(dst)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat destination: %w", err)
+	}
+
+	if !overwrite {
+		return false, fmt.Errorf("destination already exists: %s (set overwrite to replace it)", filepath.Base(dst))
+	}
+	if info.IsDir() {
+		entries, err := os.ReadDir(dst)
+		if err != nil {
+			return false, fmt.Errorf("failed to read destination: %w", err)
+		}
+		if len(entries) > 0 {
+			return false, fmt.Errorf("destination is a non-empty directory and will not be replaced: %s", filepath.Base(dst))
+		}
+	}
+	return true, nil
+}
+
+// movePath renames src to dst, replacing a file or empty directory there, and
+// falls back to copy and delete when they are on different filesystems
+func movePath(src, dst string) error {
+	err := replacePath(src, dst)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, syscall.EXDEV) {
+		return fmt.Errorf("failed to move path: %w", err)
+	}
+
+	if err := copyIntoPlace(src, dst); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(src); err != nil {
+		return fmt.Errorf("copied to destination but failed to remove source: %w", err)
+	}
+	return nil
+}
+
+// copyIntoPlace copies src to a staging directory beside dst and then moves
+// the copy over dst, so an existing destination is only replaced by a
+// complete copy and a failed copy leaves it untouched
+func copyIntoPlace(src, dst string) error {
+	staging, err := os.MkdirTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".copy-*")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	staged := filepath.Join(staging, filepath.Base(dst))
+	if err := copyTree(src, staged); err != nil {
+		return err
+	}
+	if err := replacePath(staged, dst); err != nil {
+		return fmt.Errorf("failed to move copy into place: %w", err)
+	}
+	return nil
+}
+
+// replacePath renames from to dst. Rename replaces a file, or an empty
+// directory with a directory, atomically; when from and dst differ in type the
+// old entry is set aside and only removed once from is in place.
+func replacePath(from, dst string) error {
+	err := os.Rename(from, dst)
+	if err == nil || errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if _, statErr := os.Lstat(dst); statErr != nil {
+		return err
+	}
+
+	aside, err := os.MkdirTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".old-*")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	old := filepath.Join(aside, filepath.Base(dst))
+	if err := os.Rename(dst, old); err != nil {
+		os.Remove(aside)
+		return err
+	}
+	if err := os.Rename(from, dst); err != nil {
+		os.Rename(old, dst)
+		os.Remove(aside)
+		return err
+	}
+
+	// The replaced entry is a file or an empty directory
+	if err := os.Remove(old); err != nil {
+		log.Printf("Failed to remove replaced %s: %v", old, err)
+		return nil
+	}
+	os.Remove(aside)
+	return nil
+}
+
+// copyTree copies src to dst, recursing into directories. Permissions are
+// preserved and symlinks are copied as links.
+func copyTree(src, dst string) error {
+	// Directories are created writable so their contents can be copied; their
+	// own modes are applied once everything is in place
+	type dirMode struct {
+		path string
+		perm os.FileMode
+	}
+	var dirs []dirMode
+
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+
+		switch {
+		case d.IsDir():
+			if err := os.Mkdir(target, info.Mode().Perm()|0700); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+			dirs = append(dirs, dirMode{target, info.Mode().Perm()})
+			return nil
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("failed to read link: %w", err)
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return fmt.Errorf("failed to create link: %w", err)
+			}
+			return nil
+		case d.Type().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return fmt.Errorf("cannot copy special file: %s", path)
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i].path, dirs[i].perm); err != nil {
+			return fmt.Errorf("failed to set permissions: %w", err)
+		}
+	}
+	return nil
+}
+
+// copyFile copies the regular file src to the new file dst
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+	return os.Chmod(dst, perm)
+}
+
+// countEntries returns the number of entries in the tree rooted at p, counting
+// p itself. Symlinks are not followed.
+func countEntries(p string) (int, error) {
+	count := 0
+	err := filepath.WalkDir(p, func(_ string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", p, err)
+	}
+	return count, nil
+}
+
+// newFSOpResult builds the result for an operation on target, moved or copied from src if set
+func newFSOpResult(cfg *serverConfig, operation, src, target string, dryRun bool) fsOpResult {
+	formatter, _ := newPathFormatter(cfg.PathStyle, cfg.RootDir, ".")
+	result := fsOpResult{
+		Operation: operation,
+		Path:      formatter.format(relPath(cfg.RootDir, target)),
+		DryRun:    dryRun,
+	}
+	if src != "" {
+		result.Source = formatter.format(relPath(cfg.RootDir, src))
+	}
+	return result
+}
+
+// fsOpToolResult logs the completed operation and wraps it as a tool result
+func fsOpToolResult(request mcp.CallToolRequest, result fsOpResult) *mcp.CallToolResult {
+	text := result.summary()
+	log.Printf("[TOOL COMPLETED] %s - %s", request.Params.Name, text)
+	return mcp.NewToolResultStructured(result, text)
+}
+
+// fsOpOutputSchema describes fsOpResult for clients that validate structured content
+const fsOpOutputSchema = `{
+	"type": "object",
+	"properties": {
+		"operation": {"type": "string"},
+		"path": {"type": "string"},
+		"source": {"type": "string"},
+		"entries": {"type": "integer", "description": "Files, directories and links affected"},
+		"replaced": {"type": "boolean"},
+		"dry_run": {"type": "boolean"}
+	},
+	"required": ["operation", "path", "entries", "replaced", "dry_run"]
+}`
+
+// dryRunProperty is the schema of the dry_run argument shared by these tools
+var dryRunProperty = map[string]interface{}{
+	"type":        "boolean",
+	"description": "Report what would happen without changing anything",
+	"default":     false,
+}
+
+// createDirectoryServerTool defines the create_directory tool
+func createDirectoryServerTool(cfg *serverConfig) server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "create_directory",
+			Description: "Creates a directory under the root",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Directory to create (relative to the root, e.g. '/src/pkg')",
+					},
+					"create_parents": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Create missing parent directories and succeed if the directory exists",
+						"default":     false,
+					},
+					"dry_run": dryRunProperty,
+				},
+				Required: []string{"path"},
+			},
+			RawOutputSchema: json.RawMessage(fsOpOutputSchema),
+		},
+		Handler: createDirectoryTool(cfg),
+	}
+}
+
+// movePathServerTool defines the move_path tool
+func movePathServerTool(cfg *serverConfig) server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "move_path",
+			Description: "Moves or renames a file or directory under the root",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"source": map[string]interface{}{
+						"type":        "string",
+						"description": "Path to move (relative to the root)",
+					},
+					"destination": map[string]interface{}{
+						"type":        "string",
+						"description": "New path (relative to the root); its parent directory must exist",
+					},
+					"overwrite": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Replace an existing destination file or empty directory",
+						"default":     false,
+					},
+					"dry_run": dryRunProperty,
+				},
+				Required: []string{"source", "destination"},
+			},
+			RawOutputSchema: json.RawMessage(fsOpOutputSchema),
+		},
+		Handler: movePathTool(cfg),
+	}
+}
+
+// copyPathServerTool defines the copy_path tool
+func copyPathServerTool(cfg *serverConfig) server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "copy_path",
+			Description: "Copies a file or directory under the root",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"source": map[string]interface{}{
+						"type":        "string",
+						"description": "Path to copy (relative to the root)",
+					},
+					"destination": map[string]interface{}{
+						"type":        "string",
+						"description": "Path of the copy (relative to the root); its parent directory must exist",
+					},
+					"recursive": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Copy directories and their contents",
+						"default":     false,
+					},
+					"overwrite": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Replace an existing destination file or empty directory",
+						"default":     false,
+					},
+					"dry_run": dryRunProperty,
+				},
+				Required: []string{"source", "destination"},
+			},
+			RawOutputSchema: json.RawMessage(fsOpOutputSchema),
+		},
+		Handler: copyPathTool(cfg),
+	}
+}
+
+// deletePathServerTool defines the delete_path tool
+func deletePathServerTool(cfg *serverConfig) server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "delete_path",
+			Description: "Permanently deletes a file, symlink or directory under the root",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Path to delete (relative to the root); symlinks are removed, not followed",
+					},
+					"recursive": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Delete non-empty directories and everything in them",
+						"default":     false,
+					},
+					"dry_run": dryRunProperty,
+				},
+				Required: []string{"path"},
+			},
+			RawOutputSchema: json.RawMessage(fsOpOutputSchema),
+		},
+		Handler: deletePathTool(cfg),
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// fsOp calls one of the tree reorganization tools and returns its structured result
+func fsOp(t *testing.T, cfg *serverConfig, name, arguments string) fsOpResult {
+	t.Helper()
+
+	handlers := map[string]func(*serverConfig) server.ToolHandlerFunc{
+		"create_directory": createDirectoryTool,
+		"move_path":        movePathTool,
+		"copy_path":        copyPathTool,
+		"delete_path":      deletePathTool,
+	}
+	result, err := callTool(handlers[name](cfg), name, arguments)
+	if err != nil {
+		t.Fatalf("%s returned error for %s: %v", name, arguments, err)
+	}
+
+	op, ok := result.StructuredContent.(fsOpResult)
+	if !ok {
+		t.Fatalf("StructuredContent is not fsOpResult, got %T", result.StructuredContent)
+	}
+	return op
+}
+
+func TestCreateDirectoryTool(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := writeConfig(tempDir)
+
+	op := fsOp(t, cfg, "create_directory", `{"path": "/a/b/c", "create_parents": true, "dry_run": true}`)
+	if !op.DryRun || op.Entries != 3 {
+		t.Errorf("Unexpected dry run result %+v", op)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "a")); !os.IsNotExist(err) {
+		t.Fatal("Dry run created a directory")
+	}
+
+	fsOp(t, cfg, "create_directory", `{"path": "/a/b/c", "create_parents": true}`)
+	if info, err := os.Stat(filepath.Join(tempDir, "a", "b", "c")); err != nil || !info.IsDir() {
+		t.Fatalf("Expected directory to be created: %v", err)
+	}
+
+	fsOp(t, cfg, "create_directory", `{"path": "/single"}`)
+
+	for arguments, errText := range map[string]string{
+		`{"path": "/single"}`:        "already exists",
+		`{"path": "/x/y"}`:           "parent directory does not exist",
+		`{"path": "/file1.txt/sub"}`: "not a directory",
+	} {
+		_, err := callTool(createDirectoryTool(cfg), "create_directory", arguments)
+		if err == nil || !contains(err.Error(), errText) {
+			t.Errorf("Expected '%s' error for %s, got %v", errText, arguments, err)
+		}
+	}
+}
+
+func TestMovePathTool(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := writeConfig(tempDir)
+
+	op := fsOp(t, cfg, "move_path", `{"source": "/subdir", "destination": "/moved", "dry_run": true}`)
+	if op.Entries != 4 || !op.DryRun {
+		t.Errorf("Unexpected dry run result %+v", op)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "subdir")); err != nil {
+		t.Fatal("Dry run moved the source")
+	}
+
+	fsOp(t, cfg, "move_path", `{"source": "/subdir", "destination": "/moved"}`)
+	if data, err := os.ReadFile(filepath.Join(tempDir, "moved", "file2.go")); err != nil || string(data) != "package main" {
+		t.Errorf("Expected moved contents, got %q (%v)", data, err)
+	}
+
+	// Overwrite protection
+	writeTestFile(t, filepath.Join(tempDir, "other.txt"), "other")
+	_, err := callTool(movePathTool(cfg), "move_path", `{"source": "/other.txt", "destination": "/file1.txt"}`)
+	if err == nil || !contains(err.Error(), "already exists") {
+		t.Errorf("Expected overwrite protection, got %v", err)
+	}
+	op = fsOp(t, cfg, "move_path", `{"source": "/other.txt", "destination": "/file1.txt", "overwrite": true}`)
+	if !op.Replaced {
+		t.Errorf("Expected replaced flag, got %+v", op)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "file1.txt")); string(data) != "other" {
+		t.Errorf("Expected overwritten destination, got %q", data)
+	}
+}
+
+func TestCopyPathTool(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := writeConfig(tempDir)
+	if err := os.Symlink("file2.go", filepath.Join(tempDir, "subdir", "link.go")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	if err := os.Chmod(filepath.Join(tempDir, "subdir", "file2.go"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := callTool(copyPathTool(cfg), "copy_path", `{"source": "/subdir", "destination": "/copy"}`)
+	if err == nil || !contains(err.Error(), "set recursive") {
+		t.Errorf("Expected recursive error, got %v", err)
+	}
+
+	op := fsOp(t, cfg, "copy_path", `{"source": "/subdir", "destination": "/copy", "recursive": true}`)
+	if op.Entries != 5 {
+		t.Errorf("Expected 5 entries copied, got %+v", op)
+	}
+
+	info, err := os.Stat(filepath.Join(tempDir, "copy", "file2.go"))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected copied file with mode 0600, got %v (%v)", info, err)
+	}
+	if link, err := os.Readlink(filepath.Join(tempDir, "copy", "link.go")); err != nil || link != "file2.go" {
+		t.Errorf("Expected symlink to be copied as a link, got %q (%v)", link, err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "subdir", "file2.go")); err != nil {
+		t.Error("Copy removed the source")
+	}
+
+	_, err = callTool(copyPathTool(cfg), "copy_path", `{"source": "/subdir", "destination": "/subdir/inner", "recursive": true}`)
+	if err == nil || !contains(err.Error(), "inside the source") {
+		t.Errorf("Expected copy-into-itself error, got %v", err)
+	}
+}
+
+func TestCopyPathTool_FailedOverwriteKeepsDestination(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := writeConfig(tempDir)
+
+	// A socket cannot be copied, so copying this directory fails partway
+	writeTestFile(t, filepath.Join(tempDir, "src", "a.txt"), "a")
+	listener, err := net.Listen("unix", filepath.Join(tempDir, "src", "z.sock"))
+	if err != nil {
+		t.Skipf("Unix sockets not supported: %v", err)
+	}
+	defer listener.Close()
+
+	_, err = callTool(copyPathTool(cfg), "copy_path", `{"source": "/src", "destination": "/file1.txt", "recursive": true, "overwrite": true}`)
+	if err == nil || !contains(err.Error(), "special file") {
+		t.Fatalf("Expected the copy to fail, got %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(tempDir, "file1.txt")); err != nil || string(data) != "test content" {
+		t.Errorf("Expected the destination to survive the failed copy, got %q (%v)", data, err)
+	}
+
+	// No staging directories are left behind
+	entries, _ := os.ReadDir(tempDir)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			t.Errorf("Unexpected leftover %s", e.Name())
+		}
+	}
+}
+
+func TestMovePathTool_ReplaceDifferentType(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := writeConfig(tempDir)
+
+	// A file replaces an empty directory
+	writeTestFile(t, filepath.Join(tempDir, "other.txt"), "other")
+	if op := fsOp(t, cfg, "move_path", `{"source": "/other.txt", "destination": "/emptydir", "overwrite": true}`); !op.Replaced {
+		t.Errorf("Expected replaced flag, got %+v", op)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "emptydir")); string(data) != "other" {
+		t.Errorf("Expected the file in place of the directory, got %q", data)
+	}
+
+	// A directory replaces a file, whether moved or copied
+	fsOp(t, cfg, "copy_path", `{"source": "/subdir", "destination": "/emptydir", "recursive": true, "overwrite": true}`)
+	fsOp(t, cfg, "move_path", `{"source": "/subdir", "destination": "/file1.txt", "overwrite": true}`)
+	for _, dir := range []string{"emptydir", "file1.txt"} {
+		if data, err := os.ReadFile(filepath.Join(tempDir, dir, "file2.go")); err != nil || string(data) != "package main" {
+			t.Errorf("Expected the directory in place at %s, got %q (%v)", dir, data, err)
+		}
+	}
+
+	entries, _ := os.ReadDir(tempDir)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			t.Errorf("Unexpected leftover %s", e.Name())
+		}
+	}
+}
+
+func TestDeletePathTool(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := writeConfig(tempDir)
+	outside := t.TempDir()
+	writeTestFile(t, filepath.Join(outside, "keep.txt"), "keep")
+	if err := os.Symlink(outside, filepath.Join(tempDir, "subdir", "out")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	cfg.SymlinkPolicy = symlinkPolicyFollow
+
+	_, err := callTool(deletePathTool(cfg), "delete_path", `{"path": "/subdir"}`)
+	if err == nil || !contains(err.Error(), "not empty") {
+		t.Errorf("Expected non-empty error, got %v", err)
+	}
+
+	// Deleting a symlink removes the link, not its target
+	fsOp(t, cfg, "delete_path", `{"path": "/subdir/out"}`)
+	if _, err := os.Stat(filepath.Join(outside, "keep.txt")); err != nil {
+		t.Fatalf("Deleting a symlink removed its target: %v", err)
+	}
+
+	op := fsOp(t, cfg, "delete_path", `{"path": "/subdir", "recursive": true}`)
+	if op.Entries != 4 {
+		t.Errorf("Expected 4 entries deleted, got %+v", op)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "subdir")); !os.IsNotExist(err) {
+		t.Error("Expected directory to be deleted")
+	}
+
+	for arguments, errText := range map[string]string{
+		`{"path": "/"}`:            "root directory itself",
+		`{"path": "/../etc"}`:      "outside root directory",
+		`{"path": "/missing.txt"}`: "does not exist",
+	} {
+		_, err := callTool(deletePathTool(cfg), "delete_path", arguments)
+		if err == nil || !contains(err.Error(), errText) {
+			t.Errorf("Expected '%s' error for %s, got %v", errText, arguments, err)
+		}
+	}
+
+	// Mutating tools refuse to run on a read-only server
+	_, err = callTool(deletePathTool(newServerConfig(tempDir)), "delete_path", `{"path": "/file1.txt"}`)
+	if err != errWriteDisabled {
+		t.Errorf("Expected errWriteDisabled, got %v", err)
+	}
+}
@@ -0,0 +1,716 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"lukechampine.com/blake3"
+)
+
+// Supported digest algorithms
+const (
+	hashSHA256 = "sha256"
+	hashSHA1   = "sha1"
+	hashMD5    = "md5"
+	hashBLAKE3 = "blake3"
+)
+
+// hashAlgorithms maps algorithm names to hash constructors
+var hashAlgorithms = map[string]func() hash.Hash{
+	hashSHA256: sha256.New,
+	hashSHA1:   sha1.New,
+	hashMD5:    md5.New,
+	hashBLAKE3: func() hash.Hash { return blake3.New(32, nil) },
+}
+
+// defaultMaxHashBytes caps how much file data one hash_files call reads
+const defaultMaxHashBytes = 1 << 30
+
+// errHashByteCap marks files left unhashed because max_bytes was used up
+var errHashByteCap = errors.New("skipped: max_bytes reached")
+
+// fileHash is a single hash_files entry. Files that could not be hashed carry
+// an error instead of a digest.
+type fileHash struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Digest string `json:"digest,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// hashResult is the structured content returned by hash_files
+type hashResult struct {
+	Algorithm   string     `json:"algorithm"`
+	Files       []fileHash `json:"files"`
+	FilesHashed int        `json:"files_hashed"`
+	BytesHashed int64      `json:"bytes_hashed"`
+	Truncated   bool       `json:"truncated"` // some files were skipped because of max_bytes
+}
+
+// checksumMismatch is a manifest entry whose file has a different digest
+type checksumMismatch struct {
+	Path     string `json:"path"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// checksumError is a manifest entry that could not be checked
+type checksumError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// verifyResult is the structured content returned by verify_checksums
+type verifyResult struct {
+	Manifest       string             `json:"manifest"`
+	Checked        int                `json:"checked"`
+	OK             int                `json:"ok"`
+	Mismatches     []checksumMismatch `json:"mismatches"`
+	Missing        []string           `json:"missing"`
+	Errors         []checksumError    `json:"errors,omitempty"`
+	MalformedLines []int              `json:"malformed_lines,omitempty"` // 1-based manifest lines that could not be parsed
+	Valid          bool               `json:"valid"`                     // the manifest listed files and every one matched
+}
+
+// hashJob is a file queued for hashing, with its position in the result
+type hashJob struct {
+	index     int
+	path      string // absolute filesystem path
+	rel       string // root-relative path for output
+	algorithm string
+	size      int64
+	limit     int64 // hash only the first limit bytes when positive
+	err       error // set when the file is to be reported without hashing
+}
+
+// hashOutcome is the result of hashing one job
+type hashOutcome struct {
+	hashJob
+	digest string
+}
+
+// hashFilesTool implements the hash_files tool handler
+func hashFilesTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		// Parse the arguments
+		var args struct {
+			Path          string   `json:"path"`
+			Algorithm     string   `json:"algorithm"`
+			Include       []string `json:"include"`
+			Exclude       []string `json:"exclude"`
+			MaxBytes      int64    `json:"max_bytes"`
+			IncludeHidden bool     `json:"include_hidden"`
+			RespectIgnore bool     `json:"respect_ignore_files"`
+		}
+		args.Path = "/"
+		args.Algorithm = hashSHA256
+		args.MaxBytes = defaultMaxHashBytes
+		args.IncludeHidden = true
+		args.RespectIgnore = cfg.RespectIgnoreFiles
+
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+
+		if _, ok := hashAlgorithms[args.Algorithm]; !ok {
+			return nil, fmt.Errorf("invalid algorithm %q: must be one of sha256, sha1, md5, blake3", args.Algorithm)
+		}
+		if args.MaxBytes <= 0 {
+			return nil, fmt.Errorf("max_bytes must be positive")
+		}
+
+		opts := defaultWalkOptions()
+		opts.Type = entryTypeFiles
+		opts.IncludeHidden = args.IncludeHidden
+		opts.RespectIgnore = args.RespectIgnore
+		opts.Ignore = cfg.IgnoreRules
+
+		var err error
+		if opts.Include, err = newPathMatcher(args.Include, patternSyntaxGlob); err != nil {
+			return nil, fmt.Errorf("invalid include: %w", err)
+		}
+		if opts.Exclude, err = newPathMatcher(args.Exclude, patternSyntaxGlob); err != nil {
+			return nil, fmt.Errorf("invalid exclude: %w", err)
+		}
+
+		// Map the input path to actual filesystem path, enforcing root containment
+		target, err := resolvePath(cfg, args.Path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Lstat(target); os.IsNotExist(err) {
+			return nil, fmt.Errorf("path does not exist: %s", args.Path)
+		}
+
+		result, err := hashTree(ctx, cfg.RootDir, target, opts, args.Algorithm, args.MaxBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		formatter, _ := newPathFormatter(cfg.PathStyle, cfg.RootDir, relPath(cfg.RootDir, target))
+		for i := range result.Files {
+			result.Files[i].Path = formatter.format(result.Files[i].Path)
+		}
+
+		summary := fmt.Sprintf("Hashed %d files (%d bytes) with %s", result.FilesHashed, result.BytesHashed, result.Algorithm)
+		if result.Truncated {
+			summary += fmt.Sprintf(" (some files skipped at max_bytes=%d)", args.MaxBytes)
+		}
+
+		log.Printf("[TOOL COMPLETED] %s - %s", request.Params.Name, summary)
+
+		return mcp.NewToolResultStructured(result, formatHashes(summary, result.Files)), nil
+	}
+}
+
+// hashTree hashes every file walkTree reports using a bounded pool of
+// workers. Files are returned in walk order with root-relative paths; once
+// maxBytes is used up, remaining files are listed without a digest.
+func hashTree(ctx context.Context, rootDir, target string, opts walkOptions, algorithm string, maxBytes int64) (hashResult, error) {
+	jobs := make(chan hashJob)
+	outcomes := hashWorkers(ctx, jobs)
+
+	// Feed files from the traversal to the workers, reserving their size
+	// against the byte cap in walk order so the result is deterministic
+	var walkErr error
+	go func() {
+		defer close(jobs)
+		budget := maxBytes
+		index := 0
+		walkErr = walkTree(rootDir, target, opts, func(entry walkEntry) error {
+			job := hashJob{index: index, path: entry.Path, rel: entry.Rel, algorithm: algorithm}
+			index++
+			if info, err := entry.Entry.Info(); err == nil {
+				job.size = info.Size()
+			}
+			if job.size > budget {
+				job.err = errHashByteCap
+			} else {
+				budget -= job.size
+			}
+
+			select {
+			case jobs <- job:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	var collected []hashOutcome
+	for out := range outcomes {
+		collected = append(collected, out)
+	}
+
+	// outcomes is closed only after the walk goroutine has closed jobs, so walkErr is settled
+	if err := ctx.Err(); err != nil {
+		return hashResult{}, err
+	}
+	if walkErr != nil {
+		return hashResult{}, fmt.Errorf("failed to walk directory: %w", walkErr)
+	}
+
+	sort.Slice(collected, func(i, j int) bool { return collected[i].index < collected[j].index })
+
+	result := hashResult{Algorithm: algorithm, Files: make([]fileHash, 0, len(collected))}
+	for _, out := range collected {
+		file := fileHash{Path: out.rel, Size: out.size, Digest: out.digest}
+		switch {
+		case errors.Is(out.err, errHashByteCap):
+			file.Error = out.err.Error()
+			result.Truncated = true
+		case out.err != nil:
+			file.Error = out.err.Error()
+		default:
+			result.FilesHashed++
+			result.BytesHashed += out.size
+		}
+		result.Files = append(result.Files, file)
+	}
+	return result, nil
+}
+
+// hashWorkers hashes the files sent on jobs with a bounded pool of workers.
+// The returned channel is closed once jobs has been closed and drained.
+func hashWorkers(ctx context.Context, jobs <-chan hashJob) <-chan hashOutcome {
+	outcomes := make(chan hashOutcome)
+
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				out := hashOutcome{hashJob: job}
+				if job.err == nil {
+					out.digest, out.size, out.err = hashFile(ctx, job.path, job.algorithm, job.limit)
+				}
+				outcomes <- out
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+	return outcomes
+}
+
+// hashFile streams the file at p (or its first limit bytes, when limit is
+// positive) through the named algorithm and returns the hex digest and the
+// number of bytes read
+func hashFile(ctx context.Context, p, algorithm string, limit int64) (string, int64, error) {
+	// Check the type before opening: a FIFO or device would block or never end
+	info, err := os.Stat(p)
+	if err != nil {
+		return "", 0, err
+	}
+	if info.IsDir() {
+		return "", 0, fmt.Errorf("is a directory")
+	}
+	if !info.Mode().IsRegular() {
+		return "", 0, fmt.Errorf("not a regular file")
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if limit > 0 {
+		r = io.LimitReader(f, limit)
+	}
+
+	h := hashAlgorithms[algorithm]()
+	n, err := io.Copy(h, contextReader{ctx: ctx, r: r})
+	if err != nil {
+		return "", n, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// contextReader stops a long read as soon as its context is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// formatHashes renders hash_files results in sha256sum style, so the text can
+// be saved as a manifest for verify_checksums
+func formatHashes(summary string, files []fileHash) string {
+	var b strings.Builder
+	b.WriteString(summary)
+	b.WriteString("\n\n")
+	for _, f := range files {
+		if f.Error != "" {
+			fmt.Fprintf(&b, "%s: %s\n", f.Path, f.Error)
+		} else {
+			fmt.Fprintf(&b, "%s  %s\n", f.Digest, f.Path)
+		}
+	}
+	return b.String()
+}
+
+// checksumEntry is one parsed manifest line
+type checksumEntry struct {
+	line      int
+	name      string
+	digest    string // lower-case hex
+	algorithm string // set by BSD-style lines, empty otherwise
+}
+
+var (
+	// gnuChecksumLine matches sha256sum output: "<hex>  <name>" or "<hex> *<name>",
+	// with a leading backslash when the name is escaped
+	gnuChecksumLine = regexp.MustCompile(`^(\\?)([0-9a-fA-F]+) [ *](.+)$`)
+
+	// bsdChecksumLine matches tagged output: "SHA256 (<name>) = <hex>"
+	bsdChecksumLine = regexp.MustCompile(`^([A-Za-z0-9-]+) \((.+)\) = ([0-9a-fA-F]+)$`)
+
+	// checksumNameEscapes undoes the escaping sha256sum applies to names
+	checksumNameEscapes = strings.NewReplacer(`\\`, `\`, `\n`, "\n")
+)
+
+// parseChecksumManifest parses a SHA256SUMS-style manifest in GNU or BSD
+// format. Blank lines and '#' comments are skipped; other lines that do not
+// parse are returned as malformed.
+func parseChecksumManifest(data string) (entries []checksumEntry, malformed []int) {
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if m := bsdChecksumLine.FindStringSubmatch(line); m != nil {
+			algorithm := strings.ToLower(strings.ReplaceAll(m[1], "-", ""))
+			if _, ok := hashAlgorithms[algorithm]; ok {
+				entries = append(entries, checksumEntry{line: i + 1, name: m[2], digest: strings.ToLower(m[3]), algorithm: algorithm})
+				continue
+			}
+		}
+
+		if m := gnuChecksumLine.FindStringSubmatch(line); m != nil {
+			name := m[3]
+			if m[1] != "" {
+				name = checksumNameEscapes.Replace(name)
+			}
+			entries = append(entries, checksumEntry{line: i + 1, name: name, digest: strings.ToLower(m[2])})
+			continue
+		}
+
+		malformed = append(malformed, i+1)
+	}
+	return entries, malformed
+}
+
+// algorithmForDigest infers the algorithm of an untagged digest from its
+// length. 64-character digests are taken to be SHA-256.
+func algorithmForDigest(digest string) (string, error) {
+	switch len(digest) {
+	case 32:
+		return hashMD5, nil
+	case 40:
+		return hashSHA1, nil
+	case 64:
+		return hashSHA256, nil
+	}
+	return "", fmt.Errorf("cannot infer the algorithm of a %d-character digest; set algorithm", len(digest))
+}
+
+// verifyChecksumsTool implements the verify_checksums tool handler
+func verifyChecksumsTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		// Parse the arguments
+		var args struct {
+			Manifest  string `json:"manifest"`
+			Algorithm string `json:"algorithm"`
+		}
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+
+		if args.Manifest == "" {
+			return nil, fmt.Errorf("manifest is required")
+		}
+		if _, ok := hashAlgorithms[args.Algorithm]; args.Algorithm != "" && !ok {
+			return nil, fmt.Errorf("invalid algorithm %q: must be one of sha256, sha1, md5, blake3", args.Algorithm)
+		}
+
+		// Map the input path to actual filesystem path, enforcing root containment
+		manifest, err := resolvePath(cfg, args.Manifest)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(manifest)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("manifest does not exist: %s", args.Manifest)
+			}
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+
+		entries, malformed := parseChecksumManifest(string(data))
+
+		formatter, _ := newPathFormatter(cfg.PathStyle, cfg.RootDir, ".")
+		result := verifyResult{
+			Manifest:       formatter.format(relPath(cfg.RootDir, manifest)),
+			Mismatches:     []checksumMismatch{},
+			Missing:        []string{},
+			MalformedLines: malformed,
+		}
+
+		// Names are relative to the manifest's directory, as with sha256sum -c
+		// run next to it; a leading slash makes a name relative to the root
+		manifestDir := relPath(cfg.RootDir, filepath.Dir(manifest))
+		jobs := make([]hashJob, len(entries))
+		for i, entry := range entries {
+			input := entry.name
+			if !strings.HasPrefix(input, "/") {
+				input = "/" + path.Join(manifestDir, input)
+			}
+
+			job := hashJob{index: i, rel: input, algorithm: entry.algorithm}
+			if job.algorithm == "" {
+				job.algorithm = args.Algorithm
+			}
+			if job.algorithm == "" {
+				job.algorithm, job.err = algorithmForDigest(entry.digest)
+			}
+			if job.err == nil {
+				if job.path, job.err = resolvePath(cfg, input); job.err == nil {
+					job.rel = relPath(cfg.RootDir, job.path)
+				}
+			}
+			jobs[i] = job
+		}
+
+		outcomes, err := hashJobs(ctx, jobs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, out := range outcomes {
+			outPath := out.rel
+			if out.path != "" {
+				outPath = formatter.format(out.rel)
+			}
+			expected := entries[out.index].digest
+
+			switch {
+			case os.IsNotExist(out.err):
+				result.Missing = append(result.Missing, outPath)
+			case out.err != nil:
+				result.Errors = append(result.Errors, checksumError{Path: outPath, Error: out.err.Error()})
+			case out.digest != expected:
+				result.Checked++
+				result.Mismatches = append(result.Mismatches, checksumMismatch{Path: outPath, Expected: expected, Actual: out.digest})
+			default:
+				result.Checked++
+				result.OK++
+			}
+		}
+		result.Valid = len(entries) > 0 && result.OK == len(entries) && len(malformed) == 0
+
+		summary := fmt.Sprintf("Checked %d of %d files: %d OK, %d mismatched, %d missing",
+			result.Checked, len(entries), result.OK, len(result.Mismatches), len(result.Missing))
+		if len(result.Errors) > 0 {
+			summary += fmt.Sprintf(", %d errors", len(result.Errors))
+		}
+		if len(malformed) > 0 {
+			summary += fmt.Sprintf(", %d malformed lines", len(malformed))
+		}
+
+		log.Printf("[TOOL COMPLETED] %s - %s", request.Params.Name, summary)
+
+		return mcp.NewToolResultStructured(result, formatVerify(summary, result)), nil
+	}
+}
+
+// hashJobs hashes a fixed list of jobs concurrently and returns the outcomes
+// in job order
+func hashJobs(ctx context.Context, list []hashJob) ([]hashOutcome, error) {
+	jobs := make(chan hashJob)
+	outcomes := hashWorkers(ctx, jobs)
+
+	go func() {
+		defer close(jobs)
+		for _, job := range list {
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	collected := make([]hashOutcome, 0, len(list))
+	for out := range outcomes {
+		collected = append(collected, out)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(collected, func(i, j int) bool { return collected[i].index < collected[j].index })
+	return collected, nil
+}
+
+// formatVerify renders the problems found by verify_checksums in the style of
+// sha256sum -c, after the summary
+func formatVerify(summary string, r verifyResult) string {
+	var b strings.Builder
+	b.WriteString(summary)
+	b.WriteString("\n")
+	for _, m := range r.Mismatches {
+		fmt.Fprintf(&b, "%s: FAILED\n", m.Path)
+	}
+	for _, p := range r.Missing {
+		fmt.Fprintf(&b, "%s: MISSING\n", p)
+	}
+	for _, e := range r.Errors {
+		fmt.Fprintf(&b, "%s: ERROR %s\n", e.Path, e.Error)
+	}
+	return b.String()
+}
+
+// hashFilesOutputSchema describes hashResult for clients that validate structured content
+const hashFilesOutputSchema = `{
+	"type": "object",
+	"properties": {
+		"algorithm": {"type": "string"},
+		"files": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"path": {"type": "string"},
+					"size": {"type": "integer"},
+					"digest": {"type": "string"},
+					"error": {"type": "string"}
+				},
+				"required": ["path", "size"]
+			}
+		},
+		"files_hashed": {"type": "integer"},
+		"bytes_hashed": {"type": "integer"},
+		"truncated": {"type": "boolean"}
+	},
+	"required": ["algorithm", "files", "files_hashed", "bytes_hashed", "truncated"]
+}`
+
+// verifyChecksumsOutputSchema describes verifyResult for clients that validate structured content
+const verifyChecksumsOutputSchema = `{
+	"type": "object",
+	"properties": {
+		"manifest": {"type": "string"},
+		"checked": {"type": "integer"},
+		"ok": {"type": "integer"},
+		"mismatches": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"path": {"type": "string"},
+					"expected": {"type": "string"},
+					"actual": {"type": "string"}
+				},
+				"required": ["path", "expected", "actual"]
+			}
+		},
+		"missing": {"type": "array", "items": {"type": "string"}},
+		"errors": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"path": {"type": "string"},
+					"error": {"type": "string"}
+				},
+				"required": ["path", "error"]
+			}
+		},
+		"malformed_lines": {"type": "array", "items": {"type": "integer"}},
+		"valid": {"type": "boolean"}
+	},
+	"required": ["manifest", "checked", "ok", "mismatches", "missing", "valid"]
+}`
+
+// hashAlgorithmProperty is the schema of the algorithm argument
+func hashAlgorithmProperty(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"enum":        []string{hashSHA256, hashSHA1, hashMD5, hashBLAKE3},
+		"description": description,
+	}
+}
+
+// hashFilesServerTool defines the hash_files tool
+func hashFilesServerTool(cfg *serverConfig) server.ServerTool {
+	algorithm := hashAlgorithmProperty("Digest algorithm")
+	algorithm["default"] = hashSHA256
+
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "hash_files",
+			Description: "Computes sha256, sha1, md5 or blake3 digests of a file or of every file under a directory",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "File or directory to hash (use '/' for root directory)",
+						"default":     "/",
+					},
+					"algorithm": algorithm,
+					"include": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Only hash files whose root-relative path matches one of these globs (e.g. '*.tar.gz')",
+					},
+					"exclude": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Skip files and directories matching these globs",
+					},
+					"max_bytes": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum total bytes to read; files beyond the cap are listed without a digest",
+						"minimum":     1,
+						"default":     defaultMaxHashBytes,
+					},
+					"include_hidden": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Hash dot-files and dot-directories",
+						"default":     true,
+					},
+					"respect_ignore_files": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Skip files matched by .gitignore/.ignore files and the server ignore file",
+						"default":     cfg.RespectIgnoreFiles,
+					},
+				},
+			},
+			RawOutputSchema: json.RawMessage(hashFilesOutputSchema),
+		},
+		Handler: hashFilesTool(cfg),
+	}
+}
+
+// verifyChecksumsServerTool defines the verify_checksums tool
+func verifyChecksumsServerTool(cfg *serverConfig) server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "verify_checksums",
+			Description: "Checks the files listed in a SHA256SUMS-style manifest and reports mismatched and missing files",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"manifest": map[string]interface{}{
+						"type":        "string",
+						"description": "Manifest in sha256sum or BSD tagged format; names are relative to its directory",
+					},
+					"algorithm": hashAlgorithmProperty("Algorithm of untagged digests (inferred from digest length when omitted)"),
+				},
+				Required: []string{"manifest"},
+			},
+			RawOutputSchema: json.RawMessage(verifyChecksumsOutputSchema),
+		},
+		Handler: verifyChecksumsTool(cfg),
+	}
+}
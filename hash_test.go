@@ -0,0 +1,235 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func hexDigest(sum []byte) string {
+	return hex.EncodeToString(sum)
+}
+
+func TestHashFilesTool(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := newServerConfig(tempDir)
+	cfg.PathStyle = pathStyleVirtual
+	handler := hashFilesTool(cfg)
+
+	result, err := callTool(handler, "hash_files", `{"path": "/"}`)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	hashes := result.StructuredContent.(hashResult)
+
+	sum := sha256.Sum256([]byte("test content"))
+	want := []fileHash{
+		{Path: "/file1.txt", Size: 12, Digest: hexDigest(sum[:])},
+		{Path: "/subdir/deep/file3.json", Size: 14},
+		{Path: "/subdir/file2.go", Size: 12},
+	}
+	if len(hashes.Files) != len(want) {
+		t.Fatalf("Expected %d files, got %+v", len(want), hashes.Files)
+	}
+	for i, w := range want {
+		got := hashes.Files[i]
+		if got.Path != w.Path || got.Size != w.Size || len(got.Digest) != 64 || got.Error != "" {
+			t.Errorf("File %d: expected %+v, got %+v", i, w, got)
+		}
+	}
+	if hashes.Files[0].Digest != want[0].Digest {
+		t.Errorf("Expected digest %s, got %s", want[0].Digest, hashes.Files[0].Digest)
+	}
+	if hashes.FilesHashed != 3 || hashes.BytesHashed != 38 || hashes.Truncated {
+		t.Errorf("Unexpected totals %+v", hashes)
+	}
+
+	// The text output is usable as a manifest
+	text := result.Content[0].(mcp.TextContent).Text
+	if !contains(text, want[0].Digest+"  /file1.txt\n") {
+		t.Errorf("Expected sha256sum-style line, got %q", text)
+	}
+}
+
+func TestHashFilesTool_Algorithms(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+	writeTestFile(t, filepath.Join(tempDir, "empty"), "")
+
+	handler := hashFilesTool(newServerConfig(tempDir))
+
+	md5sum := md5.Sum([]byte("test content"))
+	tests := []struct {
+		algorithm string
+		path      string
+		digest    string
+	}{
+		{"md5", "/file1.txt", hexDigest(md5sum[:])},
+		{"sha1", "/empty", "da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+		{"blake3", "/empty", "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262"},
+	}
+	for _, tt := range tests {
+		result, err := callTool(handler, "hash_files", `{"path": "`+tt.path+`", "algorithm": "`+tt.algorithm+`"}`)
+		if err != nil {
+			t.Fatalf("%s: handler returned error: %v", tt.algorithm, err)
+		}
+		files := result.StructuredContent.(hashResult).Files
+		if len(files) != 1 || files[0].Digest != tt.digest {
+			t.Errorf("%s: expected digest %s, got %+v", tt.algorithm, tt.digest, files)
+		}
+	}
+
+	if _, err := callTool(handler, "hash_files", `{"algorithm": "crc32"}`); err == nil || !contains(err.Error(), "invalid algorithm") {
+		t.Errorf("Expected invalid algorithm error, got %v", err)
+	}
+}
+
+func TestHashFilesTool_MaxBytes(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := newServerConfig(tempDir)
+	cfg.PathStyle = pathStyleVirtual
+	handler := hashFilesTool(cfg)
+
+	// file1.txt (12) and file3.json (14) fit; file2.go (12) would exceed 30 bytes
+	result, err := callTool(handler, "hash_files", `{"max_bytes": 30}`)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	hashes := result.StructuredContent.(hashResult)
+	if !hashes.Truncated || hashes.FilesHashed != 2 || hashes.BytesHashed != 26 {
+		t.Errorf("Expected 2 files hashed and truncation, got %+v", hashes)
+	}
+	last := hashes.Files[2]
+	if last.Path != "/subdir/file2.go" || last.Digest != "" || !contains(last.Error, "max_bytes") {
+		t.Errorf("Expected file2.go to be skipped, got %+v", last)
+	}
+}
+
+func TestParseChecksumManifest(t *testing.T) {
+	manifest := "# build 42\r\n" +
+		"AAAA  plain name.txt\r\n" +
+		"bbbb *binary.bin\n" +
+		"\\cccc  back\\\\slash\\nnewline\n" +
+		"SHA1 (tagged) = dddd\n" +
+		"\n" +
+		"not a checksum line\n"
+
+	entries, malformed := parseChecksumManifest(manifest)
+	want := []checksumEntry{
+		{line: 2, name: "plain name.txt", digest: "aaaa"},
+		{line: 3, name: "binary.bin", digest: "bbbb"},
+		{line: 4, name: "back\\slash\nnewline", digest: "cccc"},
+		{line: 5, name: "tagged", digest: "dddd", algorithm: "sha1"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("Expected entries %+v, got %+v", want, entries)
+	}
+	if !reflect.DeepEqual(malformed, []int{7}) {
+		t.Errorf("Expected malformed line 7, got %v", malformed)
+	}
+}
+
+func TestVerifyChecksumsTool(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	good := sha256.Sum256([]byte("package main"))
+	md5sum := md5.Sum([]byte(`{"test": true}`))
+	writeTestFile(t, filepath.Join(tempDir, "subdir", "SHA256SUMS"),
+		hexDigest(good[:])+"  file2.go\n"+
+			"MD5 (deep/file3.json) = "+hexDigest(md5sum[:])+"\n"+
+			hexDigest(good[:])+"  ../file1.txt\n"+
+			hexDigest(good[:])+"  gone.bin\n"+
+			hexDigest(good[:])+"  ../../escape\n")
+
+	cfg := newServerConfig(tempDir)
+	cfg.PathStyle = pathStyleVirtual
+	handler := verifyChecksumsTool(cfg)
+
+	result, err := callTool(handler, "verify_checksums", `{"manifest": "/subdir/SHA256SUMS"}`)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	verify := result.StructuredContent.(verifyResult)
+
+	if verify.Manifest != "/subdir/SHA256SUMS" || verify.Checked != 3 || verify.OK != 2 || verify.Valid {
+		t.Errorf("Unexpected totals %+v", verify)
+	}
+	if len(verify.Mismatches) != 1 || verify.Mismatches[0].Path != "/file1.txt" {
+		t.Errorf("Expected /file1.txt to mismatch, got %+v", verify.Mismatches)
+	}
+	if !reflect.DeepEqual(verify.Missing, []string{"/subdir/gone.bin"}) {
+		t.Errorf("Expected /subdir/gone.bin to be missing, got %v", verify.Missing)
+	}
+	if len(verify.Errors) != 1 || !contains(verify.Errors[0].Error, "outside root directory") {
+		t.Errorf("Expected containment error for escaping entry, got %+v", verify.Errors)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !contains(text, "/file1.txt: FAILED") || !contains(text, "/subdir/gone.bin: MISSING") {
+		t.Errorf("Expected sha256sum -c style problems, got %q", text)
+	}
+
+	// A clean manifest verifies
+	writeTestFile(t, filepath.Join(tempDir, "SHA256SUMS"), hexDigest(good[:])+"  subdir/file2.go\n")
+	result, err = callTool(handler, "verify_checksums", `{"manifest": "/SHA256SUMS"}`)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if verify := result.StructuredContent.(verifyResult); !verify.Valid || verify.OK != 1 {
+		t.Errorf("Expected a valid manifest, got %+v", verify)
+	}
+
+	if _, err := callTool(handler, "verify_checksums", `{"manifest": "/missing"}`); err == nil || !contains(err.Error(), "does not exist") {
+		t.Errorf("Expected missing manifest error, got %v", err)
+	}
+}
+
+func TestVerifyChecksumsTool_SpecialFiles(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	if err := makeFIFO(filepath.Join(tempDir, "pipe")); err != nil {
+		t.Skipf("Named pipes not supported: %v", err)
+	}
+	good := sha256.Sum256([]byte("package main"))
+	writeTestFile(t, filepath.Join(tempDir, "SHA256SUMS"),
+		hexDigest(good[:])+"  pipe\n"+
+			hexDigest(good[:])+"  subdir/file2.go\n")
+
+	cfg := newServerConfig(tempDir)
+	cfg.PathStyle = pathStyleVirtual
+
+	// Reading a pipe with no writer would block, so it is reported instead
+	done := make(chan verifyResult, 1)
+	go func() {
+		result, err := callTool(verifyChecksumsTool(cfg), "verify_checksums", `{"manifest": "/SHA256SUMS"}`)
+		if err != nil {
+			t.Errorf("Handler returned error: %v", err)
+			close(done)
+			return
+		}
+		done <- result.StructuredContent.(verifyResult)
+	}()
+	select {
+	case verify := <-done:
+		if verify.OK != 1 || verify.Valid {
+			t.Errorf("Unexpected totals %+v", verify)
+		}
+		if len(verify.Errors) != 1 || verify.Errors[0].Path != "/pipe" || !contains(verify.Errors[0].Error, "not a regular file") {
+			t.Errorf("Expected the pipe to be reported, got %+v", verify.Errors)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("verify_checksums blocked on a named pipe")
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// ignoreFileNames lists the per-directory ignore files honored during walks,
+// in increasing order of precedence (.ignore overrides .gitignore, as in ripgrep)
+var ignoreFileNames = []string{".gitignore", ".ignore"}
+
+// ignoreRule is a single parsed line from an ignore file
+type ignoreRule struct {
+	pattern  string // doublestar pattern, relative to base when anchored
+	base     string // slash-separated directory of the ignore file relative to the root ("" for the root)
+	negate   bool   // line started with "!"
+	dirOnly  bool   // line ended with "/"
+	anchored bool   // pattern contained a "/" other than a trailing one
+}
+
+// ignoreMatcher evaluates an ordered list of ignore rules. Later rules take
+// precedence over earlier ones, so rules from deeper directories are appended
+// after those of their parents.
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// parseIgnoreRules parses gitignore-format lines. base is the slash-separated
+// directory (relative to the served root) that anchored patterns are relative to.
+func parseIgnoreRules(r io.Reader, base string) ([]ignoreRule, error) {
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreLine(scanner.Text(), base); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, scanner.Err()
+}
+
+// parseIgnoreLine parses one gitignore line, returning false for blanks and comments
+func parseIgnoreLine(line, base string) (ignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+
+	// Trailing spaces are ignored unless escaped with a backslash
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+
+	// "dir/**" matches everything inside dir but not dir itself
+	if strings.HasSuffix(line, "/**") {
+		line += "/*"
+	}
+
+	if line == "" || !doublestar.ValidatePattern(line) {
+		return ignoreRule{}, false
+	}
+
+	rule.pattern = line
+	return rule, true
+}
+
+// match reports whether the rule's pattern matches rel
+func (r ignoreRule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	// Rules only apply to paths below the directory containing the ignore file
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		rel = rel[len(r.base)+1:]
+	}
+
+	name := rel
+	if !r.anchored {
+		name = path.Base(rel)
+	}
+
+	ok, _ := doublestar.Match(r.pattern, name)
+	return ok
+}
+
+// Match reports whether the root-relative path rel is ignored. The last
+// matching rule wins, so negated rules can re-include earlier matches.
+func (m *ignoreMatcher) Match(rel string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+
+	for i := len(m.rules) - 1; i >= 0; i-- {
+		if m.rules[i].match(rel, isDir) {
+			return !m.rules[i].negate
+		}
+	}
+	return false
+}
+
+// withRules returns a matcher with extra rules appended after m's rules.
+// m itself is returned unchanged when there is nothing to add.
+func (m *ignoreMatcher) withRules(rules []ignoreRule) *ignoreMatcher {
+	if len(rules) == 0 {
+		return m
+	}
+
+	child := &ignoreMatcher{}
+	if m != nil {
+		child.rules = append(child.rules, m.rules...)
+	}
+	child.rules = append(child.rules, rules...)
+	return child
+}
+
+// loadIgnoreFile reads ignore rules from a single file. A missing file yields no rules.
+func loadIgnoreFile(filename, base string) ([]ignoreRule, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseIgnoreRules(f, base)
+}
+
+// loadDirIgnoreRules reads the ignore files that live directly in dir.
+// Unreadable files are logged and skipped so a bad .gitignore never aborts a walk.
+func loadDirIgnoreRules(dir, base string) []ignoreRule {
+	var rules []ignoreRule
+	for _, name := range ignoreFileNames {
+		fileRules, err := loadIgnoreFile(filepath.Join(dir, name), base)
+		if err != nil {
+			log.Printf("Failed to read ignore file %s: %v", filepath.Join(dir, name), err)
+			continue
+		}
+		rules = append(rules, fileRules...)
+	}
+	return rules
+}
+
+// ignoreMatcherFor builds the matcher that applies inside dir, which must be
+// rootDir or one of its descendants: base rules first, then the ignore files
+// of every directory from rootDir down to dir.
+func ignoreMatcherFor(rootDir, dir string, base *ignoreMatcher) *ignoreMatcher {
+	m := base
+	rel := relPath(rootDir, dir)
+
+	current := rootDir
+	m = m.withRules(loadDirIgnoreRules(current, ""))
+	if rel == "." {
+		return m
+	}
+
+	prefix := ""
+	for _, segment := range strings.Split(rel, "/") {
+		current = filepath.Join(current, segment)
+		prefix = path.Join(prefix, segment)
+		m = m.withRules(loadDirIgnoreRules(current, prefix))
+	}
+	return m
+}
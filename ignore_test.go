@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreMatcher_Semantics(t *testing.T) {
+	rules, err := parseIgnoreRules(strings.NewReader(`
+# comment
+*.log
+!keep.log
+/build
+out/
+docs/**/*.tmp
+cache/**
+\#literal
+trailing   
+`), "")
+	if err != nil {
+		t.Fatalf("parseIgnoreRules returned error: %v", err)
+	}
+
+	m := (*ignoreMatcher)(nil).withRules(rules)
+
+	tests := []struct {
+		rel     string
+		isDir   bool
+		ignored bool
+	}{
+		{"debug.log", false, true},
+		{"a/b/debug.log", false, true},
+		{"keep.log", false, false},
+		{"a/keep.log", false, false},
+		{"build", true, true},
+		{"src/build", true, false},
+		{"out", true, true},
+		{"src/out", true, true},
+		{"out", false, false},
+		{"docs/a/b/x.tmp", false, true},
+		{"docs/x.tmp", false, true},
+		{"x.tmp", false, false},
+		{"cache", true, false},
+		{"cache/x", false, true},
+		{"#literal", false, true},
+		{"trailing", false, true},
+		{"main.go", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := m.Match(tt.rel, tt.isDir); got != tt.ignored {
+			t.Errorf("Match(%q, dir=%v) = %v, want %v", tt.rel, tt.isDir, got, tt.ignored)
+		}
+	}
+}
+
+func TestIgnoreMatcher_NestedBase(t *testing.T) {
+	rules, err := parseIgnoreRules(strings.NewReader("/gen\n*.pb.go\n"), "api")
+	if err != nil {
+		t.Fatalf("parseIgnoreRules returned error: %v", err)
+	}
+
+	m := (*ignoreMatcher)(nil).withRules(rules)
+
+	if !m.Match("api/gen", true) {
+		t.Error("Anchored nested pattern should match api/gen")
+	}
+	if m.Match("gen", true) {
+		t.Error("Anchored nested pattern should not match outside its directory")
+	}
+	if !m.Match("api/v1/x.pb.go", false) {
+		t.Error("Unanchored nested pattern should match below its directory")
+	}
+	if m.Match("x.pb.go", false) {
+		t.Error("Nested rules should not apply above their directory")
+	}
+}
+
+func TestWalkTree_RespectIgnoreFiles(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	// Root ignores *.json and emptydir/, subdir re-includes json files below it,
+	// and .git is always skipped
+	writeTestFile(t, filepath.Join(tempDir, ".gitignore"), "*.json\nemptydir/\n")
+	writeTestFile(t, filepath.Join(tempDir, "subdir", ".ignore"), "!*.json\nfile2.go\n")
+	writeTestFile(t, filepath.Join(tempDir, "top.json"), "{}")
+	writeTestFile(t, filepath.Join(tempDir, ".git", "HEAD"), "ref")
+
+	opts := defaultWalkOptions()
+	opts.RespectIgnore = true
+
+	visited := map[string]bool{}
+	err := walkTree(tempDir, tempDir, opts, func(entry walkEntry) error {
+		visited[entry.Rel] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walkTree returned error: %v", err)
+	}
+
+	for _, rel := range []string{".", "file1.txt", "subdir", "subdir/deep", "subdir/deep/file3.json", ".gitignore"} {
+		if !visited[rel] {
+			t.Errorf("Expected %s to be visited", rel)
+		}
+	}
+	for _, rel := range []string{"top.json", "emptydir", "subdir/file2.go", ".git", ".git/HEAD"} {
+		if visited[rel] {
+			t.Errorf("Expected %s to be ignored", rel)
+		}
+	}
+}
+
+func TestWalkTree_IgnoreFilesAboveTarget(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	writeTestFile(t, filepath.Join(tempDir, ".gitignore"), "*.json\n")
+
+	opts := defaultWalkOptions()
+	opts.RespectIgnore = true
+
+	target := filepath.Join(tempDir, "subdir")
+	err := walkTree(tempDir, target, opts, func(entry walkEntry) error {
+		if strings.HasSuffix(entry.Rel, ".json") {
+			t.Errorf("Root .gitignore should apply when walking a subdirectory, got %s", entry.Rel)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walkTree returned error: %v", err)
+	}
+}
+
+// writeTestFile creates a file and any missing parent directories
+func writeTestFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatalf("Failed to create directory for %s: %v", name, err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+}
@@ -0,0 +1,638 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Snapshot states recorded for a touched path
+const (
+	snapshotAbsent   = "absent"   // the path did not exist
+	snapshotFile     = "file"     // a copy of the file is in the snapshot directory
+	snapshotDir      = "dir"      // a copy of the directory tree is in the snapshot directory
+	snapshotSymlink  = "symlink"  // the link itself is in the snapshot directory
+	snapshotTrashed  = "trashed"  // the call moved the path to the trash; Snapshot is the trash id
+	snapshotRestored = "restored" // the call restored the trash entry Trash to the path
+)
+
+// journalRecord is one line of the journal: either a file-modifying tool call
+// with the state of the paths it touched beforehand, or a marker that an
+// earlier call was undone
+type journalRecord struct {
+	ID        string            `json:"id"`
+	Time      time.Time         `json:"time"`
+	Root      string            `json:"root"`
+	Tool      string            `json:"tool,omitempty"`
+	Arguments json.RawMessage   `json:"arguments,omitempty"`
+	Client    string            `json:"client,omitempty"`
+	Before    []journalSnapshot `json:"before,omitempty"`
+	TrashDir  string            `json:"trash_dir,omitempty"` // trash holding the entries of trashed snapshots
+	UndoOf    string            `json:"undo_of,omitempty"`
+}
+
+// journalSnapshot is the state of one path before a journaled call
+type journalSnapshot struct {
+	Path     string      `json:"path"` // virtual path
+	State    string      `json:"state"`
+	Snapshot string      `json:"snapshot,omitempty"` // copy, relative to the snapshot directory, or trash id
+	Trash    *trashEntry `json:"trash,omitempty"`    // metadata of the restored trash entry
+}
+
+// journal is an append-only JSON-lines log of file-modifying tool calls.
+// Before-state copies live in a directory next to the journal file.
+type journal struct {
+	path    string
+	snapDir string
+	mu      sync.Mutex // serializes journaled calls so snapshots match the order of changes
+}
+
+// openJournal returns the journal stored at path. Like the trash, it must be
+// outside the served root so agents cannot see or rewrite their own history.
+func openJournal(path, rootDir string) (*journal, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	realPath, err := evalExistingSymlinks(absPath)
+	if err != nil {
+		return nil, err
+	}
+	if realRoot, err := filepath.EvalSymlinks(rootDir); err == nil && withinRoot(realRoot, realPath) {
+		return nil, fmt.Errorf("journal %s must be outside the served root", absPath)
+	}
+
+	j := &journal{path: absPath, snapDir: absPath + ".snapshots"}
+	if err := os.MkdirAll(j.snapDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create journal snapshot directory: %w", err)
+	}
+	f, err := os.OpenFile(absPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	f.Close()
+	return j, nil
+}
+
+// append writes a record as one JSON line
+func (j *journal) append(rec journalRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal record: %w", err)
+	}
+
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return f.Sync()
+}
+
+// records reads every record in the journal
+func (j *journal) records() ([]journalRecord, error) {
+	f, err := os.Open(j.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer f.Close()
+
+	// Records embed tool arguments, so lines can be far longer than bufio.Scanner allows
+	var recs []journalRecord
+	reader := bufio.NewReader(f)
+	for n := 1; ; n++ {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var rec journalRecord
+			if jsonErr := json.Unmarshal(line, &rec); jsonErr != nil {
+				return nil, fmt.Errorf("journal line %d: %w", n, jsonErr)
+			}
+			recs = append(recs, rec)
+		}
+		if err == io.EOF {
+			return recs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read journal: %w", err)
+		}
+	}
+}
+
+// snapshot records the current state of the given absolute paths under a
+// new record id. A path that does not exist is recorded at its topmost
+// missing ancestor, so undo also removes directories the call created.
+func (j *journal) snapshot(rootDir, id string, paths []string) ([]journalSnapshot, error) {
+	var snaps []journalSnapshot
+	seen := make(map[string]bool)
+
+	for _, p := range paths {
+		for parent := filepath.Dir(p); parent != rootDir && withinRoot(rootDir, parent); parent = filepath.Dir(parent) {
+			if _, err := os.Lstat(parent); err == nil {
+				break
+			}
+			p = parent
+		}
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+
+		snap := journalSnapshot{Path: virtualPath(relPath(rootDir, p)), State: snapshotAbsent}
+		info, err := os.Lstat(p)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to stat %s: %w", p, err)
+		}
+		if err == nil {
+			switch {
+			case info.IsDir():
+				snap.State = snapshotDir
+			case info.Mode()&os.ModeSymlink != 0:
+				snap.State = snapshotSymlink
+			default:
+				snap.State = snapshotFile
+			}
+			snap.Snapshot = filepath.Join(id, strconv.Itoa(len(snaps)))
+			dst := filepath.Join(j.snapDir, snap.Snapshot)
+			if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+				return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+			}
+			if err := copyTree(p, dst); err != nil {
+				return nil, fmt.Errorf("failed to snapshot %s: %w", p, err)
+			}
+		}
+		snaps = append(snaps, snap)
+	}
+	return snaps, nil
+}
+
+// undo rolls back the last n journaled calls made under rootDir that have not
+// been undone yet, most recent first, and returns the records it undid
+func (j *journal) undo(rootDir string, n int) ([]journalRecord, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	recs, err := j.records()
+	if err != nil {
+		return nil, err
+	}
+
+	undone := make(map[string]bool)
+	for _, rec := range recs {
+		if rec.UndoOf != "" {
+			undone[rec.UndoOf] = true
+		}
+	}
+
+	var result []journalRecord
+	for i := len(recs) - 1; i >= 0 && len(result) < n; i-- {
+		rec := recs[i]
+		if rec.UndoOf != "" || undone[rec.ID] || rec.Root != rootDir {
+			continue
+		}
+
+		if err := j.restore(rootDir, rec); err != nil {
+			return result, fmt.Errorf("failed to undo %s (%s): %w", rec.ID, rec.Tool, err)
+		}
+		marker := journalRecord{ID: newJournalID(), Time: time.Now().UTC(), Root: rootDir, UndoOf: rec.ID}
+		if err := j.append(marker); err != nil {
+			return result, err
+		}
+		result = append(result, rec)
+	}
+	return result, nil
+}
+
+// restore puts every path of rec back into its recorded state. Paths are
+// resolved with the default containment rules, so a symlink planted since the
+// call cannot redirect the restore outside the root.
+func (j *journal) restore(rootDir string, rec journalRecord) error {
+	cfg := newServerConfig(rootDir)
+	for i := len(rec.Before) - 1; i >= 0; i-- {
+		snap := rec.Before[i]
+		target, err := resolveEntryPath(cfg, snap.Path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", snap.Path, err)
+		}
+
+		// A trashed path is still intact in the trash, so it is moved back
+		// rather than copied, and never over something made since
+		if snap.State == snapshotTrashed {
+			if _, err := os.Lstat(target); err == nil {
+				return fmt.Errorf("%s has been recreated since it was trashed", snap.Path)
+			}
+			t := &trash{dir: rec.TrashDir}
+			if _, err := t.restore(snap.Snapshot, target); err != nil {
+				return fmt.Errorf("failed to restore %s from trash: %w", snap.Path, err)
+			}
+			continue
+		}
+
+		// A restored path was only ever held by the trash, so it goes back
+		// into its old slot; there is no copy to bring back if it were removed
+		if snap.State == snapshotRestored {
+			if _, err := os.Lstat(target); err != nil {
+				return fmt.Errorf("%s has been removed since it was restored", snap.Path)
+			}
+			t := &trash{dir: rec.TrashDir}
+			if err := t.putBack(*snap.Trash, target); err != nil {
+				return fmt.Errorf("failed to move %s back to trash: %w", snap.Path, err)
+			}
+			continue
+		}
+
+		if err := os.RemoveAll(target); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", snap.Path, err)
+		}
+		if snap.State == snapshotAbsent {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), defaultDirPerm); err != nil {
+			return fmt.Errorf("failed to recreate parent of %s: %w", snap.Path, err)
+		}
+		if err := copyTree(filepath.Join(j.snapDir, snap.Snapshot), target); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", snap.Path, err)
+		}
+	}
+	return nil
+}
+
+// newJournalID returns a unique, time-ordered record id
+func newJournalID() string {
+	id, err := newTrashID(time.Now())
+	if err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return id
+}
+
+// journalTargets returns the paths a tool call will modify, so their state can
+// be recorded before it runs. Calls that change nothing (e.g. dry runs) return nil.
+type journalTargets func(cfg *serverConfig, request mcp.CallToolRequest) []string
+
+// journaledTools lists the file-modifying tools and what each one touches
+var journaledTools = map[string]journalTargets{
+	"write_file":         argumentTargets(resolveWritePath, "path"),
+	"edit_file":          argumentTargets(resolveWritePath, "path"),
+	"apply_patch":        patchTargets,
+	"create_directory":   argumentTargets(resolveWritePath, "path"),
+	"move_path":          argumentTargets(resolveEntryPath, "source", "destination"),
+	"copy_path":          argumentTargets(resolveEntryPath, "destination"),
+	"delete_path":        argumentTargets(resolveEntryPath, "path"),
+	"trash_path":         argumentTargets(resolveEntryPath, "path"),
+	"restore_from_trash": restoreTargets,
+}
+
+// trashSnapshots returns the state to record for a successful call that moved
+// paths into or out of the trash, from its result and the paths it touched.
+// It returns nil if the result does not say which trash entries were moved.
+type trashSnapshots func(cfg *serverConfig, paths []string, result *mcp.CallToolResult) []journalSnapshot
+
+// trashingTools lists the journaled tools that move entries into or out of the
+// trash. The trash already keeps them intact, so they are not copied; the
+// record names the trash entries instead, and undo moves them back.
+var trashingTools = map[string]trashSnapshots{
+	"trash_path":         trashPathSnapshots,
+	"restore_from_trash": restoreSnapshots,
+}
+
+// trashPathSnapshots records the trash entry a trash_path call created
+func trashPathSnapshots(cfg *serverConfig, paths []string, result *mcp.CallToolResult) []journalSnapshot {
+	entry, ok := result.StructuredContent.(trashEntry)
+	if !ok {
+		return nil
+	}
+	return []journalSnapshot{{Path: entry.OriginalPath, State: snapshotTrashed, Snapshot: entry.ID}}
+}
+
+// restoreSnapshots records the trash entry a restore_from_trash call took out
+// of the trash and, after it, the one it displaced from the destination, so
+// undo moves the restored entry back before bringing the displaced one out
+func restoreSnapshots(cfg *serverConfig, paths []string, result *mcp.CallToolResult) []journalSnapshot {
+	restored, ok := result.StructuredContent.(trashRestoreResult)
+	if !ok {
+		return nil
+	}
+	entry := restored.trashEntry
+	entry.ExpiresAt = nil
+	dest := virtualPath(relPath(cfg.RootDir, paths[0]))
+
+	var snaps []journalSnapshot
+	if restored.Displaced != "" {
+		snaps = append(snaps, journalSnapshot{Path: dest, State: snapshotTrashed, Snapshot: restored.Displaced})
+	}
+	return append(snaps, journalSnapshot{Path: dest, State: snapshotRestored, Snapshot: entry.ID, Trash: &entry})
+}
+
+// pathResolver maps a tool input path the way the tool itself does: through a
+// final symlink for tools that write file contents, or not for tools that
+// act on directory entries
+type pathResolver func(cfg *serverConfig, input string) (string, error)
+
+// argumentTargets returns a journalTargets reading paths from the named arguments
+func argumentTargets(resolve pathResolver, names ...string) journalTargets {
+	return func(cfg *serverConfig, request mcp.CallToolRequest) []string {
+		var args map[string]interface{}
+		if err := request.BindArguments(&args); err != nil {
+			return nil
+		}
+		if dryRun, _ := args["dry_run"].(bool); dryRun {
+			return nil
+		}
+
+		var paths []string
+		for _, name := range names {
+			if input, ok := args[name].(string); ok && input != "" {
+				paths = append(paths, input)
+			}
+		}
+		return resolveTargets(cfg, resolve, paths)
+	}
+}
+
+// patchTargets returns the files an apply_patch call touches
+func patchTargets(cfg *serverConfig, request mcp.CallToolRequest) []string {
+	var args struct {
+		Patch string `json:"patch"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return nil
+	}
+	patches, err := parsePatch(args.Patch)
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+	for _, p := range patches {
+		for _, path := range []string{p.OldPath, p.NewPath} {
+			if path != "" {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return resolveTargets(cfg, resolveWritePath, paths)
+}
+
+// restoreTargets returns the destination of a restore_from_trash call
+func restoreTargets(cfg *serverConfig, request mcp.CallToolRequest) []string {
+	var args struct {
+		ID          string `json:"id"`
+		Destination string `json:"destination"`
+	}
+	if err := request.BindArguments(&args); err != nil || cfg.Trash == nil {
+		return nil
+	}
+	if args.Destination == "" {
+		entry, err := cfg.Trash.get(args.ID)
+		if err != nil {
+			return nil
+		}
+		args.Destination = entry.OriginalPath
+	}
+	return resolveTargets(cfg, resolveEntryPath, []string{args.Destination})
+}
+
+// resolveTargets maps input paths to absolute paths, dropping invalid ones;
+// the tool itself reports those
+func resolveTargets(cfg *serverConfig, resolve pathResolver, inputs []string) []string {
+	var paths []string
+	for _, input := range inputs {
+		if p, err := resolve(cfg, input); err == nil {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// journaled wraps a file-modifying tool handler so that every successful call
+// is recorded in the journal together with the prior state of what it touched
+func journaled(cfg *serverConfig, name string, targets journalTargets, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		paths := targets(cfg, request)
+		if len(paths) == 0 {
+			return next(ctx, request)
+		}
+
+		j := cfg.Journal
+		j.mu.Lock()
+		defer j.mu.Unlock()
+
+		rec := journalRecord{
+			ID:     newJournalID(),
+			Time:   time.Now().UTC(),
+			Root:   cfg.RootDir,
+			Tool:   name,
+			Client: clientName(ctx),
+		}
+		rec.Arguments, _ = json.Marshal(request.Params.Arguments)
+
+		trashed, moves := trashingTools[name]
+		if !moves {
+			before, err := j.snapshot(cfg.RootDir, rec.ID, paths)
+			if err != nil {
+				os.RemoveAll(filepath.Join(j.snapDir, rec.ID))
+				return nil, fmt.Errorf("failed to journal %s: %w", name, err)
+			}
+			rec.Before = before
+		}
+
+		result, err := next(ctx, request)
+		if err != nil || result == nil || result.IsError {
+			// Nothing changed, so there is nothing to undo
+			os.RemoveAll(filepath.Join(j.snapDir, rec.ID))
+			return result, err
+		}
+
+		if moves {
+			if rec.Before = trashed(cfg, paths, result); rec.Before == nil {
+				log.Printf("[JOURNAL] %s call %s returned no trash entry; not recorded", name, rec.ID)
+				return result, nil
+			}
+			rec.TrashDir = cfg.Trash.dir
+		}
+
+		if err := j.append(rec); err != nil {
+			log.Printf("[JOURNAL] failed to record %s call %s: %v", name, rec.ID, err)
+		}
+		return result, nil
+	}
+}
+
+// journalAll wraps the handlers of all journaled tools in tools
+func journalAll(cfg *serverConfig, tools []server.ServerTool) {
+	for i, tool := range tools {
+		if targets, ok := journaledTools[tool.Tool.Name]; ok {
+			tools[i].Handler = journaled(cfg, tool.Tool.Name, targets, tool.Handler)
+		}
+	}
+}
+
+// undoResult is the structured content returned by undo_last_operation
+type undoResult struct {
+	Undone []undoneOperation `json:"undone"`
+}
+
+// undoneOperation describes one rolled back call
+type undoneOperation struct {
+	ID    string    `json:"id"`
+	Tool  string    `json:"tool"`
+	Time  time.Time `json:"time"`
+	Paths []string  `json:"paths"` // virtual paths restored to their earlier state
+}
+
+// newUndoResult summarizes undone records
+func newUndoResult(recs []journalRecord) (undoResult, string) {
+	result := undoResult{Undone: []undoneOperation{}}
+	var text bytes.Buffer
+	for _, rec := range recs {
+		op := undoneOperation{ID: rec.ID, Tool: rec.Tool, Time: rec.Time, Paths: []string{}}
+		seen := make(map[string]bool)
+		for _, snap := range rec.Before {
+			if !seen[snap.Path] {
+				seen[snap.Path] = true
+				op.Paths = append(op.Paths, snap.Path)
+			}
+		}
+		result.Undone = append(result.Undone, op)
+		fmt.Fprintf(&text, "Undid %s %s from %s (%d paths restored)\n", rec.Tool, rec.ID, rec.Time.Format(time.RFC3339), len(op.Paths))
+	}
+	if len(recs) == 0 {
+		text.WriteString("Nothing to undo\n")
+	}
+	return result, text.String()
+}
+
+// undoLastOperationTool implements the undo_last_operation tool handler
+func undoLastOperationTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		if cfg.Journal == nil {
+			return nil, errJournalDisabled
+		}
+		if !cfg.AllowWrite {
+			return nil, errWriteDisabled
+		}
+
+		// Parse the arguments
+		var args struct {
+			Count int `json:"count"`
+		}
+		args.Count = 1
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+		if args.Count < 1 {
+			return nil, fmt.Errorf("count must be at least 1")
+		}
+
+		recs, err := cfg.Journal.undo(cfg.RootDir, args.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		result, text := newUndoResult(recs)
+		log.Printf("[TOOL COMPLETED] %s - undid %d operations", request.Params.Name, len(recs))
+
+		return mcp.NewToolResultStructured(result, text), nil
+	}
+}
+
+// errJournalDisabled is returned by undo_last_operation when no journal is configured
+var errJournalDisabled = errors.New("journal is disabled; start the server with -journal")
+
+// undoLastOperationServerTool defines the undo_last_operation tool
+func undoLastOperationServerTool(cfg *serverConfig) server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "undo_last_operation",
+			Description: "Rolls back the most recent file-modifying tool calls recorded in the server's journal",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"count": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of operations to undo, most recent first",
+						"minimum":     1,
+						"default":     1,
+					},
+				},
+			},
+			RawOutputSchema: json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"undone": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"id": {"type": "string"},
+					"tool": {"type": "string"},
+					"time": {"type": "string", "format": "date-time"},
+					"paths": {"type": "array", "items": {"type": "string"}}
+				},
+				"required": ["id", "tool", "time", "paths"]
+			}
+		}
+	},
+	"required": ["undone"]
+}`),
+		},
+		Handler: undoLastOperationTool(cfg),
+	}
+}
+
+// runUndo implements the "undo" subcommand, which rolls back journaled
+// operations without starting a server
+func runUndo(args []string) int {
+	flags := flag.NewFlagSet("undo", flag.ContinueOnError)
+	journalPath := flags.String("journal", "", "Journal file written by the server (required)")
+	count := flags.Int("n", 1, "Number of operations to undo, most recent first")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *journalPath == "" || flags.NArg() != 1 || *count < 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s undo -journal <file> [-n <count>] <root_directory>\n", os.Args[0])
+		return 2
+	}
+
+	rootDir, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to get absolute path: %v\n", err)
+		return 1
+	}
+	j, err := openJournal(*journalPath, rootDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	recs, err := j.undo(rootDir, *count)
+	_, text := newUndoResult(recs)
+	fmt.Print(text)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// journalConfig returns a write-enabled configuration for tempDir with a journal outside it
+func journalConfig(t *testing.T, tempDir string) *serverConfig {
+	t.Helper()
+
+	j, err := openJournal(filepath.Join(t.TempDir(), "journal.jsonl"), tempDir)
+	if err != nil {
+		t.Fatalf("openJournal failed: %v", err)
+	}
+	cfg := writeConfig(tempDir)
+	cfg.Journal = j
+	return cfg
+}
+
+// journaledHandler returns the journaled handler of the named tool
+func journaledHandler(cfg *serverConfig, name string) server.ToolHandlerFunc {
+	tools := []server.ServerTool{
+		writeFileServerTool(cfg),
+		editFileServerTool(cfg),
+		movePathServerTool(cfg),
+		deletePathServerTool(cfg),
+	}
+	if cfg.Trash != nil {
+		tools = append(tools, trashWriteServerTools(cfg)...)
+	}
+	journalAll(cfg, tools)
+	for _, tool := range tools {
+		if tool.Tool.Name == name {
+			return tool.Handler
+		}
+	}
+	return nil
+}
+
+func TestJournal_UndoWritesAndEdits(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := journalConfig(t, tempDir)
+	target := filepath.Join(tempDir, "new", "dir", "notes.txt")
+
+	calls := []struct{ name, arguments string }{
+		{"write_file", `{"path": "/new/dir/notes.txt", "content": "one\n", "create_parents": true}`},
+		{"edit_file", `{"path": "/new/dir/notes.txt", "edits": [{"old_string": "one", "new_string": "two"}]}`},
+		{"delete_path", `{"path": "/subdir", "recursive": true}`},
+	}
+	for _, c := range calls {
+		if _, err := callTool(journaledHandler(cfg, c.name), c.name, c.arguments); err != nil {
+			t.Fatalf("%s failed: %v", c.name, err)
+		}
+	}
+
+	// Failed and dry-run calls change nothing and are not journaled
+	callTool(journaledHandler(cfg, "edit_file"), "edit_file", `{"path": "/new/dir/notes.txt", "edits": [{"old_string": "missing", "new_string": "x"}]}`)
+	callTool(journaledHandler(cfg, "delete_path"), "delete_path", `{"path": "/file1.txt", "dry_run": true}`)
+	if recs, _ := cfg.Journal.records(); len(recs) != 3 {
+		t.Fatalf("Expected 3 journal records, got %d", len(recs))
+	}
+
+	// Undo the delete and the edit
+	result, err := callTool(undoLastOperationTool(cfg), "undo_last_operation", `{"count": 2}`)
+	if err != nil {
+		t.Fatalf("undo_last_operation failed: %v", err)
+	}
+	undone := result.StructuredContent.(undoResult).Undone
+	if len(undone) != 2 || undone[0].Tool != "delete_path" || undone[1].Tool != "edit_file" {
+		t.Fatalf("Unexpected undone operations %+v", undone)
+	}
+	if data, err := os.ReadFile(filepath.Join(tempDir, "subdir", "deep", "file3.json")); err != nil || string(data) != `{"test": true}` {
+		t.Errorf("Expected deleted tree to be restored, got %q (%v)", data, err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "one\n" {
+		t.Errorf("Expected edit to be undone, got %q", data)
+	}
+
+	// Undo the write, which also removes the directories it created
+	result, err = callTool(undoLastOperationTool(cfg), "undo_last_operation", `{}`)
+	if err != nil {
+		t.Fatalf("undo_last_operation failed: %v", err)
+	}
+	if undone := result.StructuredContent.(undoResult).Undone; len(undone) != 1 || undone[0].Paths[0] != "/new" {
+		t.Errorf("Unexpected undone operations %+v", undone)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "new")); !os.IsNotExist(err) {
+		t.Error("Expected created directories to be removed")
+	}
+
+	// Nothing left to undo
+	result, _ = callTool(undoLastOperationTool(cfg), "undo_last_operation", `{}`)
+	if undone := result.StructuredContent.(undoResult).Undone; len(undone) != 0 {
+		t.Errorf("Expected nothing to undo, got %+v", undone)
+	}
+}
+
+func TestJournal_UndoTrash(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := journalConfig(t, tempDir)
+	cfg.Trash = trashConfig(t, tempDir, 0).Trash
+
+	if _, err := callTool(journaledHandler(cfg, "trash_path"), "trash_path", `{"path": "/subdir"}`); err != nil {
+		t.Fatalf("trash_path failed: %v", err)
+	}
+	recs, _ := cfg.Journal.records()
+	if len(recs) != 1 || len(recs[0].Before) != 1 || recs[0].Before[0].State != snapshotTrashed {
+		t.Fatalf("Expected the trash entry to be recorded, got %+v", recs)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Journal.snapDir, recs[0].ID)); !os.IsNotExist(err) {
+		t.Error("Expected no snapshot copy of the trashed tree")
+	}
+
+	// Undoing moves the entry back out of the trash instead of copying it
+	if _, err := callTool(undoLastOperationTool(cfg), "undo_last_operation", `{}`); err != nil {
+		t.Fatalf("undo_last_operation failed: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(tempDir, "subdir", "file2.go")); err != nil || string(data) != "package main" {
+		t.Errorf("Expected the trashed tree to be restored, got %q (%v)", data, err)
+	}
+	if entries, _ := cfg.Trash.list(); len(entries) != 0 {
+		t.Errorf("Expected the trash to be empty after undo, got %+v", entries)
+	}
+
+	// Undo rewrites the root, so it needs writes
+	cfg.AllowWrite = false
+	if _, err := callTool(undoLastOperationTool(cfg), "undo_last_operation", `{}`); err != errWriteDisabled {
+		t.Errorf("Expected errWriteDisabled, got %v", err)
+	}
+}
+
+func TestJournal_UndoRestore(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := journalConfig(t, tempDir)
+	cfg.Trash = trashConfig(t, tempDir, 0).Trash
+	cfg.PathStyle = pathStyleVirtual
+
+	result, err := callTool(journaledHandler(cfg, "trash_path"), "trash_path", `{"path": "/file1.txt"}`)
+	if err != nil {
+		t.Fatalf("trash_path failed: %v", err)
+	}
+	trashed := result.StructuredContent.(trashEntry)
+	if _, err := callTool(journaledHandler(cfg, "restore_from_trash"), "restore_from_trash", fmt.Sprintf(`{"id": %q}`, trashed.ID)); err != nil {
+		t.Fatalf("restore_from_trash failed: %v", err)
+	}
+
+	// Undoing the restore puts the file back into its trash slot, not away for good
+	if _, err := callTool(undoLastOperationTool(cfg), "undo_last_operation", `{}`); err != nil {
+		t.Fatalf("undo_last_operation failed: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(tempDir, "file1.txt")); !os.IsNotExist(err) {
+		t.Error("Expected the restored file to be gone from the root")
+	}
+	result, err = callTool(listTrashTool(cfg), "list_trash", `{}`)
+	if err != nil {
+		t.Fatalf("list_trash failed: %v", err)
+	}
+	entries := result.StructuredContent.(trashListResult).Entries
+	if len(entries) != 1 || entries[0].ID != trashed.ID || entries[0].OriginalPath != "/file1.txt" || !entries[0].TrashedAt.Equal(trashed.TrashedAt) {
+		t.Fatalf("Expected %+v back in the trash, got %+v", trashed, entries)
+	}
+
+	// With overwrite, undo also brings back what the restore displaced
+	writeTestFile(t, filepath.Join(tempDir, "file1.txt"), "newer")
+	result, err = callTool(journaledHandler(cfg, "restore_from_trash"), "restore_from_trash", fmt.Sprintf(`{"id": %q, "overwrite": true}`, trashed.ID))
+	if err != nil {
+		t.Fatalf("restore_from_trash failed: %v", err)
+	}
+	displaced := result.StructuredContent.(trashRestoreResult).Displaced
+	result, err = callTool(undoLastOperationTool(cfg), "undo_last_operation", `{}`)
+	if err != nil {
+		t.Fatalf("undo_last_operation failed: %v", err)
+	}
+	if undone := result.StructuredContent.(undoResult).Undone; len(undone) != 1 || len(undone[0].Paths) != 1 {
+		t.Errorf("Unexpected undone operations %+v", undone)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "file1.txt")); string(data) != "newer" {
+		t.Errorf("Expected the displaced file to be back, got %q", data)
+	}
+	if _, err := cfg.Trash.get(trashed.ID); err != nil {
+		t.Errorf("Expected %s back in the trash: %v", trashed.ID, err)
+	}
+	if _, err := cfg.Trash.get(displaced); err == nil {
+		t.Errorf("Expected displaced entry %s to leave the trash", displaced)
+	}
+
+	// A restored entry that has since been removed cannot go back
+	if _, err := callTool(journaledHandler(cfg, "restore_from_trash"), "restore_from_trash", fmt.Sprintf(`{"id": %q, "destination": "/restored.txt"}`, trashed.ID)); err != nil {
+		t.Fatalf("restore_from_trash failed: %v", err)
+	}
+	os.Remove(filepath.Join(tempDir, "restored.txt"))
+	if _, err := callTool(undoLastOperationTool(cfg), "undo_last_operation", `{}`); err == nil || !contains(err.Error(), "removed since it was restored") {
+		t.Errorf("Expected undo to refuse, got %v", err)
+	}
+}
+
+func TestRunUndo(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := journalConfig(t, tempDir)
+	if _, err := callTool(journaledHandler(cfg, "move_path"), "move_path", `{"source": "/file1.txt", "destination": "/renamed.txt"}`); err != nil {
+		t.Fatalf("move_path failed: %v", err)
+	}
+
+	if code := runUndo([]string{"-journal", cfg.Journal.path, tempDir}); code != 0 {
+		t.Fatalf("runUndo exited with %d", code)
+	}
+	if data, err := os.ReadFile(filepath.Join(tempDir, "file1.txt")); err != nil || string(data) != "test content" {
+		t.Errorf("Expected move to be undone, got %q (%v)", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "renamed.txt")); !os.IsNotExist(err) {
+		t.Error("Expected move destination to be removed")
+	}
+
+	if code := runUndo([]string{tempDir}); code != 2 {
+		t.Errorf("Expected usage error without -journal, got %d", code)
+	}
+}
+
+func TestOpenJournal_InsideRoot(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	if _, err := openJournal(filepath.Join(tempDir, "journal.jsonl"), tempDir); err == nil {
+		t.Error("Expected a journal inside the root to be rejected")
+	}
+}
@@ -1,222 +1,710 @@
-package main
-
-import (
-	"context"
-	"encoding/json"
-	"flag"
-	"fmt"
-	"log"
-	"net/http"
-	"os"
-	"path/filepath"
-	"strconv"
-	"strings"
-	"time"
-
-	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
-)
-
-// walkDirectoryTool implements the walk_directory tool handler
-func walkDirectoryTool(rootDir string) server.ToolHandlerFunc {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		// Log the tool call with arguments
-		if request.Params.Arguments != nil {
-			if args, ok := request.Params.Arguments.(json.RawMessage); ok {
-				log.Printf("[TOOL CALL] %s with arguments: %s", request.Params.Name, string(args))
-			} else {
-				log.Printf("[TOOL CALL] %s with arguments: %v", request.Params.Name, request.Params.Arguments)
-			}
-		} else {
-			log.Printf("[TOOL CALL] %s with no arguments", request.Params.Name)
-		}
-		// Parse the arguments
-		var args struct {
-			Path string `json:"path"`
-		}
-		
-		// Set default path to "/"
-		args.Path = "/"
-		
-		// Parse arguments if provided
-		if err := request.BindArguments(&args); err != nil {
-			return nil, fmt.Errorf("failed to parse arguments: %w", err)
-		}
-		
-		// Map the input path to actual filesystem path
-		var targetPath string
-		if args.Path == "/" {
-			targetPath = rootDir
-		} else {
-			// Remove leading slash and join with root
-			cleanPath := strings.TrimPrefix(args.Path, "/")
-			targetPath = filepath.Join(rootDir, cleanPath)
-		}
-		
-		// Ensure the target path is within the root directory (security check)
-		absRoot, err := filepath.Abs(rootDir)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get absolute root path: %w", err)
-		}
-		
-		absTarget, err := filepath.Abs(targetPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get absolute target path: %w", err)
-		}
-		
-		if !strings.HasPrefix(absTarget, absRoot) {
-			return nil, fmt.Errorf("path is outside root directory")
-		}
-		
-		// Check if target path exists
-		if _, err := os.Stat(absTarget); os.IsNotExist(err) {
-			return nil, fmt.Errorf("path does not exist: %s", args.Path)
-		}
-		
-		// Walk the directory tree
-		var files []string
-		err = filepath.WalkDir(absTarget, func(path string, d os.DirEntry, err error) error {
-			if err != nil {
-				// Log permission errors but continue walking
-				if os.IsPermission(err) {
-					log.Printf("Permission denied: %s", path)
-					return nil
-				}
-				return err
-			}
-			
-			// Convert to absolute path and normalize separators
-			absPath, err := filepath.Abs(path)
-			if err != nil {
-				return err
-			}
-			
-			// Convert to forward slashes for cross-platform consistency
-			normalizedPath := filepath.ToSlash(absPath)
-			files = append(files, normalizedPath)
-			
-			return nil
-		})
-		
-		if err != nil {
-			return nil, fmt.Errorf("failed to walk directory: %w", err)
-		}
-		
-		// Create result with structured content
-		result := mcp.NewToolResultStructured(files, fmt.Sprintf("Found %d files and directories", len(files)))
-		
-		// Log the completion
-		log.Printf("[TOOL COMPLETED] %s - found %d files/directories", request.Params.Name, len(files))
-		
-		return result, nil
-	}
-}
-
-// loggingMiddleware logs all incoming HTTP requests
-func loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		
-		// Log request details
-		log.Printf("[HTTP REQUEST] %s %s from %s", r.Method, r.RequestURI, r.RemoteAddr)
-		
-		// Call the next handler
-		next.ServeHTTP(w, r)
-		
-		// Log completion
-		log.Printf("[HTTP COMPLETED] %s %s - took %s", r.Method, r.RequestURI, time.Since(start))
-	})
-}
-
-func main() {
-	// Parse command line arguments
-	var useStdio bool
-	flag.BoolVar(&useStdio, "s", false, "Use stdio transport instead of HTTP")
-	flag.Parse()
-	
-	// Get root directory argument
-	args := flag.Args()
-	if len(args) != 1 {
-		fmt.Fprintf(os.Stderr, "Usage: %s [-s] <root_directory>\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "  -s: Use stdio transport instead of HTTP\n")
-		os.Exit(1)
-	}
-	
-	rootDir := args[0]
-	
-	// Validate root directory exists
-	if _, err := os.Stat(rootDir); os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "Error: Root directory does not exist: %s\n", rootDir)
-		os.Exit(1)
-	}
-	
-	// Convert to absolute path for consistency
-	absRootDir, err := filepath.Abs(rootDir)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to get absolute path: %v\n", err)
-		os.Exit(1)
-	}
-	
-	// Create MCP server with logging
-	mcpServer := server.NewMCPServer("directory-walker", "1.0.0")
-	log.Printf("MCP Server created: directory-walker v1.0.0")
-	
-	// Define the walk_directory tool
-	walkTool := server.ServerTool{
-		Tool: mcp.Tool{
-			Name:        "walk_directory",
-			Description: "Recursively lists all files and directories under the specified path",
-			InputSchema: mcp.ToolInputSchema{
-				Type: "object",
-				Properties: map[string]interface{}{
-					"path": map[string]interface{}{
-						"type":        "string",
-						"description": "Directory path to walk (use '/' for root directory)",
-						"default":     "/",
-					},
-				},
-			},
-		},
-		Handler: walkDirectoryTool(absRootDir),
-	}
-	
-	// Register the tool
-	mcpServer.AddTools(walkTool)
-	log.Printf("Registered tool: %s", walkTool.Tool.Name)
-	
-	// Start server based on transport mode
-	if useStdio {
-		fmt.Fprintf(os.Stderr, "Starting MCP Directory Walker Server (stdio) for root: %s\n", absRootDir)
-		err = server.ServeStdio(mcpServer)
-	} else {
-		// HTTP server
-		port := os.Getenv("PORT")
-		if port == "" {
-			port = "5001"
-		}
-		
-		portNum, err := strconv.Atoi(port)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: Invalid PORT value: %s\n", port)
-			os.Exit(1)
-		}
-		
-		fmt.Fprintf(os.Stderr, "Starting MCP Directory Walker Server (HTTP) on port %d for root: %s\n", portNum, absRootDir)
-		
-		// Create HTTP server - using StreamableHTTPServer for the /mcp path
-		httpServer := server.NewStreamableHTTPServer(mcpServer, 
-			server.WithEndpointPath("/mcp"),
-			server.WithStateLess(true),
-		)
-		
-		// Add basic request logging information
-		log.Printf("HTTP MCP Server ready to accept requests on http://localhost:%d/mcp", portNum)
-		
-		err = httpServer.Start(fmt.Sprintf(":%d", portNum))
-	}
-	
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error starting server: %v\n", err)
-		os.Exit(1)
-	}
-}
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// serverConfig holds the settings shared by all tool handlers
+type serverConfig struct {
+	RootDir            string         // absolute path of the served root directory
+	RespectIgnoreFiles bool           // default for walk_directory's respect_ignore_files argument
+	IgnoreRules        *ignoreMatcher // rules from the server-level ignore file, if any
+	PathStyle          string         // default style for paths in results
+	SymlinkPolicy      string         // how symlinks in requested paths are treated
+	MaxReadBytes       int64          // most file content read_file returns per call
+	AllowWrite         bool           // enables the tools that modify files
+	Trash              *trash         // server-managed trash; nil disables the trash tools
+	Journal            *journal       // journal of file-modifying calls; nil disables journaling
+	WalkTimeout        time.Duration  // longest a walk_directory call runs before returning what it found; 0 for no limit
+}
+
+// newServerConfig returns the default configuration for serving rootDir
+func newServerConfig(rootDir string) *serverConfig {
+	return &serverConfig{
+		RootDir:            rootDir,
+		RespectIgnoreFiles: true,
+		PathStyle:          pathStyleAbsolute,
+		SymlinkPolicy:      symlinkPolicyWithinRoot,
+		MaxReadBytes:       defaultMaxReadBytes,
+	}
+}
+
+// walkResult is the structured content returned by walk_directory. Files is
+// used for plain path listings; Entries replaces it when metadata fields are requested.
+type walkResult struct {
+	Files      []string   `json:"files,omitempty"`
+	Entries    []walkItem `json:"entries,omitempty"`
+	Tree       *treeNode  `json:"tree,omitempty"`        // tree and nested_json formats
+	NextCursor string     `json:"next_cursor,omitempty"` // set when more entries remain
+	Truncated  bool       `json:"truncated,omitempty"`   // the server's walk timeout ended the walk early
+}
+
+// walkOutputSchema describes walkResult for clients that validate structured content
+const walkOutputSchema = `{
+	"type": "object",
+	"$defs": {
+		"node": {
+			"type": "object",
+			"properties": {
+				"name": {"type": "string"},
+				"path": {"type": "string"},
+				"type": {"type": "string", "enum": ["file", "dir", "symlink", "other"]},
+				"size": {"type": "integer"},
+				"mode": {"type": "string"},
+				"mtime": {"type": "string", "format": "date-time"},
+				"symlink_target": {"type": "string"},
+				"child_count": {"type": "integer"},
+				"children": {"type": "array", "items": {"$ref": "#/$defs/node"}},
+				"omitted": {"type": "integer", "description": "Children left out by max_entries_per_dir"}
+			},
+			"required": ["name", "path", "type"]
+		}
+	},
+	"properties": {
+		"files": {
+			"type": "array",
+			"description": "Paths of matching entries (when no fields were requested)",
+			"items": {"type": "string"}
+		},
+		"entries": {
+			"type": "array",
+			"description": "Matching entries with the requested metadata fields",
+			"items": {
+				"type": "object",
+				"properties": {
+					"path": {"type": "string"},
+					"type": {"type": "string", "enum": ["file", "dir", "symlink", "other"]},
+					"size": {"type": "integer"},
+					"mode": {"type": "string", "description": "Octal permission bits"},
+					"mtime": {"type": "string", "format": "date-time"},
+					"symlink_target": {"type": "string"},
+					"child_count": {"type": "integer"}
+				},
+				"required": ["path"]
+			}
+		},
+		"tree": {
+			"$ref": "#/$defs/node",
+			"description": "Nested entries (tree and nested_json formats)"
+		},
+		"next_cursor": {
+			"type": "string",
+			"description": "Pass as cursor to fetch the next page"
+		},
+		"truncated": {
+			"type": "boolean",
+			"description": "The walk hit the server's time limit; the results are partial"
+		}
+	}
+}`
+
+// walkDirectoryTool implements the walk_directory tool handler
+func walkDirectoryTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+		
+		// Parse the arguments
+		var args struct {
+			Path          string   `json:"path"`
+			MaxDepth      int      `json:"max_depth"`
+			Type          string   `json:"type"`
+			IncludeHidden bool     `json:"include_hidden"`
+			Include       []string `json:"include"`
+			Exclude       []string `json:"exclude"`
+			PatternSyntax string   `json:"pattern_syntax"`
+			RespectIgnore bool     `json:"respect_ignore_files"`
+			Limit         int      `json:"limit"`
+			Cursor        string   `json:"cursor"`
+			Fields        []string `json:"fields"`
+			Format        string   `json:"format"`
+			MaxPerDir     int      `json:"max_entries_per_dir"`
+			PathStyle     string   `json:"path_style"`
+		}
+		
+		// Set defaults: walk everything from "/"
+		args.Path = "/"
+		args.MaxDepth = -1
+		args.Type = entryTypeAll
+		args.IncludeHidden = true
+		args.PatternSyntax = patternSyntaxGlob
+		args.RespectIgnore = cfg.RespectIgnoreFiles
+		args.Format = formatList
+		args.PathStyle = cfg.PathStyle
+		
+		// Parse arguments if provided
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+
+		if !validEntryType(args.Type) {
+			return nil, fmt.Errorf("invalid type %q: must be one of files, dirs, symlinks, all", args.Type)
+		}
+
+		if args.Limit < 0 {
+			return nil, fmt.Errorf("invalid limit %d: must not be negative", args.Limit)
+		}
+
+		switch args.Format {
+		case formatList:
+		case formatTree, formatNestedJSON:
+			if args.Limit > 0 || args.Cursor != "" {
+				return nil, fmt.Errorf("limit and cursor are only supported with the list format")
+			}
+		default:
+			return nil, fmt.Errorf("invalid format %q: must be one of list, tree, nested_json", args.Format)
+		}
+
+		fields, err := newEntryFieldSet(args.Fields)
+		if err != nil {
+			return nil, err
+		}
+
+		opts := defaultWalkOptions()
+		opts.MaxDepth = args.MaxDepth
+		opts.Type = args.Type
+		opts.IncludeHidden = args.IncludeHidden
+		opts.RespectIgnore = args.RespectIgnore
+		opts.Ignore = cfg.IgnoreRules
+
+		include, err := newPathMatcher(args.Include, args.PatternSyntax)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include: %w", err)
+		}
+		opts.Include = include
+
+		exclude, err := newPathMatcher(args.Exclude, args.PatternSyntax)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude: %w", err)
+		}
+		opts.Exclude = exclude
+
+		// Resume after the position recorded in the cursor
+		if args.Cursor != "" {
+			cursor, err := decodeCursor(args.Cursor)
+			if err != nil {
+				return nil, err
+			}
+			if cursor.Path != args.Path {
+				return nil, fmt.Errorf("invalid cursor: it was issued for path %s", cursor.Path)
+			}
+			opts.After = cursor.After
+		}
+		
+		// Map the input path to actual filesystem path, enforcing root containment
+		absRoot := cfg.RootDir
+		absTarget, err := resolvePath(cfg, args.Path)
+		if err != nil {
+			return nil, err
+		}
+		
+		// Check if target path exists
+		if _, err := os.Lstat(absTarget); os.IsNotExist(err) {
+			return nil, fmt.Errorf("path does not exist: %s", args.Path)
+		}
+		
+		// Format output paths in the requested style
+		formatter, err := newPathFormatter(args.PathStyle, absRoot, relPath(absRoot, absTarget))
+		if err != nil {
+			return nil, err
+		}
+		outPath := formatter.format
+		
+		// Stop at the server's time limit with what has been found so far,
+		// and report progress when the client asked for it
+		walkCtx := ctx
+		if cfg.WalkTimeout > 0 {
+			var cancel context.CancelFunc
+			walkCtx, cancel = context.WithTimeout(ctx, cfg.WalkTimeout)
+			defer cancel()
+		}
+		opts.Context = walkCtx
+		
+		progress := newProgressReporter(ctx, request)
+		var count int
+		opts.Visit = func(scanned int) {
+			progress.reportThrottled(float64(scanned), 0, fmt.Sprintf("Scanned %d entries, found %d", scanned, count))
+		}
+		
+		// Walk the directory tree, stopping once the page is full
+		var page walkResult
+		var tree *treeBuilder
+		switch {
+		case args.Format != formatList:
+			tree = newTreeBuilder(relPath(absRoot, absTarget), args.MaxPerDir, outPath)
+		case len(fields) > 0:
+			page.Entries = []walkItem{}
+		default:
+			page.Files = []string{}
+		}
+		var lastRel string
+		err = walkTree(absRoot, absTarget, opts, func(entry walkEntry) error {
+			if args.Limit > 0 && count == args.Limit {
+				page.NextCursor = encodeCursor(walkCursor{Path: args.Path, After: lastRel})
+				return errStopWalk
+			}
+			
+			item := walkItem{Path: outPath(entry.Rel)}
+			if len(fields) > 0 {
+				item = newWalkItem(item.Path, entry, fields)
+			}
+			
+			switch {
+			case tree != nil:
+				// Tree nodes always carry their type so directories can be told apart
+				item.Type = entryTypeName(entry.Entry.Type())
+				if !tree.add(entry.Rel, item, entry.Entry.IsDir()) {
+					// Over the per-directory cap: count it and don't walk its contents
+					return skipEntry(entry.Entry)
+				}
+			case len(fields) > 0:
+				page.Entries = append(page.Entries, item)
+			default:
+				page.Files = append(page.Files, item.Path)
+			}
+			count++
+			lastRel = entry.Rel
+			return nil
+		})
+		
+		switch {
+		case ctx.Err() != nil:
+			// The client cancelled the call or its own deadline passed
+			return nil, ctx.Err()
+		case walkCtx.Err() != nil && errors.Is(err, walkCtx.Err()):
+			// List pages can be resumed where the time limit stopped them
+			page.Truncated = true
+			if args.Format == formatList && count > 0 {
+				page.NextCursor = encodeCursor(walkCursor{Path: args.Path, After: lastRel})
+			}
+		case err != nil && !errors.Is(err, errStopWalk):
+			return nil, fmt.Errorf("failed to walk directory: %w", err)
+		}
+		
+		// Create result with structured content
+		summary := fmt.Sprintf("Found %d files and directories", count)
+		if page.Truncated {
+			summary += fmt.Sprintf("; stopped early by the server's %s walk time limit", cfg.WalkTimeout)
+		}
+		if page.NextCursor != "" {
+			summary += fmt.Sprintf("; more results available, pass cursor %q to continue", page.NextCursor)
+		}
+		
+		switch args.Format {
+		case formatTree:
+			page.Tree = tree.root
+			summary = renderTree(tree.root) + "\n" + summary
+		case formatNestedJSON:
+			page.Tree = tree.root
+			data, err := json.MarshalIndent(page, "", "  ")
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode tree: %w", err)
+			}
+			summary = string(data)
+		}
+		result := mcp.NewToolResultStructured(page, summary)
+		
+		// Log the completion
+		log.Printf("[TOOL COMPLETED] %s - found %d files/directories", request.Params.Name, count)
+		
+		return result, nil
+	}
+}
+
+// walkDirectoryServerTool defines the walk_directory tool
+func walkDirectoryServerTool(cfg *serverConfig) server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "walk_directory",
+			Description: "Recursively lists all files and directories under the specified path",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Directory path to walk (use '/' for root directory)",
+						"default":     "/",
+					},
+					"max_depth": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum depth to descend below the path (0 lists only the path itself, 1 adds its immediate children; omit for unlimited)",
+						"minimum":     0,
+					},
+					"type": map[string]interface{}{
+						"type":        "string",
+						"description": "Only return entries of this type",
+						"enum":        []string{"files", "dirs", "symlinks", "all"},
+						"default":     "all",
+					},
+					"include_hidden": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Include dot-files and dot-directories (hidden directories are not descended into when false)",
+						"default":     true,
+					},
+					"include": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Only return entries whose root-relative path matches one of these patterns (globs without '/' match the base name at any depth, e.g. '*.go')",
+					},
+					"exclude": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Skip entries whose root-relative path matches one of these patterns; excluded directories are not descended into (e.g. 'vendor', '**/testdata')",
+					},
+					"pattern_syntax": map[string]interface{}{
+						"type":        "string",
+						"description": "Syntax of include/exclude patterns: doublestar globs or unanchored regular expressions",
+						"enum":        []string{"glob", "regex"},
+						"default":     "glob",
+					},
+					"respect_ignore_files": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Skip entries matched by .gitignore/.ignore files and the server ignore file, and never descend into .git directories",
+						"default":     cfg.RespectIgnoreFiles,
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of entries to return; when more remain the result includes next_cursor (omit or 0 for no limit)",
+						"minimum":     0,
+					},
+					"cursor": map[string]interface{}{
+						"type":        "string",
+						"description": "Opaque next_cursor from a previous call with the same path, to continue where it stopped",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"description": "Result layout: 'list' of paths, 'tree' drawn with box characters in the text content, or 'nested_json' with children arrays (both tree formats fill 'tree' in the structured content)",
+						"enum":        []string{"list", "tree", "nested_json"},
+						"default":     "list",
+					},
+					"max_entries_per_dir": map[string]interface{}{
+						"type":        "integer",
+						"description": "With tree formats, show at most this many children per directory and collapse the rest into a '... N more entries' marker (0 for no cap)",
+						"minimum":     0,
+					},
+					"path_style": map[string]interface{}{
+						"type":        "string",
+						"description": "How result paths are written: 'virtual' ('/src/x.go', usable as the path argument of later calls), 'relative' (to the requested path) or 'absolute' (host filesystem path)",
+						"enum":        []string{"virtual", "relative", "absolute"},
+						"default":     cfg.PathStyle,
+					},
+					"fields": map[string]interface{}{
+						"type":        "array",
+						"description": "Metadata to return for each entry; when set the result lists objects under 'entries' instead of paths under 'files'",
+						"items": map[string]interface{}{
+							"type": "string",
+							"enum": entryFields,
+						},
+					},
+				},
+			},
+			RawOutputSchema: json.RawMessage(walkOutputSchema),
+		},
+		Handler: walkDirectoryTool(cfg),
+	}
+}
+
+// logToolCall logs a tool call with its arguments
+func logToolCall(request mcp.CallToolRequest) {
+	if request.Params.Arguments != nil {
+		if args, ok := request.Params.Arguments.(json.RawMessage); ok {
+			log.Printf("[TOOL CALL] %s with arguments: %s", request.Params.Name, string(args))
+		} else {
+			log.Printf("[TOOL CALL] %s with arguments: %v", request.Params.Name, request.Params.Arguments)
+		}
+	} else {
+		log.Printf("[TOOL CALL] %s with no arguments", request.Params.Name)
+	}
+}
+
+// loggingMiddleware logs all incoming HTTP requests
+func loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		
+		// Log request details
+		log.Printf("[HTTP REQUEST] %s %s from %s", r.Method, r.RequestURI, r.RemoteAddr)
+		
+		// Call the next handler
+		next.ServeHTTP(w, r)
+		
+		// Log completion
+		log.Printf("[HTTP COMPLETED] %s %s - took %s", r.Method, r.RequestURI, time.Since(start))
+	})
+}
+
+func main() {
+	// Subcommands are handled before the server's own flags
+	if len(os.Args) > 1 && os.Args[1] == "undo" {
+		os.Exit(runUndo(os.Args[2:]))
+	}
+	
+	// Parse command line arguments
+	var useStdio bool
+	var respectIgnoreFiles bool
+	var ignoreFile string
+	var pathStyle string
+	var symlinkPolicy string
+	var maxReadBytes int64
+	var allowWrite bool
+	var trashDir string
+	var trashRetention time.Duration
+	var journalPath string
+	var watch bool
+	var walkTimeout time.Duration
+	flag.BoolVar(&useStdio, "s", false, "Use stdio transport instead of HTTP")
+	flag.BoolVar(&respectIgnoreFiles, "respect-ignore-files", true, "Honor .gitignore/.ignore files in walks unless a call overrides it")
+	flag.StringVar(&ignoreFile, "ignore-file", "", "Server-level ignore file (gitignore syntax, patterns relative to the root)")
+	flag.StringVar(&pathStyle, "path-style", pathStyleAbsolute, "Default style for result paths: virtual, relative or absolute")
+	flag.StringVar(&symlinkPolicy, "symlink-policy", symlinkPolicyWithinRoot, "Symlinks in requested paths: deny, within-root or follow")
+	flag.Int64Var(&maxReadBytes, "max-read-bytes", defaultMaxReadBytes, "Maximum bytes of file content read_file returns per call")
+	flag.BoolVar(&allowWrite, "allow-write", false, "Enable tools that modify files under the root")
+	flag.StringVar(&trashDir, "trash-dir", "", "Enable the trash tools, keeping trashed entries in this directory outside the root")
+	flag.StringVar(&journalPath, "journal", "", "Record file-modifying tool calls in this JSON-lines journal so they can be undone")
+	flag.DurationVar(&walkTimeout, "walk-timeout", 0, "Stop walk_directory calls after this long and return the partial results (e.g. 30s); 0 for no limit")
+	flag.BoolVar(&watch, "watch", false, "Watch the root so clients can subscribe to resource changes")
+	flag.DurationVar(&trashRetention, "trash-retention", 0, "Permanently delete trash entries older than this (e.g. 720h); 0 keeps them")
+	flag.Parse()
+	
+	// Get root directory argument
+	args := flag.Args()
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s [-s] [-respect-ignore-files=false] [-ignore-file <file>] [-path-style <style>] [-symlink-policy <policy>] [-max-read-bytes <n>] [-allow-write] [-trash-dir <dir>] [-trash-retention <duration>] [-journal <file>] [-walk-timeout <duration>] [-watch] <root_directory>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  -s: Use stdio transport instead of HTTP\n")
+		fmt.Fprintf(os.Stderr, "  -respect-ignore-files: Honor .gitignore/.ignore files by default (default true)\n")
+		fmt.Fprintf(os.Stderr, "  -ignore-file: Server-level ignore file applied to every walk\n")
+		fmt.Fprintf(os.Stderr, "  -path-style: Default style for result paths: virtual, relative or absolute (default absolute)\n")
+		fmt.Fprintf(os.Stderr, "  -symlink-policy: Symlinks in requested paths: deny, within-root or follow (default within-root)\n")
+		fmt.Fprintf(os.Stderr, "  -max-read-bytes: Maximum bytes of file content read_file returns per call (default %d)\n", defaultMaxReadBytes)
+		fmt.Fprintf(os.Stderr, "  -allow-write: Enable tools that modify files under the root (default false)\n")
+		fmt.Fprintf(os.Stderr, "  -trash-dir: Enable the trash tools, keeping trashed entries in this directory outside the root\n")
+		fmt.Fprintf(os.Stderr, "  -trash-retention: Permanently delete trash entries older than this duration (default 0, keep forever)\n")
+		fmt.Fprintf(os.Stderr, "  -journal: Record file-modifying tool calls in this journal so they can be undone\n")
+		fmt.Fprintf(os.Stderr, "  -walk-timeout: Stop walk_directory calls after this long and return the partial results (default 0, no limit)\n")
+		fmt.Fprintf(os.Stderr, "  -watch: Watch the root so clients can subscribe to resource changes; HTTP then runs in stateful mode (default false)\n")
+		fmt.Fprintf(os.Stderr, "\nTo roll back journaled operations: %s undo -journal <file> [-n <count>] <root_directory>\n", os.Args[0])
+		os.Exit(1)
+	}
+	
+	rootDir := args[0]
+	
+	// Validate root directory exists
+	if _, err := os.Stat(rootDir); os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Error: Root directory does not exist: %s\n", rootDir)
+		os.Exit(1)
+	}
+	
+	// Convert to absolute path for consistency
+	absRootDir, err := filepath.Abs(rootDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to get absolute path: %v\n", err)
+		os.Exit(1)
+	}
+	
+	cfg := newServerConfig(absRootDir)
+	cfg.RespectIgnoreFiles = respectIgnoreFiles
+	
+	if !validPathStyle(pathStyle) {
+		fmt.Fprintf(os.Stderr, "Error: Invalid -path-style %q: must be virtual, relative or absolute\n", pathStyle)
+		os.Exit(1)
+	}
+	cfg.PathStyle = pathStyle
+	
+	if !validSymlinkPolicy(symlinkPolicy) {
+		fmt.Fprintf(os.Stderr, "Error: Invalid -symlink-policy %q: must be deny, within-root or follow\n", symlinkPolicy)
+		os.Exit(1)
+	}
+	cfg.SymlinkPolicy = symlinkPolicy
+	
+	if maxReadBytes <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: Invalid -max-read-bytes %d: must be positive\n", maxReadBytes)
+		os.Exit(1)
+	}
+	cfg.MaxReadBytes = maxReadBytes
+	cfg.AllowWrite = allowWrite
+	
+	if walkTimeout < 0 {
+		fmt.Fprintf(os.Stderr, "Error: Invalid -walk-timeout %s: must not be negative\n", walkTimeout)
+		os.Exit(1)
+	}
+	cfg.WalkTimeout = walkTimeout
+	
+	// Set up the trash, applying the retention policy once at startup
+	if trashRetention < 0 {
+		fmt.Fprintf(os.Stderr, "Error: Invalid -trash-retention %s: must not be negative\n", trashRetention)
+		os.Exit(1)
+	}
+	if trashDir != "" {
+		cfg.Trash, err = newTrash(trashDir, absRootDir, trashRetention)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		purgeTrash(cfg.Trash)
+		log.Printf("Trash enabled at %s (retention %s)", cfg.Trash.dir, trashRetention)
+	}
+	
+	if journalPath != "" {
+		cfg.Journal, err = openJournal(journalPath, absRootDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		log.Printf("Journaling file-modifying tool calls to %s", cfg.Journal.path)
+	}
+	
+	// Load the server-level ignore file
+	if ignoreFile != "" {
+		rules, err := loadIgnoreFile(ignoreFile, "")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to read ignore file: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.IgnoreRules = cfg.IgnoreRules.withRules(rules)
+		log.Printf("Loaded %d rules from ignore file %s", len(rules), ignoreFile)
+	}
+	
+	// Let clients cancel tool calls; resource subscriptions also need the
+	// watcher in place before the server is created
+	calls := newCallTracker()
+	hooks := &server.Hooks{}
+	calls.addHooks(hooks)
+	serverOptions := []server.ServerOption{
+		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(calls.middleware),
+	}
+	var watcher *resourceWatcher
+	if watch {
+		watcher = newResourceWatcher(cfg, watchDebounce)
+		watcher.addHooks(hooks)
+		serverOptions = append(serverOptions, server.WithResourceCapabilities(true, true))
+	}
+	
+	// Create MCP server with logging
+	mcpServer := server.NewMCPServer("directory-walker", "1.0.0", serverOptions...)
+	mcpServer.AddNotificationHandler(methodNotificationCancelled, calls.handleCancelled)
+	log.Printf("MCP Server created: directory-walker v1.0.0")
+	
+	// Register the tools
+	tools := []server.ServerTool{
+		walkDirectoryServerTool(cfg),
+		readFileServerTool(cfg),
+		searchFilesServerTool(cfg),
+		statPathServerTool(cfg),
+		hashFilesServerTool(cfg),
+		verifyChecksumsServerTool(cfg),
+		findDuplicatesServerTool(cfg),
+		diskUsageServerTool(cfg),
+		recentFilesServerTool(cfg),
+		watchDirectoryServerTool(cfg),
+	}
+	
+	// Tools that modify files are only exposed when explicitly enabled
+	if cfg.AllowWrite {
+		tools = append(tools,
+			writeFileServerTool(cfg),
+			editFileServerTool(cfg),
+			applyPatchServerTool(cfg),
+			createDirectoryServerTool(cfg),
+			movePathServerTool(cfg),
+			copyPathServerTool(cfg),
+			deletePathServerTool(cfg),
+		)
+	}
+	
+	// Listing the trash leaves everything alone; moving entries in and out of
+	// it changes the tree and emptying it destroys them, so those need -allow-write
+	if cfg.Trash != nil {
+		tools = append(tools, trashServerTools(cfg)...)
+		if cfg.AllowWrite {
+			tools = append(tools, trashWriteServerTools(cfg)...)
+		}
+	}
+	
+	// Journaling wraps every file-modifying handler in one place; undoing
+	// rewrites the root, so it is only offered when writes are allowed
+	if cfg.Journal != nil {
+		journalAll(cfg, tools)
+		if cfg.AllowWrite {
+			tools = append(tools, undoLastOperationServerTool(cfg))
+		}
+	}
+	mcpServer.AddTools(tools...)
+	for _, tool := range tools {
+		log.Printf("Registered tool: %s", tool.Tool.Name)
+	}
+	
+	// Expose the served tree as resources for clients that browse natively
+	if err := registerResources(mcpServer, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if watcher != nil {
+		if err := watcher.start(mcpServer); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer watcher.close()
+	}
+	
+	// Start server based on transport mode
+	if useStdio {
+		fmt.Fprintf(os.Stderr, "Starting MCP Directory Walker Server (stdio) for root: %s\n", absRootDir)
+		if watcher != nil {
+			err = serveStdio(mcpServer, watcher)
+		} else {
+			err = server.ServeStdio(mcpServer)
+		}
+	} else {
+		// HTTP server
+		port := os.Getenv("PORT")
+		if port == "" {
+			port = "5001"
+		}
+		
+		portNum, err := strconv.Atoi(port)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Invalid PORT value: %s\n", port)
+			os.Exit(1)
+		}
+		
+		fmt.Fprintf(os.Stderr, "Starting MCP Directory Walker Server (HTTP) on port %d for root: %s\n", portNum, absRootDir)
+		
+		// Create HTTP server - using StreamableHTTPServer for the /mcp path.
+		// Subscriptions belong to a session, so watching needs stateful mode.
+		mux := http.NewServeMux()
+		httpServer := server.NewStreamableHTTPServer(mcpServer, 
+			server.WithEndpointPath("/mcp"),
+			server.WithStateLess(watcher == nil),
+			server.WithStreamableHTTPServer(&http.Server{Handler: mux}),
+		)
+		if watcher != nil {
+			mux.Handle("/mcp", watcher.httpHandler(httpServer))
+		} else {
+			mux.Handle("/mcp", httpServer)
+		}
+		
+		// Add basic request logging information
+		log.Printf("HTTP MCP Server ready to accept requests on http://localhost:%d/mcp", portNum)
+		
+		err = httpServer.Start(fmt.Sprintf(":%d", portNum))
+	}
+	
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting server: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -1,284 +1,1066 @@
-package main
-
-import (
-	"context"
-	"encoding/json"
-	"os"
-	"path/filepath"
-	"testing"
-
-	"github.com/mark3labs/mcp-go/mcp"
-)
-
-// createTempTestDir creates a temporary directory structure for testing
-func createTempTestDir(t *testing.T) string {
-	tempDir, err := os.MkdirTemp("", "mcp-walker-test-")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-
-	// Create test structure:
-	// tempDir/
-	//   ├── file1.txt
-	//   ├── subdir/
-	//   │   ├── file2.go
-	//   │   └── deep/
-	//   │       └── file3.json
-	//   └── emptydir/
-
-	if err := os.WriteFile(filepath.Join(tempDir, "file1.txt"), []byte("test content"), 0644); err != nil {
-		t.Fatalf("Failed to create file1.txt: %v", err)
-	}
-
-	subdirPath := filepath.Join(tempDir, "subdir")
-	if err := os.MkdirAll(subdirPath, 0755); err != nil {
-		t.Fatalf("Failed to create subdir: %v", err)
-	}
-
-	if err := os.WriteFile(filepath.Join(subdirPath, "file2.go"), []byte("package main"), 0644); err != nil {
-		t.Fatalf("Failed to create file2.go: %v", err)
-	}
-
-	deepPath := filepath.Join(subdirPath, "deep")
-	if err := os.MkdirAll(deepPath, 0755); err != nil {
-		t.Fatalf("Failed to create deep dir: %v", err)
-	}
-
-	if err := os.WriteFile(filepath.Join(deepPath, "file3.json"), []byte(`{"test": true}`), 0644); err != nil {
-		t.Fatalf("Failed to create file3.json: %v", err)
-	}
-
-	emptyDirPath := filepath.Join(tempDir, "emptydir")
-	if err := os.MkdirAll(emptyDirPath, 0755); err != nil {
-		t.Fatalf("Failed to create emptydir: %v", err)
-	}
-
-	return tempDir
-}
-
-func TestWalkDirectoryTool_RootPath(t *testing.T) {
-	tempDir := createTempTestDir(t)
-	defer os.RemoveAll(tempDir)
-
-	handler := walkDirectoryTool(tempDir)
-
-	// Create request for root path
-	request := mcp.CallToolRequest{
-		Params: mcp.CallToolParams{
-			Name:      "walk_directory",
-			Arguments: json.RawMessage(`{"path": "/"}`),
-		},
-	}
-
-	result, err := handler(context.Background(), request)
-	if err != nil {
-		t.Fatalf("Handler returned error: %v", err)
-	}
-
-	if result == nil {
-		t.Fatal("Result is nil")
-	}
-
-	// Check that we have structured content
-	if result.StructuredContent == nil {
-		t.Fatal("StructuredContent is nil")
-	}
-
-	// Extract the file list from structured content
-	files, ok := result.StructuredContent.([]string)
-	if !ok {
-		t.Fatalf("StructuredContent is not []string, got %T", result.StructuredContent)
-	}
-
-	// We should have at least 6 items: tempDir, file1.txt, subdir, file2.go, deep, file3.json, emptydir
-	if len(files) < 6 {
-		t.Fatalf("Expected at least 6 files/dirs, got %d: %v", len(files), files)
-	}
-
-	// Convert to normalized paths for comparison
-	expectedFiles := []string{
-		filepath.ToSlash(tempDir),
-		filepath.ToSlash(filepath.Join(tempDir, "file1.txt")),
-		filepath.ToSlash(filepath.Join(tempDir, "subdir")),
-		filepath.ToSlash(filepath.Join(tempDir, "subdir", "file2.go")),
-		filepath.ToSlash(filepath.Join(tempDir, "subdir", "deep")),
-		filepath.ToSlash(filepath.Join(tempDir, "subdir", "deep", "file3.json")),
-		filepath.ToSlash(filepath.Join(tempDir, "emptydir")),
-	}
-
-	// Check that all expected files are present
-	fileMap := make(map[string]bool)
-	for _, file := range files {
-		fileMap[file] = true
-	}
-
-	for _, expected := range expectedFiles {
-		if !fileMap[expected] {
-			t.Errorf("Expected file %s not found in results", expected)
-		}
-	}
-}
-
-func TestWalkDirectoryTool_SubdirPath(t *testing.T) {
-	tempDir := createTempTestDir(t)
-	defer os.RemoveAll(tempDir)
-
-	handler := walkDirectoryTool(tempDir)
-
-	// Create request for subdir path
-	request := mcp.CallToolRequest{
-		Params: mcp.CallToolParams{
-			Name:      "walk_directory",
-			Arguments: json.RawMessage(`{"path": "/subdir"}`),
-		},
-	}
-
-	result, err := handler(context.Background(), request)
-	if err != nil {
-		t.Fatalf("Handler returned error: %v", err)
-	}
-
-	files, ok := result.StructuredContent.([]string)
-	if !ok {
-		t.Fatalf("StructuredContent is not []string, got %T", result.StructuredContent)
-	}
-
-	// Should contain subdir, file2.go, deep dir, and file3.json
-	if len(files) < 4 {
-		t.Fatalf("Expected at least 4 files/dirs in subdir, got %d: %v", len(files), files)
-	}
-
-	// Check for specific files
-	fileMap := make(map[string]bool)
-	for _, file := range files {
-		fileMap[file] = true
-	}
-
-	expectedInSubdir := []string{
-		filepath.ToSlash(filepath.Join(tempDir, "subdir", "file2.go")),
-		filepath.ToSlash(filepath.Join(tempDir, "subdir", "deep")),
-		filepath.ToSlash(filepath.Join(tempDir, "subdir", "deep", "file3.json")),
-	}
-
-	for _, expected := range expectedInSubdir {
-		if !fileMap[expected] {
-			t.Errorf("Expected file %s not found in subdir results", expected)
-		}
-	}
-}
-
-func TestWalkDirectoryTool_DefaultPath(t *testing.T) {
-	tempDir := createTempTestDir(t)
-	defer os.RemoveAll(tempDir)
-
-	handler := walkDirectoryTool(tempDir)
-
-	// Create request with no arguments (should default to "/")
-	request := mcp.CallToolRequest{
-		Params: mcp.CallToolParams{
-			Name: "walk_directory",
-		},
-	}
-
-	result, err := handler(context.Background(), request)
-	if err != nil {
-		t.Fatalf("Handler returned error: %v", err)
-	}
-
-	files, ok := result.StructuredContent.([]string)
-	if !ok {
-		t.Fatalf("StructuredContent is not []string, got %T", result.StructuredContent)
-	}
-
-	// Should include all files since it defaults to root
-	if len(files) < 6 {
-		t.Fatalf("Expected at least 6 files/dirs with default path, got %d: %v", len(files), files)
-	}
-}
-
-func TestWalkDirectoryTool_NonexistentPath(t *testing.T) {
-	tempDir := createTempTestDir(t)
-	defer os.RemoveAll(tempDir)
-
-	handler := walkDirectoryTool(tempDir)
-
-	// Create request for nonexistent path
-	request := mcp.CallToolRequest{
-		Params: mcp.CallToolParams{
-			Name:      "walk_directory",
-			Arguments: json.RawMessage(`{"path": "/nonexistent"}`),
-		},
-	}
-
-	_, err := handler(context.Background(), request)
-	if err == nil {
-		t.Fatal("Expected error for nonexistent path, but got none")
-	}
-
-	if !contains(err.Error(), "does not exist") {
-		t.Errorf("Expected 'does not exist' error, got: %v", err)
-	}
-}
-
-func TestWalkDirectoryTool_SecurityCheck(t *testing.T) {
-	tempDir := createTempTestDir(t)
-	defer os.RemoveAll(tempDir)
-
-	handler := walkDirectoryTool(tempDir)
-
-	// Try to access parent directory (security check)
-	request := mcp.CallToolRequest{
-		Params: mcp.CallToolParams{
-			Name:      "walk_directory",
-			Arguments: json.RawMessage(`{"path": "/../.."}`),
-		},
-	}
-
-	_, err := handler(context.Background(), request)
-	if err == nil {
-		t.Fatal("Expected error for path outside root, but got none")
-	}
-
-	if !contains(err.Error(), "outside root directory") {
-		t.Errorf("Expected 'outside root directory' error, got: %v", err)
-	}
-}
-
-func TestWalkDirectoryTool_InvalidJSON(t *testing.T) {
-	tempDir := createTempTestDir(t)
-	defer os.RemoveAll(tempDir)
-
-	handler := walkDirectoryTool(tempDir)
-
-	// Create request with invalid JSON
-	request := mcp.CallToolRequest{
-		Params: mcp.CallToolParams{
-			Name:      "walk_directory",
-			Arguments: json.RawMessage(`{"path": }`), // Invalid JSON
-		},
-	}
-
-	_, err := handler(context.Background(), request)
-	if err == nil {
-		t.Fatal("Expected error for invalid JSON, but got none")
-	}
-
-	if !contains(err.Error(), "failed to parse arguments") {
-		t.Errorf("Expected 'failed to parse arguments' error, got: %v", err)
-	}
-}
-
-// Helper function to check if a string contains a substring
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(substr) == 0 || 
-		(len(s) > len(substr) && containsHelper(s, substr)))
-}
-
-func containsHelper(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
-	}
-	return false
-}
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// createTempTestDir creates a temporary directory structure for testing
+func createTempTestDir(t *testing.T) string {
+	tempDir, err := os.MkdirTemp("", "mcp-walker-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	// Create test structure:
+	// tempDir/
+	//   ├── file1.txt
+	//   ├── subdir/
+	//   │   ├── file2.go
+	//   │   └── deep/
+	//   │       └── file3.json
+	//   └── emptydir/
+
+	if err := os.WriteFile(filepath.Join(tempDir, "file1.txt"), []byte("test content"), 0644); err != nil {
+		t.Fatalf("Failed to create file1.txt: %v", err)
+	}
+
+	subdirPath := filepath.Join(tempDir, "subdir")
+	if err := os.MkdirAll(subdirPath, 0755); err != nil {
+		t.Fatalf("Failed to create subdir: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(subdirPath, "file2.go"), []byte("package main"), 0644); err != nil {
+		t.Fatalf("Failed to create file2.go: %v", err)
+	}
+
+	deepPath := filepath.Join(subdirPath, "deep")
+	if err := os.MkdirAll(deepPath, 0755); err != nil {
+		t.Fatalf("Failed to create deep dir: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(deepPath, "file3.json"), []byte(`{"test": true}`), 0644); err != nil {
+		t.Fatalf("Failed to create file3.json: %v", err)
+	}
+
+	emptyDirPath := filepath.Join(tempDir, "emptydir")
+	if err := os.MkdirAll(emptyDirPath, 0755); err != nil {
+		t.Fatalf("Failed to create emptydir: %v", err)
+	}
+
+	return tempDir
+}
+
+func TestWalkDirectoryTool_RootPath(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	// Create request for root path
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "walk_directory",
+			Arguments: json.RawMessage(`{"path": "/"}`),
+		},
+	}
+
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if result == nil {
+		t.Fatal("Result is nil")
+	}
+
+	// Check that we have structured content
+	if result.StructuredContent == nil {
+		t.Fatal("StructuredContent is nil")
+	}
+
+	// Extract the file list from structured content
+	page, ok := result.StructuredContent.(walkResult)
+	if !ok {
+		t.Fatalf("StructuredContent is not walkResult, got %T", result.StructuredContent)
+	}
+	files := page.Files
+
+	// We should have at least 6 items: tempDir, file1.txt, subdir, file2.go, deep, file3.json, emptydir
+	if len(files) < 6 {
+		t.Fatalf("Expected at least 6 files/dirs, got %d: %v", len(files), files)
+	}
+
+	// Convert to normalized paths for comparison
+	expectedFiles := []string{
+		filepath.ToSlash(tempDir),
+		filepath.ToSlash(filepath.Join(tempDir, "file1.txt")),
+		filepath.ToSlash(filepath.Join(tempDir, "subdir")),
+		filepath.ToSlash(filepath.Join(tempDir, "subdir", "file2.go")),
+		filepath.ToSlash(filepath.Join(tempDir, "subdir", "deep")),
+		filepath.ToSlash(filepath.Join(tempDir, "subdir", "deep", "file3.json")),
+		filepath.ToSlash(filepath.Join(tempDir, "emptydir")),
+	}
+
+	// Check that all expected files are present
+	fileMap := make(map[string]bool)
+	for _, file := range files {
+		fileMap[file] = true
+	}
+
+	for _, expected := range expectedFiles {
+		if !fileMap[expected] {
+			t.Errorf("Expected file %s not found in results", expected)
+		}
+	}
+}
+
+func TestWalkDirectoryTool_SubdirPath(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	// Create request for subdir path
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "walk_directory",
+			Arguments: json.RawMessage(`{"path": "/subdir"}`),
+		},
+	}
+
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	page, ok := result.StructuredContent.(walkResult)
+	if !ok {
+		t.Fatalf("StructuredContent is not walkResult, got %T", result.StructuredContent)
+	}
+	files := page.Files
+
+	// Should contain subdir, file2.go, deep dir, and file3.json
+	if len(files) < 4 {
+		t.Fatalf("Expected at least 4 files/dirs in subdir, got %d: %v", len(files), files)
+	}
+
+	// Check for specific files
+	fileMap := make(map[string]bool)
+	for _, file := range files {
+		fileMap[file] = true
+	}
+
+	expectedInSubdir := []string{
+		filepath.ToSlash(filepath.Join(tempDir, "subdir", "file2.go")),
+		filepath.ToSlash(filepath.Join(tempDir, "subdir", "deep")),
+		filepath.ToSlash(filepath.Join(tempDir, "subdir", "deep", "file3.json")),
+	}
+
+	for _, expected := range expectedInSubdir {
+		if !fileMap[expected] {
+			t.Errorf("Expected file %s not found in subdir results", expected)
+		}
+	}
+}
+
+func TestWalkDirectoryTool_DefaultPath(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	// Create request with no arguments (should default to "/")
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "walk_directory",
+		},
+	}
+
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	page, ok := result.StructuredContent.(walkResult)
+	if !ok {
+		t.Fatalf("StructuredContent is not walkResult, got %T", result.StructuredContent)
+	}
+	files := page.Files
+
+	// Should include all files since it defaults to root
+	if len(files) < 6 {
+		t.Fatalf("Expected at least 6 files/dirs with default path, got %d: %v", len(files), files)
+	}
+}
+
+func TestWalkDirectoryTool_NonexistentPath(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	// Create request for nonexistent path
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "walk_directory",
+			Arguments: json.RawMessage(`{"path": "/nonexistent"}`),
+		},
+	}
+
+	_, err := handler(context.Background(), request)
+	if err == nil {
+		t.Fatal("Expected error for nonexistent path, but got none")
+	}
+
+	if !contains(err.Error(), "does not exist") {
+		t.Errorf("Expected 'does not exist' error, got: %v", err)
+	}
+}
+
+func TestWalkDirectoryTool_SecurityCheck(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	// Try to access parent directory (security check)
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "walk_directory",
+			Arguments: json.RawMessage(`{"path": "/../.."}`),
+		},
+	}
+
+	_, err := handler(context.Background(), request)
+	if err == nil {
+		t.Fatal("Expected error for path outside root, but got none")
+	}
+
+	if !contains(err.Error(), "outside root directory") {
+		t.Errorf("Expected 'outside root directory' error, got: %v", err)
+	}
+}
+
+func TestWalkDirectoryTool_InvalidJSON(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	// Create request with invalid JSON
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "walk_directory",
+			Arguments: json.RawMessage(`{"path": }`), // Invalid JSON
+		},
+	}
+
+	_, err := handler(context.Background(), request)
+	if err == nil {
+		t.Fatal("Expected error for invalid JSON, but got none")
+	}
+
+	if !contains(err.Error(), "failed to parse arguments") {
+		t.Errorf("Expected 'failed to parse arguments' error, got: %v", err)
+	}
+}
+
+func TestWalkDirectoryTool_MaxDepth(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	// Only the root and its immediate children should be returned
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "walk_directory",
+			Arguments: json.RawMessage(`{"path": "/", "max_depth": 1}`),
+		},
+	}
+
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	page, ok := result.StructuredContent.(walkResult)
+	if !ok {
+		t.Fatalf("StructuredContent is not walkResult, got %T", result.StructuredContent)
+	}
+	files := page.Files
+
+	if len(files) != 4 {
+		t.Fatalf("Expected 4 entries at depth <= 1, got %d: %v", len(files), files)
+	}
+
+	for _, file := range files {
+		if contains(file, "file2.go") || contains(file, "deep") {
+			t.Errorf("Entry %s is deeper than max_depth", file)
+		}
+	}
+}
+
+func TestWalkDirectoryTool_TypeFilter(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	tests := []struct {
+		entryType string
+		expected  []string
+	}{
+		{"files", []string{"file1.txt", "subdir/file2.go", "subdir/deep/file3.json"}},
+		{"dirs", []string{"", "subdir", "subdir/deep", "emptydir"}},
+	}
+
+	for _, tt := range tests {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "walk_directory",
+				Arguments: json.RawMessage(`{"type": "` + tt.entryType + `"}`),
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error for type %s: %v", tt.entryType, err)
+		}
+
+		page, ok := result.StructuredContent.(walkResult)
+		if !ok {
+			t.Fatalf("StructuredContent is not walkResult, got %T", result.StructuredContent)
+		}
+		files := page.Files
+
+		if len(files) != len(tt.expected) {
+			t.Fatalf("Expected %d entries for type %s, got %d: %v", len(tt.expected), tt.entryType, len(files), files)
+		}
+
+		fileMap := make(map[string]bool)
+		for _, file := range files {
+			fileMap[file] = true
+		}
+
+		for _, rel := range tt.expected {
+			expected := filepath.ToSlash(filepath.Join(tempDir, rel))
+			if !fileMap[expected] {
+				t.Errorf("Expected %s in results for type %s", expected, tt.entryType)
+			}
+		}
+	}
+}
+
+func TestWalkDirectoryTool_InvalidType(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "walk_directory",
+			Arguments: json.RawMessage(`{"type": "sockets"}`),
+		},
+	}
+
+	_, err := handler(context.Background(), request)
+	if err == nil {
+		t.Fatal("Expected error for invalid type, but got none")
+	}
+
+	if !contains(err.Error(), "invalid type") {
+		t.Errorf("Expected 'invalid type' error, got: %v", err)
+	}
+}
+
+func TestWalkDirectoryTool_ExcludeHidden(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	// Add a hidden directory with a file inside and a hidden file
+	hiddenDir := filepath.Join(tempDir, ".git")
+	if err := os.MkdirAll(hiddenDir, 0755); err != nil {
+		t.Fatalf("Failed to create hidden dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(hiddenDir, "HEAD"), []byte("ref"), 0644); err != nil {
+		t.Fatalf("Failed to create hidden dir file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, ".env"), []byte("X=1"), 0644); err != nil {
+		t.Fatalf("Failed to create hidden file: %v", err)
+	}
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "walk_directory",
+			Arguments: json.RawMessage(`{"include_hidden": false}`),
+		},
+	}
+
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	page, ok := result.StructuredContent.(walkResult)
+	if !ok {
+		t.Fatalf("StructuredContent is not walkResult, got %T", result.StructuredContent)
+	}
+	files := page.Files
+
+	if len(files) != 7 {
+		t.Fatalf("Expected 7 non-hidden entries, got %d: %v", len(files), files)
+	}
+
+	for _, file := range files {
+		if contains(file, "/.") {
+			t.Errorf("Hidden entry %s should have been excluded", file)
+		}
+	}
+}
+
+func TestWalkDirectoryTool_IncludeExclude(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "walk_directory",
+			Arguments: json.RawMessage(`{"include": ["*.go", "*.json"], "exclude": ["**/deep"]}`),
+		},
+	}
+
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	page, ok := result.StructuredContent.(walkResult)
+	if !ok {
+		t.Fatalf("StructuredContent is not walkResult, got %T", result.StructuredContent)
+	}
+	files := page.Files
+
+	// The root itself plus subdir/file2.go; file3.json lives in the excluded deep/
+	expected := []string{
+		filepath.ToSlash(tempDir),
+		filepath.ToSlash(filepath.Join(tempDir, "subdir", "file2.go")),
+	}
+	if len(files) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, files)
+	}
+	for i := range expected {
+		if files[i] != expected[i] {
+			t.Errorf("Expected %s at index %d, got %s", expected[i], i, files[i])
+		}
+	}
+}
+
+func TestWalkDirectoryTool_RegexPatterns(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "walk_directory",
+			Arguments: json.RawMessage(`{"type": "files", "include": ["\\.(txt|json)$"], "pattern_syntax": "regex"}`),
+		},
+	}
+
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	page, ok := result.StructuredContent.(walkResult)
+	if !ok {
+		t.Fatalf("StructuredContent is not walkResult, got %T", result.StructuredContent)
+	}
+	files := page.Files
+
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 files, got %d: %v", len(files), files)
+	}
+}
+
+func TestWalkDirectoryTool_InvalidPattern(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "walk_directory",
+			Arguments: json.RawMessage(`{"exclude": ["[unclosed"]}`),
+		},
+	}
+
+	_, err := handler(context.Background(), request)
+	if err == nil {
+		t.Fatal("Expected error for invalid pattern, but got none")
+	}
+
+	if !contains(err.Error(), "invalid exclude") {
+		t.Errorf("Expected 'invalid exclude' error, got: %v", err)
+	}
+
+	// An unknown syntax is rejected even without patterns
+	request.Params.Arguments = json.RawMessage(`{"pattern_syntax": "bogus"}`)
+	if _, err := handler(context.Background(), request); err == nil || !contains(err.Error(), "invalid pattern syntax") {
+		t.Errorf("Expected 'invalid pattern syntax' error, got: %v", err)
+	}
+}
+
+func TestWalkDirectoryTool_RespectIgnoreFiles(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	writeTestFile(t, filepath.Join(tempDir, ".gitignore"), "subdir/\n")
+
+	cfg := newServerConfig(tempDir)
+	handler := walkDirectoryTool(cfg)
+
+	countEntries := func(arguments string) int {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "walk_directory",
+				Arguments: json.RawMessage(arguments),
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+
+		page, ok := result.StructuredContent.(walkResult)
+		if !ok {
+			t.Fatalf("StructuredContent is not walkResult, got %T", result.StructuredContent)
+		}
+		files := page.Files
+		return len(files)
+	}
+
+	// Root, .gitignore, file1.txt, emptydir
+	if n := countEntries(`{}`); n != 4 {
+		t.Errorf("Expected 4 entries with ignore files honored, got %d", n)
+	}
+
+	// Everything, including subdir/ and its contents
+	if n := countEntries(`{"respect_ignore_files": false}`); n != 8 {
+		t.Errorf("Expected 8 entries with ignore files disabled, got %d", n)
+	}
+
+	// Server-level rules apply on top of the tree's ignore files
+	cfg.IgnoreRules = cfg.IgnoreRules.withRules([]ignoreRule{{pattern: "*.txt"}})
+	if n := countEntries(`{}`); n != 3 {
+		t.Errorf("Expected 3 entries with server ignore rules, got %d", n)
+	}
+}
+
+func TestWalkDirectoryTool_Pagination(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	callPage := func(arguments string) walkResult {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "walk_directory",
+				Arguments: json.RawMessage(arguments),
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+
+		page, ok := result.StructuredContent.(walkResult)
+		if !ok {
+			t.Fatalf("StructuredContent is not walkResult, got %T", result.StructuredContent)
+		}
+		return page
+	}
+
+	full := callPage(`{}`)
+
+	// Page through two entries at a time and compare with the full walk
+	var paged []string
+	arguments := `{"limit": 2}`
+	for pages := 0; ; pages++ {
+		if pages > len(full.Files) {
+			t.Fatal("Pagination did not terminate")
+		}
+
+		page := callPage(arguments)
+		if len(page.Files) > 2 {
+			t.Fatalf("Page exceeds limit: %v", page.Files)
+		}
+		paged = append(paged, page.Files...)
+
+		if page.NextCursor == "" {
+			break
+		}
+		arguments = `{"limit": 2, "cursor": "` + page.NextCursor + `"}`
+	}
+
+	if len(paged) != len(full.Files) {
+		t.Fatalf("Paged walk returned %d entries, full walk %d: %v vs %v", len(paged), len(full.Files), paged, full.Files)
+	}
+	for i := range paged {
+		if paged[i] != full.Files[i] {
+			t.Errorf("Entry %d: paged %s, full %s", i, paged[i], full.Files[i])
+		}
+	}
+}
+
+func TestWalkDirectoryTool_CursorPathMismatch(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	cursor := encodeCursor(walkCursor{Path: "/", After: "file1.txt"})
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "walk_directory",
+			Arguments: json.RawMessage(`{"path": "/subdir", "cursor": "` + cursor + `"}`),
+		},
+	}
+
+	_, err := handler(context.Background(), request)
+	if err == nil {
+		t.Fatal("Expected error for cursor issued for another path, but got none")
+	}
+
+	if !contains(err.Error(), "invalid cursor") {
+		t.Errorf("Expected 'invalid cursor' error, got: %v", err)
+	}
+}
+
+func TestWalkDirectoryTool_Fields(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "walk_directory",
+			Arguments: json.RawMessage(`{"path": "/subdir", "fields": ["type", "size"]}`),
+		},
+	}
+
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	page, ok := result.StructuredContent.(walkResult)
+	if !ok {
+		t.Fatalf("StructuredContent is not walkResult, got %T", result.StructuredContent)
+	}
+
+	if page.Files != nil {
+		t.Errorf("Expected no plain files when fields are requested, got %v", page.Files)
+	}
+	if len(page.Entries) != 4 {
+		t.Fatalf("Expected 4 entries, got %d: %+v", len(page.Entries), page.Entries)
+	}
+
+	for _, item := range page.Entries {
+		if item.Type == "" || item.Size == nil {
+			t.Errorf("Entry %s is missing requested fields: %+v", item.Path, item)
+		}
+	}
+
+	// The structured content must serialize to the declared output schema shape
+	data, err := json.Marshal(result.StructuredContent)
+	if err != nil {
+		t.Fatalf("Failed to marshal structured content: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Structured content is not a JSON object: %v", err)
+	}
+	if _, ok := decoded["entries"].([]interface{}); !ok {
+		t.Errorf("Expected entries array in %s", data)
+	}
+}
+
+func TestWalkDirectoryTool_InvalidField(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "walk_directory",
+			Arguments: json.RawMessage(`{"fields": ["color"]}`),
+		},
+	}
+
+	_, err := handler(context.Background(), request)
+	if err == nil {
+		t.Fatal("Expected error for invalid field, but got none")
+	}
+
+	if !contains(err.Error(), "invalid field") {
+		t.Errorf("Expected 'invalid field' error, got: %v", err)
+	}
+}
+
+func TestWalkOutputSchema_ValidJSON(t *testing.T) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(walkOutputSchema), &schema); err != nil {
+		t.Fatalf("walkOutputSchema is not valid JSON: %v", err)
+	}
+	if schema["type"] != "object" {
+		t.Errorf("Output schema type must be object, got %v", schema["type"])
+	}
+}
+
+func TestWalkDirectoryTool_TreeFormats(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	for _, format := range []string{"tree", "nested_json"} {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "walk_directory",
+				Arguments: json.RawMessage(`{"path": "/subdir", "format": "` + format + `"}`),
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error for format %s: %v", format, err)
+		}
+
+		page, ok := result.StructuredContent.(walkResult)
+		if !ok {
+			t.Fatalf("StructuredContent is not walkResult, got %T", result.StructuredContent)
+		}
+		if page.Tree == nil || page.Tree.Name != "subdir" || len(page.Tree.Children) != 2 {
+			t.Fatalf("Unexpected tree for format %s: %+v", format, page.Tree)
+		}
+
+		text := result.Content[0].(mcp.TextContent).Text
+		if format == "tree" && !contains(text, "└── file2.go") {
+			t.Errorf("Expected box-drawing tree text, got:\n%s", text)
+		}
+		if format == "nested_json" && !contains(text, `"children"`) {
+			t.Errorf("Expected nested JSON text, got:\n%s", text)
+		}
+	}
+}
+
+func TestWalkDirectoryTool_InvalidFormat(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	tests := []struct {
+		arguments string
+		errText   string
+	}{
+		{`{"format": "xml"}`, "invalid format"},
+		{`{"format": "tree", "limit": 5}`, "only supported with the list format"},
+	}
+
+	for _, tt := range tests {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "walk_directory",
+				Arguments: json.RawMessage(tt.arguments),
+			},
+		}
+
+		_, err := handler(context.Background(), request)
+		if err == nil {
+			t.Fatalf("Expected error for %s, but got none", tt.arguments)
+		}
+		if !contains(err.Error(), tt.errText) {
+			t.Errorf("Expected '%s' error, got: %v", tt.errText, err)
+		}
+	}
+}
+
+func TestWalkDirectoryTool_VirtualPathsRoundTrip(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := newServerConfig(tempDir)
+	cfg.PathStyle = pathStyleVirtual
+	handler := walkDirectoryTool(cfg)
+
+	walk := func(arguments string) []string {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "walk_directory",
+				Arguments: json.RawMessage(arguments),
+			},
+		}
+
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Handler returned error for %s: %v", arguments, err)
+		}
+
+		page, ok := result.StructuredContent.(walkResult)
+		if !ok {
+			t.Fatalf("StructuredContent is not walkResult, got %T", result.StructuredContent)
+		}
+		return page.Files
+	}
+
+	// Every directory returned by a walk can be fed back in as the path argument
+	for _, dir := range walk(`{"type": "dirs"}`) {
+		if contains(dir, tempDir) {
+			t.Errorf("Virtual path %s leaks the host root", dir)
+		}
+
+		files := walk(`{"path": "` + dir + `", "max_depth": 0}`)
+		if len(files) != 1 || files[0] != dir {
+			t.Errorf("Walking %s returned %v", dir, files)
+		}
+	}
+
+	// Per-call override of the server default
+	files := walk(`{"path": "/subdir", "path_style": "relative", "type": "files"}`)
+	if len(files) != 2 || files[0] != "deep/file3.json" || files[1] != "file2.go" {
+		t.Errorf("Unexpected relative paths: %v", files)
+	}
+}
+
+func TestWalkDirectoryTool_SiblingPrefixEscape(t *testing.T) {
+	parent, err := os.MkdirTemp("", "mcp-walker-parent-")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(parent)
+
+	// root is parent/proj; parent/proj-secrets shares its string prefix
+	root := filepath.Join(parent, "proj")
+	writeTestFile(t, filepath.Join(root, "readme.txt"), "public")
+	writeTestFile(t, filepath.Join(parent, "proj-secrets", "key"), "secret")
+
+	handler := walkDirectoryTool(newServerConfig(root))
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "walk_directory",
+			Arguments: json.RawMessage(`{"path": "../proj-secrets"}`),
+		},
+	}
+
+	_, err = handler(context.Background(), request)
+	if err == nil {
+		t.Fatal("Expected error for sibling directory sharing the root prefix, but got none")
+	}
+
+	if !contains(err.Error(), "outside root directory") {
+		t.Errorf("Expected 'outside root directory' error, got: %v", err)
+	}
+}
+
+func TestWalkDirectoryTool_SymlinkEscape(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	outside, err := os.MkdirTemp("", "mcp-walker-outside-")
+	if err != nil {
+		t.Fatalf("Failed to create outside dir: %v", err)
+	}
+	defer os.RemoveAll(outside)
+
+	if err := os.Symlink(outside, filepath.Join(tempDir, "escape")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "walk_directory",
+			Arguments: json.RawMessage(`{"path": "/escape"}`),
+		},
+	}
+
+	_, err = handler(context.Background(), request)
+	if err == nil {
+		t.Fatal("Expected error for symlink pointing outside the root, but got none")
+	}
+
+	if !contains(err.Error(), "outside root directory") {
+		t.Errorf("Expected 'outside root directory' error, got: %v", err)
+	}
+}
+
+func TestWalkDirectoryTool_Cancelled(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := walkDirectoryTool(newServerConfig(tempDir))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "walk_directory",
+			Arguments: json.RawMessage(`{}`),
+		},
+	}
+
+	_, err := handler(ctx, request)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+}
+
+func TestWalkDirectoryTool_WalkTimeout(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	// A limit that has always passed by the time the walk starts
+	cfg := newServerConfig(tempDir)
+	cfg.WalkTimeout = time.Nanosecond
+
+	for _, format := range []string{formatList, formatTree} {
+		result, err := callTool(walkDirectoryTool(cfg), "walk_directory", `{"format": "`+format+`"}`)
+		if err != nil {
+			t.Fatalf("Expected partial results for %s, got error: %v", format, err)
+		}
+		page := result.StructuredContent.(walkResult)
+		if !page.Truncated || page.NextCursor != "" {
+			t.Errorf("Expected a truncated %s result without a cursor, got %+v", format, page)
+		}
+		if text := result.Content[0].(mcp.TextContent).Text; !contains(text, "time limit") {
+			t.Errorf("Expected the summary to mention the time limit, got %q", text)
+		}
+	}
+}
+
+func TestWalkDirectoryTool_WalkTimeoutResume(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	for i := 0; i < 3000; i++ {
+		writeTestFile(t, filepath.Join(tempDir, "many", fmt.Sprintf("f%04d.txt", i)), "")
+	}
+
+	full, err := callTool(walkDirectoryTool(newServerConfig(tempDir)), "walk_directory", `{}`)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	want := full.StructuredContent.(walkResult).Files
+
+	// Truncated list results resume from their cursor without gaps or repeats
+	cfg := newServerConfig(tempDir)
+	cfg.WalkTimeout = time.Millisecond
+	var got []string
+	arguments := `{}`
+	for calls := 0; ; calls++ {
+		if calls > len(want) {
+			t.Fatal("Resuming did not terminate")
+		}
+		result, err := callTool(walkDirectoryTool(cfg), "walk_directory", arguments)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		page := result.StructuredContent.(walkResult)
+		got = append(got, page.Files...)
+		if page.Truncated && page.NextCursor == "" {
+			t.Skip("Walk made no progress within the time limit on this machine")
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		arguments = `{"cursor": "` + page.NextCursor + `"}`
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("Resumed walk returned %d entries, full walk %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("Entry %d: resumed %s, full %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWalkDirectoryTool_Progress(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	mcpServer.AddTools(walkDirectoryServerTool(newServerConfig(tempDir)))
+	session := &testSession{id: "walk-session", notifications: make(chan mcp.JSONRPCNotification, 10)}
+
+	if _, ok := callToolWithProgress(mcpServer, session, "walk_directory", map[string]any{}).(mcp.JSONRPCResponse); !ok {
+		t.Fatal("Expected walk_directory to succeed")
+	}
+
+	// Notifications are throttled, but the first is always sent
+	if len(session.notifications) == 0 {
+		t.Fatal("Expected a progress notification")
+	}
+	n := <-session.notifications
+	if n.Method != methodNotificationProgress || n.Params.AdditionalFields["progressToken"] != "tok-1" {
+		t.Errorf("Unexpected notification %s %v", n.Method, n.Params.AdditionalFields)
+	}
+	if message, _ := n.Params.AdditionalFields["message"].(string); !contains(message, "Scanned 1 entries") {
+		t.Errorf("Expected an entries scanned count, got %v", n.Params.AdditionalFields)
+	}
+}
+
+// callTool invokes a tool handler with raw JSON arguments
+func callTool(handler server.ToolHandlerFunc, name, arguments string) (*mcp.CallToolResult, error) {
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      name,
+			Arguments: json.RawMessage(arguments),
+		},
+	}
+	return handler(context.Background(), request)
+}
+
+// Helper function to check if a string contains a substring
+func contains(s, substr string) bool {
+	return len(s) >= len(substr) && (s == substr || len(substr) == 0 || 
+		(len(s) > len(substr) && containsHelper(s, substr)))
+}
+
+func containsHelper(s, substr string) bool {
+	for i := 0; i <= len(s)-len(substr); i++ {
+		if s[i:i+len(substr)] == substr {
+			return true
+		}
+	}
+	return false
+}
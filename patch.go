@@ -0,0 +1,702 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// maxPatchFuzz is the largest fuzz factor apply_patch accepts
+const maxPatchFuzz = 3
+
+// Operations a file patch can perform
+const (
+	patchOpCreate = "create"
+	patchOpModify = "modify"
+	patchOpDelete = "delete"
+	patchOpRename = "rename"
+)
+
+// filePatch is the part of a unified diff that concerns one file
+type filePatch struct {
+	OldPath string // "" for new files
+	NewPath string // "" for deleted files
+	Hunks   []patchHunk
+}
+
+// operation reports what applying p does to the tree
+func (p filePatch) operation() string {
+	switch {
+	case p.OldPath == "":
+		return patchOpCreate
+	case p.NewPath == "":
+		return patchOpDelete
+	case p.OldPath != p.NewPath:
+		return patchOpRename
+	}
+	return patchOpModify
+}
+
+// patchHunk is one @@ section of a file patch
+type patchHunk struct {
+	OldStart, OldCount int
+	NewStart, NewCount int
+	Ops                []diffOp
+}
+
+// patchHunkResult reports how one hunk applied
+type patchHunkResult struct {
+	Hunk    int    `json:"hunk"`   // 1-based index within the file
+	Status  string `json:"status"` // "applied" or "rejected"
+	Offset  int    `json:"offset,omitempty"`
+	Fuzz    int    `json:"fuzz,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// patchFileResult reports how one file patch applied
+type patchFileResult struct {
+	Path      string            `json:"path"`
+	OldPath   string            `json:"old_path,omitempty"` // for renames
+	Operation string            `json:"operation"`
+	Hunks     []patchHunkResult `json:"hunks"`
+	Error     string            `json:"error,omitempty"` // file-level problem, e.g. a missing file
+}
+
+// patchResult is the structured content returned by apply_patch
+type patchResult struct {
+	Applied bool              `json:"applied"` // false means nothing was changed
+	Files   []patchFileResult `json:"files"`
+}
+
+// parsePatch parses a unified diff covering one or more files. Both plain
+// "---"/"+++" diffs and git extended headers (new, deleted and renamed files)
+// are understood; a leading "a/" or "b/" on paths is removed.
+func parsePatch(text string) ([]filePatch, error) {
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	lines := splitLines(text)
+
+	var patches []filePatch
+	var cur *filePatch
+	gitHeader := false // cur was started by "diff --git" and has no ---/+++ yet
+
+	start := func() {
+		patches = append(patches, filePatch{})
+		cur = &patches[len(patches)-1]
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r\n")
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			start()
+			gitHeader = true
+			if a, b, ok := strings.Cut(strings.TrimPrefix(line, "diff --git "), " b/"); ok {
+				cur.OldPath = stripPatchPrefix(a)
+				cur.NewPath = b
+			}
+
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			oldPath := patchHeaderPath(line[4:])
+			newPath := patchHeaderPath(strings.TrimRight(lines[i+1], "\r\n")[4:])
+			if cur == nil || !gitHeader {
+				start()
+				cur.OldPath, cur.NewPath = oldPath, newPath
+			} else {
+				// Keep a git header's marking of the file as new or deleted
+				if cur.OldPath != "" {
+					cur.OldPath = oldPath
+				}
+				if cur.NewPath != "" {
+					cur.NewPath = newPath
+				}
+			}
+			gitHeader = false
+			i++
+
+		case cur != nil && gitHeader && strings.HasPrefix(line, "new file mode"):
+			cur.OldPath = ""
+		case cur != nil && gitHeader && strings.HasPrefix(line, "deleted file mode"):
+			cur.NewPath = ""
+		case cur != nil && gitHeader && strings.HasPrefix(line, "rename from "):
+			cur.OldPath = strings.TrimPrefix(line, "rename from ")
+		case cur != nil && gitHeader && strings.HasPrefix(line, "rename to "):
+			cur.NewPath = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "Binary files ") || strings.HasPrefix(line, "GIT binary patch"):
+			return nil, fmt.Errorf("line %d: binary patches are not supported", i+1)
+
+		case strings.HasPrefix(line, "@@ "):
+			if cur == nil {
+				return nil, fmt.Errorf("line %d: hunk without a file header", i+1)
+			}
+			hunk, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			cur.Hunks = append(cur.Hunks, hunk)
+			gitHeader = false
+			i = next - 1
+		}
+
+		// Anything else (index lines, commit messages, similarity scores) is ignored
+	}
+
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("no file changes found in patch")
+	}
+	for _, p := range patches {
+		if p.OldPath == "" && p.NewPath == "" {
+			return nil, fmt.Errorf("patch has a file with neither an old nor a new path")
+		}
+	}
+	return patches, nil
+}
+
+// parseHunk parses the hunk whose header is lines[i] and returns the index of
+// the first line after it
+func parseHunk(lines []string, i int) (patchHunk, int, error) {
+	var h patchHunk
+	header := strings.TrimRight(lines[i], "\r\n")
+	fields := strings.Fields(header)
+	if len(fields) < 4 || fields[3] != "@@" || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return h, 0, fmt.Errorf("line %d: malformed hunk header %q", i+1, header)
+	}
+
+	var err1, err2 error
+	h.OldStart, h.OldCount, err1 = parseHunkRange(fields[1][1:])
+	h.NewStart, h.NewCount, err2 = parseHunkRange(fields[2][1:])
+	if err1 != nil || err2 != nil {
+		return h, 0, fmt.Errorf("line %d: malformed hunk header %q", i+1, header)
+	}
+
+	oldLeft, newLeft := h.OldCount, h.NewCount
+	i++
+	for ; i < len(lines) && (oldLeft > 0 || newLeft > 0); i++ {
+		line := lines[i]
+		kind := line[0]
+		if line == "\n" || line == "\r\n" {
+			// Some editors strip the space from empty context lines
+			kind, line = ' ', " "+line
+		}
+
+		switch kind {
+		case ' ':
+			oldLeft--
+			newLeft--
+		case '-':
+			oldLeft--
+		case '+':
+			newLeft--
+		case '\\':
+			h.markNoNewline()
+			continue
+		default:
+			return h, 0, fmt.Errorf("line %d: unexpected line in hunk: %q", i+1, strings.TrimSuffix(line, "\n"))
+		}
+		if oldLeft < 0 || newLeft < 0 {
+			return h, 0, fmt.Errorf("line %d: hunk is longer than its header says", i+1)
+		}
+		h.Ops = append(h.Ops, diffOp{kind: kind, line: line[1:]})
+	}
+	if oldLeft > 0 || newLeft > 0 {
+		return h, 0, fmt.Errorf("line %d: hunk is shorter than its header says", i+1)
+	}
+
+	// The last line of the hunk may be followed by a no-newline marker
+	if i < len(lines) && strings.HasPrefix(lines[i], "\\") {
+		h.markNoNewline()
+		i++
+	}
+	return h, i, nil
+}
+
+// markNoNewline applies a "\ No newline at end of file" marker to the last line
+func (h *patchHunk) markNoNewline() {
+	if len(h.Ops) > 0 {
+		last := &h.Ops[len(h.Ops)-1]
+		last.line = strings.TrimSuffix(last.line, "\n")
+	}
+}
+
+// parseHunkRange parses "start[,count]"
+func parseHunkRange(s string) (int, int, error) {
+	startText, countText, hasCount := strings.Cut(s, ",")
+	start, err := strconv.Atoi(startText)
+	if err != nil || start < 0 {
+		return 0, 0, fmt.Errorf("invalid range %q", s)
+	}
+	count := 1
+	if hasCount {
+		count, err = strconv.Atoi(countText)
+		if err != nil || count < 0 {
+			return 0, 0, fmt.Errorf("invalid range %q", s)
+		}
+	}
+	return start, count, nil
+}
+
+// patchHeaderPath extracts the path from a ---/+++ header, returning "" for /dev/null
+func patchHeaderPath(s string) string {
+	// Drop a trailing timestamp, as written by diff -u
+	if name, _, ok := strings.Cut(s, "\t"); ok {
+		s = name
+	}
+	s = strings.TrimSpace(s)
+	if s == "/dev/null" {
+		return ""
+	}
+	return stripPatchPrefix(s)
+}
+
+// stripPatchPrefix removes the a/ or b/ prefix git puts on diff paths
+func stripPatchPrefix(s string) string {
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		return s[2:]
+	}
+	return s
+}
+
+// applyHunks applies hunks to lines. Each hunk is looked for near its stated
+// position, adjusted by the offset of the previous hunk; with fuzz > 0 up to
+// that many context lines at each end of a hunk may be ignored. Rejected hunks
+// are reported and skipped so that every hunk gets a result.
+func applyHunks(lines []string, hunks []patchHunk, fuzz int) ([]string, []patchHunkResult, bool) {
+	var out []string
+	results := make([]patchHunkResult, len(hunks))
+	pos, offset := 0, 0
+	ok := true
+
+	for n, h := range hunks {
+		results[n] = patchHunkResult{Hunk: n + 1, Status: "rejected"}
+
+		expected := h.OldStart - 1 + offset
+		if h.OldCount == 0 {
+			expected = h.OldStart + offset
+		}
+
+		matched := false
+		for f := 0; f <= fuzz && !matched; f++ {
+			lead, trail := contextTrim(h.Ops, f)
+			body := h.Ops[lead : len(h.Ops)-trail]
+			if f > 0 && lead+trail == 0 {
+				break // nothing more to ignore
+			}
+
+			var oldLines, newLines []string
+			for _, op := range body {
+				if op.kind != '+' {
+					oldLines = append(oldLines, op.line)
+				}
+				if op.kind != '-' {
+					newLines = append(newLines, op.line)
+				}
+			}
+
+			at := findLines(lines, oldLines, pos, expected+lead)
+			if at < 0 {
+				continue
+			}
+
+			out = append(out, lines[pos:at]...)
+			out = append(out, newLines...)
+			pos = at + len(oldLines)
+			offset = at - lead - (expected - offset)
+			results[n] = patchHunkResult{Hunk: n + 1, Status: "applied", Offset: offset, Fuzz: f}
+			matched = true
+		}
+
+		if !matched {
+			results[n].Message = fmt.Sprintf("context does not match the file near line %d", h.OldStart)
+			ok = false
+		}
+	}
+
+	out = append(out, lines[pos:]...)
+	return out, results, ok
+}
+
+// contextTrim returns how many leading and trailing context lines of ops to
+// drop for fuzz factor f
+func contextTrim(ops []diffOp, f int) (int, int) {
+	lead := 0
+	for lead < f && lead < len(ops) && ops[lead].kind == ' ' {
+		lead++
+	}
+	trail := 0
+	for trail < f && trail < len(ops)-lead && ops[len(ops)-1-trail].kind == ' ' {
+		trail++
+	}
+	return lead, trail
+}
+
+// findLines returns the index at or after from where want occurs in lines,
+// choosing the occurrence closest to near, or -1 if there is none
+func findLines(lines, want []string, from, near int) int {
+	last := len(lines) - len(want)
+	if last < from {
+		return -1
+	}
+	near = min(max(from, near), last)
+
+	matchAt := func(at int) bool {
+		for k, line := range want {
+			if lines[at+k] != line {
+				return false
+			}
+		}
+		return true
+	}
+
+	for d := 0; near-d >= from || near+d <= last; d++ {
+		if at := near - d; at >= from && matchAt(at) {
+			return at
+		}
+		if at := near + d; d > 0 && at <= last && matchAt(at) {
+			return at
+		}
+	}
+	return -1
+}
+
+// plannedChange is one validated file change waiting to be written
+type plannedChange struct {
+	patch   filePatch
+	oldPath string // absolute, "" for creations
+	newPath string // absolute, "" for deletions
+	content string // new content
+	perm    os.FileMode
+}
+
+// applyPatchTool implements the apply_patch tool handler
+func applyPatchTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		if !cfg.AllowWrite {
+			return nil, errWriteDisabled
+		}
+
+		// Parse the arguments
+		var args struct {
+			Patch string `json:"patch"`
+			Fuzz  int    `json:"fuzz"`
+		}
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+
+		if args.Patch == "" {
+			return nil, fmt.Errorf("patch is required")
+		}
+		if args.Fuzz < 0 || args.Fuzz > maxPatchFuzz {
+			return nil, fmt.Errorf("fuzz must be between 0 and %d", maxPatchFuzz)
+		}
+
+		patches, err := parsePatch(args.Patch)
+		if err != nil {
+			return nil, fmt.Errorf("invalid patch: %w", err)
+		}
+
+		formatter, _ := newPathFormatter(cfg.PathStyle, cfg.RootDir, ".")
+		result := patchResult{Applied: true}
+		var changes []plannedChange
+		touched := make(map[string]bool)
+
+		// Validate every file and hunk before changing anything
+		for _, p := range patches {
+			change, fileResult, err := planFilePatch(cfg, p, args.Fuzz, touched)
+			if err != nil {
+				return nil, err
+			}
+			if change.newPath != "" {
+				fileResult.Path = formatter.format(relPath(cfg.RootDir, change.newPath))
+			} else {
+				fileResult.Path = formatter.format(relPath(cfg.RootDir, change.oldPath))
+			}
+			if p.operation() == patchOpRename {
+				fileResult.OldPath = formatter.format(relPath(cfg.RootDir, change.oldPath))
+			}
+
+			if fileResult.Error != "" {
+				result.Applied = false
+			}
+			for _, h := range fileResult.Hunks {
+				if h.Status != "applied" {
+					result.Applied = false
+				}
+			}
+			result.Files = append(result.Files, fileResult)
+			changes = append(changes, change)
+		}
+
+		if result.Applied {
+			if err := commitChanges(changes); err != nil {
+				return nil, err
+			}
+		}
+
+		text := patchSummary(result)
+		log.Printf("[TOOL COMPLETED] %s - %d files, applied=%t", request.Params.Name, len(result.Files), result.Applied)
+
+		toolResult := mcp.NewToolResultStructured(result, text)
+		toolResult.IsError = !result.Applied
+		return toolResult, nil
+	}
+}
+
+// planFilePatch resolves the paths of p and applies its hunks in memory.
+// Problems with the tree (missing files, rejected hunks) are reported in the
+// file result; only containment violations are returned as errors.
+func planFilePatch(cfg *serverConfig, p filePatch, fuzz int, touched map[string]bool) (plannedChange, patchFileResult, error) {
+	change := plannedChange{patch: p, perm: defaultFilePerm}
+	fileResult := patchFileResult{Operation: p.operation(), Hunks: []patchHunkResult{}}
+
+	var err error
+	if p.OldPath != "" {
+		if change.oldPath, err = resolveWritePath(cfg, p.OldPath); err != nil {
+			return change, fileResult, fmt.Errorf("%s: %w", p.OldPath, err)
+		}
+	}
+	if p.NewPath != "" {
+		if change.newPath, err = resolveWritePath(cfg, p.NewPath); err != nil {
+			return change, fileResult, fmt.Errorf("%s: %w", p.NewPath, err)
+		}
+	}
+
+	for _, path := range []string{change.oldPath, change.newPath} {
+		if path != "" && touched[path] {
+			fileResult.Error = "file appears more than once in the patch"
+			return change, fileResult, nil
+		}
+	}
+	touched[change.oldPath], touched[change.newPath] = true, true
+
+	var current string
+	if change.oldPath != "" {
+		info, err := os.Stat(change.oldPath)
+		if err != nil {
+			fileResult.Error = fmt.Sprintf("cannot read %s: file does not exist", p.OldPath)
+			return change, fileResult, nil
+		}
+		if !info.Mode().IsRegular() {
+			fileResult.Error = fmt.Sprintf("%s is not a regular file", p.OldPath)
+			return change, fileResult, nil
+		}
+		data, err := os.ReadFile(change.oldPath)
+		if err != nil {
+			fileResult.Error = fmt.Sprintf("cannot read %s: %v", p.OldPath, err)
+			return change, fileResult, nil
+		}
+		current = string(data)
+		change.perm = info.Mode().Perm()
+	}
+	if change.newPath != "" && change.newPath != change.oldPath {
+		if _, err := os.Lstat(change.newPath); err == nil {
+			fileResult.Error = fmt.Sprintf("%s already exists", p.NewPath)
+			return change, fileResult, nil
+		}
+	}
+
+	patched, hunkResults, ok := applyHunks(splitLines(current), p.Hunks, fuzz)
+	fileResult.Hunks = hunkResults
+	change.content = strings.Join(patched, "")
+
+	if ok && change.newPath == "" && change.content != "" {
+		fileResult.Error = "file is not empty after removing the deleted lines"
+	}
+	return change, fileResult, nil
+}
+
+// commitChanges writes planned changes to disk. If one fails, the changes
+// already made are rolled back so the tree is left as it was.
+func commitChanges(changes []plannedChange) error {
+	var undo []func()
+	rollback := func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+	}
+
+	for _, c := range changes {
+		var original []byte
+		if c.oldPath != "" {
+			data, err := os.ReadFile(c.oldPath)
+			if err != nil {
+				rollback()
+				return fmt.Errorf("failed to read %s: %w", c.oldPath, err)
+			}
+			original = data
+		}
+
+		var err error
+		switch c.patch.operation() {
+		case patchOpCreate:
+			if err = makeParents(c.newPath, &undo); err == nil {
+				_, _, err = atomicWriteFile(c.newPath, []byte(c.content), writeModeCreate)
+			}
+			if err == nil {
+				path := c.newPath
+				undo = append(undo, func() { os.Remove(path) })
+			}
+
+		case patchOpDelete:
+			if err = os.Remove(c.oldPath); err == nil {
+				path, perm := c.oldPath, c.perm
+				undo = append(undo, func() { os.WriteFile(path, original, perm) })
+			}
+
+		case patchOpRename:
+			if err = makeParents(c.newPath, &undo); err == nil {
+				err = os.Rename(c.oldPath, c.newPath)
+			}
+			if err == nil {
+				oldPath, newPath := c.oldPath, c.newPath
+				undo = append(undo, func() { os.Rename(newPath, oldPath) })
+				if c.content != string(original) {
+					_, _, err = atomicWriteFile(c.newPath, []byte(c.content), writeModeOverwrite)
+					if err == nil {
+						undo = append(undo, func() { atomicWriteFile(newPath, original, writeModeOverwrite) })
+					}
+				}
+			}
+
+		default:
+			if _, _, err = atomicWriteFile(c.oldPath, []byte(c.content), writeModeOverwrite); err == nil {
+				path := c.oldPath
+				undo = append(undo, func() { atomicWriteFile(path, original, writeModeOverwrite) })
+			}
+		}
+
+		if err != nil {
+			rollback()
+			return fmt.Errorf("failed to apply patch, changes rolled back: %w", err)
+		}
+	}
+	return nil
+}
+
+// makeParents creates the missing parent directories of path, adding their
+// removal to undo so a rollback leaves no empty directories behind
+func makeParents(path string, undo *[]func()) error {
+	var missing []string // deepest first
+	for dir := filepath.Dir(path); filepath.Dir(dir) != dir; dir = filepath.Dir(dir) {
+		if _, err := os.Lstat(dir); !os.IsNotExist(err) {
+			break
+		}
+		missing = append(missing, dir)
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	*undo = append(*undo, func() {
+		for _, dir := range missing {
+			os.Remove(dir)
+		}
+	})
+	return err
+}
+
+// patchSummary renders a patch result as text, one line per file and rejected hunk
+func patchSummary(result patchResult) string {
+	var sb strings.Builder
+	if result.Applied {
+		sb.WriteString(fmt.Sprintf("Patch applied to %d files\n", len(result.Files)))
+	} else {
+		sb.WriteString("Patch rejected; no files were changed\n")
+	}
+
+	for _, f := range result.Files {
+		name := f.Path
+		if f.OldPath != "" {
+			name = f.OldPath + " -> " + f.Path
+		}
+		sb.WriteString(fmt.Sprintf("%s %s\n", f.Operation, name))
+		if f.Error != "" {
+			sb.WriteString(fmt.Sprintf("  error: %s\n", f.Error))
+		}
+		for _, h := range f.Hunks {
+			switch {
+			case h.Status != "applied":
+				sb.WriteString(fmt.Sprintf("  hunk %d rejected: %s\n", h.Hunk, h.Message))
+			case h.Offset != 0 || h.Fuzz != 0:
+				sb.WriteString(fmt.Sprintf("  hunk %d applied with offset %d, fuzz %d\n", h.Hunk, h.Offset, h.Fuzz))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// applyPatchOutputSchema describes patchResult for clients that validate structured content
+const applyPatchOutputSchema = `{
+	"type": "object",
+	"properties": {
+		"applied": {"type": "boolean", "description": "False if any file or hunk was rejected; nothing is changed then"},
+		"files": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"path": {"type": "string"},
+					"old_path": {"type": "string"},
+					"operation": {"type": "string", "enum": ["create", "modify", "delete", "rename"]},
+					"error": {"type": "string"},
+					"hunks": {
+						"type": "array",
+						"items": {
+							"type": "object",
+							"properties": {
+								"hunk": {"type": "integer"},
+								"status": {"type": "string", "enum": ["applied", "rejected"]},
+								"offset": {"type": "integer"},
+								"fuzz": {"type": "integer"},
+								"message": {"type": "string"}
+							},
+							"required": ["hunk", "status"]
+						}
+					}
+				},
+				"required": ["path", "operation", "hunks"]
+			}
+		}
+	},
+	"required": ["applied", "files"]
+}`
+
+// applyPatchServerTool defines the apply_patch tool
+func applyPatchServerTool(cfg *serverConfig) server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "apply_patch",
+			Description: "Applies a multi-file unified diff under the root, all or nothing",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"patch": map[string]interface{}{
+						"type":        "string",
+						"description": "Unified diff (diff -u or git diff); paths are relative to the root, a/ and b/ prefixes are removed",
+					},
+					"fuzz": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of context lines at each end of a hunk that may be ignored when it does not match exactly",
+						"minimum":     0,
+						"maximum":     maxPatchFuzz,
+						"default":     0,
+					},
+				},
+				Required: []string{"patch"},
+			},
+			RawOutputSchema: json.RawMessage(applyPatchOutputSchema),
+		},
+		Handler: applyPatchTool(cfg),
+	}
+}
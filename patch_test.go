@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// applyPatch calls apply_patch and returns its structured result
+func applyPatch(t *testing.T, cfg *serverConfig, patch string, fuzz int) patchResult {
+	t.Helper()
+
+	arguments, _ := json.Marshal(map[string]interface{}{"patch": patch, "fuzz": fuzz})
+	result, err := callTool(applyPatchTool(cfg), "apply_patch", string(arguments))
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	patched, ok := result.StructuredContent.(patchResult)
+	if !ok {
+		t.Fatalf("StructuredContent is not patchResult, got %T", result.StructuredContent)
+	}
+	if result.IsError == patched.Applied {
+		t.Errorf("IsError %t does not match applied %t", result.IsError, patched.Applied)
+	}
+	return patched
+}
+
+func TestParsePatch_GitHeaders(t *testing.T) {
+	patch := `diff --git a/old.txt b/new.txt
+similarity index 100%
+rename from old.txt
+rename to new.txt
+diff --git a/added.txt b/added.txt
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/added.txt
+@@ -0,0 +1 @@
++hello
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+\ No newline at end of file
+`
+	patches, err := parsePatch(patch)
+	if err != nil {
+		t.Fatalf("parsePatch failed: %v", err)
+	}
+	if len(patches) != 3 {
+		t.Fatalf("Expected 3 file patches, got %d", len(patches))
+	}
+
+	expected := []struct{ op, oldPath, newPath string }{
+		{patchOpRename, "old.txt", "new.txt"},
+		{patchOpCreate, "", "added.txt"},
+		{patchOpDelete, "gone.txt", ""},
+	}
+	for i, e := range expected {
+		p := patches[i]
+		if p.operation() != e.op || p.OldPath != e.oldPath || p.NewPath != e.newPath {
+			t.Errorf("Patch %d: expected %+v, got %s %q -> %q", i, e, p.operation(), p.OldPath, p.NewPath)
+		}
+	}
+	if ops := patches[2].Hunks[0].Ops; len(ops) != 1 || ops[0].line != "bye" {
+		t.Errorf("Expected no-newline marker to strip the newline, got %+v", ops)
+	}
+}
+
+func TestParsePatch_Errors(t *testing.T) {
+	tests := []struct {
+		patch   string
+		errText string
+	}{
+		{"just some text\n", "no file changes"},
+		{"@@ -1 +1 @@\n-a\n+b\n", "without a file header"},
+		{"--- a/x\n+++ b/x\n@@ -1,2 +1,2 @@\n-a\n+b\n", "shorter than its header"},
+		{"--- a/x\n+++ b/x\n@@ -1 +1 @@\n*a\n", "unexpected line"},
+		{"--- a/x\n+++ b/x\n@@ bogus @@\n", "malformed hunk header"},
+		{"diff --git a/x b/x\nBinary files a/x and b/x differ\n", "binary patches"},
+	}
+
+	for _, tt := range tests {
+		_, err := parsePatch(tt.patch)
+		if err == nil || !contains(err.Error(), tt.errText) {
+			t.Errorf("Expected '%s' error for %q, got %v", tt.errText, tt.patch, err)
+		}
+	}
+}
+
+func TestApplyPatchTool_CRLF(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	writeTestFile(t, filepath.Join(tempDir, "win.txt"), "one\r\n\r\ntwo\r\n")
+
+	// The empty context line has lost its leading space, as some editors do
+	patch := "--- a/win.txt\r\n+++ b/win.txt\r\n@@ -1,3 +1,3 @@\r\n one\r\n\r\n-two\r\n+TWO\r\n"
+	if result := applyPatch(t, writeConfig(tempDir), patch, 0); !result.Applied {
+		t.Fatalf("Expected the patch to apply, got %+v", result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "win.txt")); string(data) != "one\r\n\r\nTWO\r\n" {
+		t.Errorf("Unexpected content %q", data)
+	}
+}
+
+func TestCommitChanges_RollbackRemovesCreatedDirectories(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	created := filepath.Join(tempDir, "subdir", "new", "deeper", "f.txt")
+	changes := []plannedChange{
+		{patch: filePatch{NewPath: "subdir/new/deeper/f.txt"}, newPath: created, content: "x\n"},
+		{patch: filePatch{OldPath: "gone.txt", NewPath: "gone.txt"}, oldPath: filepath.Join(tempDir, "gone.txt"), newPath: filepath.Join(tempDir, "gone.txt")},
+	}
+	if err := commitChanges(changes); err == nil {
+		t.Fatal("Expected the second change to fail")
+	}
+
+	// The directories made for the new file are gone; the one that existed stays
+	if _, err := os.Stat(filepath.Join(tempDir, "subdir", "new")); !os.IsNotExist(err) {
+		t.Errorf("Expected created directories to be removed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "subdir")); err != nil {
+		t.Errorf("Expected the existing directory to remain: %v", err)
+	}
+}
+
+func TestApplyHunks_OffsetAndFuzz(t *testing.T) {
+	lines := splitLines("x\ny\na\nb\nc\nd\ne\n")
+	hunks := []patchHunk{{
+		OldStart: 1, OldCount: 3, NewStart: 1, NewCount: 3,
+		Ops: []diffOp{{' ', "a\n"}, {'-', "b\n"}, {'+', "B\n"}, {' ', "c\n"}},
+	}}
+
+	// The hunk claims line 1 but matches two lines further down
+	out, results, ok := applyHunks(lines, hunks, 0)
+	if !ok || results[0].Offset != 2 || results[0].Fuzz != 0 {
+		t.Fatalf("Expected offset application, got %+v", results)
+	}
+	if got := joinLines(out); got != "x\ny\na\nB\nc\nd\ne\n" {
+		t.Errorf("Unexpected result %q", got)
+	}
+
+	// Mismatched leading context needs fuzz 1
+	hunks[0].Ops[0].line = "changed\n"
+	if _, results, ok := applyHunks(lines, hunks, 0); ok || results[0].Status != "rejected" {
+		t.Errorf("Expected rejection without fuzz, got %+v", results)
+	}
+	out, results, ok = applyHunks(lines, hunks, 1)
+	if !ok || results[0].Fuzz != 1 {
+		t.Fatalf("Expected fuzzy application, got %+v", results)
+	}
+	if got := joinLines(out); got != "x\ny\na\nB\nc\nd\ne\n" {
+		t.Errorf("Unexpected fuzzy result %q", got)
+	}
+}
+
+func TestApplyPatchTool_MultiFile(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	writeTestFile(t, filepath.Join(tempDir, "a.txt"), "one\ntwo\nthree\n")
+	writeTestFile(t, filepath.Join(tempDir, "old.txt"), "keep\nmove\n")
+	writeTestFile(t, filepath.Join(tempDir, "gone.txt"), "bye\n")
+
+	patch := `--- a/a.txt
++++ b/a.txt
+@@ -1,3 +1,3 @@
+ one
+-two
++TWO
+ three
+diff --git a/old.txt b/sub/new.txt
+rename from old.txt
+rename to sub/new.txt
+--- a/old.txt
++++ b/sub/new.txt
+@@ -1,2 +1,2 @@
+ keep
+-move
++moved
+--- /dev/null
++++ b/created.txt
+@@ -0,0 +1,2 @@
++fresh
++file
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+`
+	result := applyPatch(t, writeConfig(tempDir), patch, 0)
+	if !result.Applied || len(result.Files) != 4 {
+		t.Fatalf("Expected all 4 files applied, got %+v", result)
+	}
+
+	expected := map[string]string{
+		"a.txt":       "one\nTWO\nthree\n",
+		"sub/new.txt": "keep\nmoved\n",
+		"created.txt": "fresh\nfile\n",
+	}
+	for name, content := range expected {
+		if data, err := os.ReadFile(filepath.Join(tempDir, name)); err != nil || string(data) != content {
+			t.Errorf("%s: expected %q, got %q (%v)", name, content, data, err)
+		}
+	}
+	for _, name := range []string{"old.txt", "gone.txt"} {
+		if _, err := os.Stat(filepath.Join(tempDir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", name)
+		}
+	}
+}
+
+func TestApplyPatchTool_AllOrNothing(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	writeTestFile(t, filepath.Join(tempDir, "a.txt"), "one\ntwo\n")
+
+	patch := `--- a/a.txt
++++ b/a.txt
+@@ -1,2 +1,2 @@
+ one
+-two
++TWO
+--- a/file1.txt
++++ b/file1.txt
+@@ -1 +1 @@
+-not what the file says
++x
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1 @@
++new
+`
+	result := applyPatch(t, writeConfig(tempDir), patch, 0)
+	if result.Applied {
+		t.Fatal("Expected the patch to be rejected")
+	}
+	if h := result.Files[0].Hunks[0]; h.Status != "applied" {
+		t.Errorf("Expected first file's hunk to validate, got %+v", h)
+	}
+	if h := result.Files[1].Hunks[0]; h.Status != "rejected" || h.Message == "" {
+		t.Errorf("Expected second file's hunk to be rejected, got %+v", h)
+	}
+
+	// Nothing was written
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "a.txt")); string(data) != "one\ntwo\n" {
+		t.Errorf("a.txt modified by rejected patch: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "new.txt")); !os.IsNotExist(err) {
+		t.Error("new.txt created by rejected patch")
+	}
+}
+
+func TestApplyPatchTool_FileErrors(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := writeConfig(tempDir)
+
+	result := applyPatch(t, cfg, "--- /dev/null\n+++ b/file1.txt\n@@ -0,0 +1 @@\n+x\n", 0)
+	if result.Applied || !contains(result.Files[0].Error, "already exists") {
+		t.Errorf("Expected existing file error, got %+v", result)
+	}
+
+	result = applyPatch(t, cfg, "--- a/missing.txt\n+++ b/missing.txt\n@@ -1 +1 @@\n-a\n+b\n", 0)
+	if result.Applied || !contains(result.Files[0].Error, "does not exist") {
+		t.Errorf("Expected missing file error, got %+v", result)
+	}
+
+	_, err := callTool(applyPatchTool(cfg), "apply_patch", `{"patch": "--- a/../x\n+++ b/../x\n@@ -1 +1 @@\n-a\n+b\n"}`)
+	if err == nil || !contains(err.Error(), "outside root directory") {
+		t.Errorf("Expected containment error, got %v", err)
+	}
+
+	_, err = callTool(applyPatchTool(cfg), "apply_patch", `{"patch": "x", "fuzz": 9}`)
+	if err == nil || !contains(err.Error(), "fuzz must be between") {
+		t.Errorf("Expected fuzz error, got %v", err)
+	}
+}
+
+// joinLines concatenates lines that keep their newlines
+func joinLines(lines []string) string {
+	var s string
+	for _, line := range lines {
+		s += line
+	}
+	return s
+}
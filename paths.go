@@ -0,0 +1,211 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Symlink policies accepted by the -symlink-policy flag
+const (
+	symlinkPolicyDeny       = "deny"        // refuse any path that passes through a symlink
+	symlinkPolicyWithinRoot = "within-root" // allow symlinks whose resolved target stays inside the root
+	symlinkPolicyFollow     = "follow"      // follow symlinks wherever they point
+)
+
+// Containment errors returned by resolvePath
+var (
+	errOutsideRoot   = errors.New("path is outside root directory")
+	errSymlinkDenied = errors.New("path traverses a symlink, which the server's symlink policy denies")
+	errSymlinkEscape = errors.New("path resolves through a symlink to a location outside root directory")
+)
+
+// validSymlinkPolicy reports whether p is a supported symlink policy
+func validSymlinkPolicy(p string) bool {
+	switch p {
+	case symlinkPolicyDeny, symlinkPolicyWithinRoot, symlinkPolicyFollow:
+		return true
+	}
+	return false
+}
+
+// mapInputPath maps a tool's "/"-rooted input path onto the absolute root
+// directory without touching the filesystem. Paths that climb out of the root
+// with ".." are rejected rather than clamped.
+func mapInputPath(rootDir, input string) (string, error) {
+	if strings.ContainsRune(input, 0) {
+		return "", fmt.Errorf("invalid path: contains NUL byte")
+	}
+
+	// Inputs always use forward slashes; strip the leading one so the path is relative
+	rel := filepath.FromSlash(strings.TrimLeft(input, "/"))
+	if filepath.IsAbs(rel) || filepath.VolumeName(rel) != "" {
+		return "", errOutsideRoot
+	}
+
+	target := filepath.Join(rootDir, rel)
+	if !withinRoot(rootDir, target) {
+		return "", errOutsideRoot
+	}
+	return target, nil
+}
+
+// withinRoot reports whether p is root or lies below it. Both must be clean
+// absolute paths; comparison is on whole path segments, so "/data/proj-secrets"
+// is not within "/data/proj".
+func withinRoot(root, p string) bool {
+	if p == root {
+		return true
+	}
+	if !strings.HasSuffix(root, string(filepath.Separator)) {
+		root += string(filepath.Separator)
+	}
+	return strings.HasPrefix(p, root)
+}
+
+// resolvePath maps a tool input path to an absolute path inside the served
+// root and enforces the symlink policy. It is shared by every tool so they all
+// apply the same containment rules. The path does not need to exist; symlinks
+// in its existing leading components are still checked.
+func resolvePath(cfg *serverConfig, input string) (string, error) {
+	target, err := mapInputPath(cfg.RootDir, input)
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkSymlinks(cfg.RootDir, target, cfg.SymlinkPolicy); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// checkSymlinks enforces policy for target, an absolute path already known to
+// be lexically inside rootDir
+func checkSymlinks(rootDir, target, policy string) error {
+	switch policy {
+	case symlinkPolicyFollow:
+		return nil
+
+	case symlinkPolicyDeny:
+		// Lstat every component below the root; a missing component ends the check
+		current := rootDir
+		rel, _ := filepath.Rel(rootDir, target)
+		if rel == "." {
+			return nil
+		}
+		for _, segment := range strings.Split(rel, string(filepath.Separator)) {
+			current = filepath.Join(current, segment)
+			info, err := os.Lstat(current)
+			if err != nil {
+				return nil
+			}
+			if info.Mode()&os.ModeSymlink != 0 {
+				return errSymlinkDenied
+			}
+		}
+		return nil
+	}
+
+	// within-root: the fully resolved target must stay inside the resolved root
+	realRoot, err := filepath.EvalSymlinks(rootDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve root directory: %w", err)
+	}
+
+	realTarget, err := evalExistingSymlinks(target)
+	if err != nil {
+		return err
+	}
+
+	if !withinRoot(realRoot, realTarget) {
+		return errSymlinkEscape
+	}
+	return nil
+}
+
+// evalExistingSymlinks resolves symlinks in the longest existing prefix of p
+// and appends the remaining, not yet existing, components unchanged
+func evalExistingSymlinks(p string) (string, error) {
+	var missing []string
+	current := p
+	for {
+		resolved, err := filepath.EvalSymlinks(current)
+		if err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				resolved = filepath.Join(resolved, missing[i])
+			}
+			return resolved, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to resolve path: %w", err)
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return p, nil
+		}
+		missing = append(missing, filepath.Base(current))
+		current = parent
+	}
+}
+
+// Output path styles accepted by the -path-style flag and the path_style argument
+const (
+	pathStyleVirtual  = "virtual"  // "/src/x.go", the same namespace tools accept as input
+	pathStyleRelative = "relative" // "x.go", relative to the requested path
+	pathStyleAbsolute = "absolute" // "/home/ci/root/src/x.go", the host filesystem path
+)
+
+// validPathStyle reports whether s is a supported path style
+func validPathStyle(s string) bool {
+	switch s {
+	case pathStyleVirtual, pathStyleRelative, pathStyleAbsolute:
+		return true
+	}
+	return false
+}
+
+// pathFormatter converts root-relative slash paths into the configured output style
+type pathFormatter struct {
+	style     string
+	rootDir   string // absolute served root
+	targetRel string // root-relative path of the requested path, used by the relative style
+}
+
+// newPathFormatter returns a formatter for results of a call on targetRel
+func newPathFormatter(style, rootDir, targetRel string) (pathFormatter, error) {
+	if !validPathStyle(style) {
+		return pathFormatter{}, fmt.Errorf("invalid path_style %q: must be one of virtual, relative, absolute", style)
+	}
+	return pathFormatter{style: style, rootDir: rootDir, targetRel: targetRel}, nil
+}
+
+// format returns the output form of the root-relative path rel
+func (f pathFormatter) format(rel string) string {
+	switch f.style {
+	case pathStyleVirtual:
+		return virtualPath(rel)
+	case pathStyleRelative:
+		if f.targetRel == "." {
+			return rel
+		}
+		if rel == f.targetRel {
+			return "."
+		}
+		if isAncestorPath(f.targetRel, rel) {
+			return rel[len(f.targetRel)+1:]
+		}
+		return rel
+	}
+
+	// Absolute paths use forward slashes for cross-platform consistency
+	return filepath.ToSlash(filepath.Join(f.rootDir, filepath.FromSlash(rel)))
+}
+
+// virtualPath returns the "/"-rooted form of a root-relative path, as accepted by tool inputs
+func virtualPath(rel string) string {
+	return path.Join("/", rel)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPathFormatter(t *testing.T) {
+	tests := []struct {
+		style     string
+		targetRel string
+		rel       string
+		want      string
+	}{
+		{pathStyleVirtual, ".", ".", "/"},
+		{pathStyleVirtual, ".", "src/x.go", "/src/x.go"},
+		{pathStyleVirtual, "src", "src/x.go", "/src/x.go"},
+		{pathStyleRelative, ".", "src/x.go", "src/x.go"},
+		{pathStyleRelative, "src", "src", "."},
+		{pathStyleRelative, "src", "src/x.go", "x.go"},
+		{pathStyleAbsolute, "src", "src/x.go", "/data/root/src/x.go"},
+		{pathStyleAbsolute, ".", ".", "/data/root"},
+	}
+
+	for _, tt := range tests {
+		f, err := newPathFormatter(tt.style, "/data/root", tt.targetRel)
+		if err != nil {
+			t.Fatalf("newPathFormatter(%q) returned error: %v", tt.style, err)
+		}
+		if got := f.format(tt.rel); got != tt.want {
+			t.Errorf("%s format(%q) from %q = %q, want %q", tt.style, tt.rel, tt.targetRel, got, tt.want)
+		}
+	}
+}
+
+func TestPathFormatter_InvalidStyle(t *testing.T) {
+	if _, err := newPathFormatter("windows", "/data/root", "."); err == nil {
+		t.Fatal("Expected error for invalid path style")
+	}
+}
+
+func TestWithinRoot(t *testing.T) {
+	tests := []struct {
+		root, p string
+		want    bool
+	}{
+		{"/data/proj", "/data/proj", true},
+		{"/data/proj", "/data/proj/a", true},
+		{"/data/proj", "/data/proj-secrets", false},
+		{"/data/proj", "/data/projx/a", false},
+		{"/data/proj", "/data", false},
+		{"/", "/etc", true},
+	}
+
+	for _, tt := range tests {
+		if got := withinRoot(tt.root, tt.p); got != tt.want {
+			t.Errorf("withinRoot(%q, %q) = %v, want %v", tt.root, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestMapInputPath(t *testing.T) {
+	root := filepath.FromSlash("/data/proj")
+
+	tests := []struct {
+		input   string
+		want    string
+		outside bool
+	}{
+		{"/", "/data/proj", false},
+		{"", "/data/proj", false},
+		{"/src/x.go", "/data/proj/src/x.go", false},
+		{"src/../docs", "/data/proj/docs", false},
+		{"//src", "/data/proj/src", false},
+		{"../proj-secrets", "", true},
+		{"/../proj-secrets/key", "", true},
+		{"/src/../../proj", "/data/proj", false},
+		{"/../..", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := mapInputPath(root, tt.input)
+		if tt.outside {
+			if !errors.Is(err, errOutsideRoot) {
+				t.Errorf("mapInputPath(%q) = %q, %v; want errOutsideRoot", tt.input, got, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("mapInputPath(%q) returned error: %v", tt.input, err)
+		} else if got != filepath.FromSlash(tt.want) {
+			t.Errorf("mapInputPath(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestResolvePath_SymlinkPolicies(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	outside, err := os.MkdirTemp("", "mcp-walker-outside-")
+	if err != nil {
+		t.Fatalf("Failed to create outside dir: %v", err)
+	}
+	defer os.RemoveAll(outside)
+
+	if err := os.Symlink(outside, filepath.Join(tempDir, "escape")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	if err := os.Symlink("subdir", filepath.Join(tempDir, "inside")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	tests := []struct {
+		policy string
+		input  string
+		want   error
+	}{
+		{symlinkPolicyWithinRoot, "/inside/file2.go", nil},
+		{symlinkPolicyWithinRoot, "/escape", errSymlinkEscape},
+		{symlinkPolicyWithinRoot, "/escape/new/file.txt", errSymlinkEscape},
+		{symlinkPolicyDeny, "/inside/file2.go", errSymlinkDenied},
+		{symlinkPolicyDeny, "/subdir/file2.go", nil},
+		{symlinkPolicyDeny, "/subdir/missing/file", nil},
+		{symlinkPolicyFollow, "/escape", nil},
+	}
+
+	for _, tt := range tests {
+		cfg := newServerConfig(tempDir)
+		cfg.SymlinkPolicy = tt.policy
+
+		_, err := resolvePath(cfg, tt.input)
+		if tt.want == nil && err != nil {
+			t.Errorf("%s: resolvePath(%q) returned error: %v", tt.policy, tt.input, err)
+		}
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: resolvePath(%q) = %v, want %v", tt.policy, tt.input, err, tt.want)
+		}
+	}
+}
+
+func FuzzMapInputPath(f *testing.F) {
+	root := filepath.FromSlash("/data/proj")
+
+	for _, seed := range []string{"/", "", "../proj-secrets", "/a/../../b", "a/./b//c", "/..", "....//", "a\x00b", `..\..\x`} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		got, err := mapInputPath(root, input)
+		if err != nil {
+			return
+		}
+
+		// Any accepted path must be clean, absolute and inside the root on a segment boundary
+		if got != filepath.Clean(got) || !filepath.IsAbs(got) {
+			t.Fatalf("mapInputPath(%q) = %q is not a clean absolute path", input, got)
+		}
+		if !withinRoot(root, got) {
+			t.Fatalf("mapInputPath(%q) = %q escapes root %q", input, got, root)
+		}
+
+		rel, relErr := filepath.Rel(root, got)
+		if relErr != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			t.Fatalf("mapInputPath(%q) = %q is not below root (rel %q)", input, got, rel)
+		}
+	})
+}
+
+func FuzzWithinRoot(f *testing.F) {
+	f.Add("/data/proj", "/data/proj-secrets")
+	f.Add("/data/proj", "/data/proj/x")
+	f.Add("/", "/x")
+
+	f.Fuzz(func(t *testing.T, root, p string) {
+		root = filepath.Clean("/" + root)
+		p = filepath.Clean("/" + p)
+
+		rel, err := filepath.Rel(root, p)
+		below := err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+		if got := withinRoot(root, p); got != below {
+			t.Fatalf("withinRoot(%q, %q) = %v, but Rel gives %q", root, p, got, rel)
+		}
+	})
+}
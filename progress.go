@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// methodNotificationProgress is the notification sent while a request with a
+// progress token runs
+const methodNotificationProgress = "notifications/progress"
+
+// progressInterval is the least time between progress notifications sent
+// with reportThrottled
+const progressInterval = 250 * time.Millisecond
+
+// progressReporter sends progress notifications for a tool call whose client
+// supplied a progress token. A nil reporter does nothing, so handlers can
+// report unconditionally.
+type progressReporter struct {
+	ctx       context.Context
+	mcpServer *server.MCPServer
+	token     mcp.ProgressToken
+	last      time.Time // when reportThrottled last sent a notification
+}
+
+// newProgressReporter returns a reporter for request, or nil when the client
+// did not ask for progress
+func newProgressReporter(ctx context.Context, request mcp.CallToolRequest) *progressReporter {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return nil
+	}
+	mcpServer := server.ServerFromContext(ctx)
+	if mcpServer == nil {
+		return nil
+	}
+	return &progressReporter{ctx: ctx, mcpServer: mcpServer, token: request.Params.Meta.ProgressToken}
+}
+
+// report sends the progress made so far. total is left out when it is not
+// known (zero), and message when it is empty.
+func (p *progressReporter) report(progress, total float64, message string) {
+	if p == nil {
+		return
+	}
+	params := map[string]any{"progressToken": p.token, "progress": progress}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+	if err := p.mcpServer.SendNotificationToClient(p.ctx, methodNotificationProgress, params); err != nil {
+		log.Printf("Failed to send progress notification: %v", err)
+	}
+}
+
+// reportThrottled is report for work that advances in many small steps: it
+// sends at most one notification per progressInterval and drops the rest
+func (p *progressReporter) reportThrottled(progress, total float64, message string) {
+	if p == nil || time.Since(p.last) < progressInterval {
+		return
+	}
+	p.last = time.Now()
+	p.report(progress, total, message)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// callToolWithProgress calls a tool through mcpServer on behalf of session,
+// passing a progress token, and returns the response
+func callToolWithProgress(mcpServer *server.MCPServer, session *testSession, name string, arguments any) mcp.JSONRPCMessage {
+	msg, _ := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]any{
+			"name":      name,
+			"arguments": arguments,
+			"_meta":     map[string]any{"progressToken": "tok-1"},
+		},
+	})
+	return mcpServer.HandleMessage(mcpServer.WithContext(context.Background(), session), msg)
+}
+
+func TestProgressReporter(t *testing.T) {
+	// Without a progress token there is nothing to report to
+	if p := newProgressReporter(context.Background(), mcp.CallToolRequest{}); p != nil {
+		t.Errorf("Expected no reporter without a progress token, got %+v", p)
+	}
+	var nilReporter *progressReporter
+	nilReporter.report(1, 2, "ignored")
+
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	mcpServer.AddTool(mcp.NewTool("count"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		progress := newProgressReporter(ctx, request)
+		progress.report(1, 0, "")
+		progress.report(2, 3, "two of three")
+		return mcp.NewToolResultText("done"), nil
+	})
+
+	session := &testSession{id: "progress-session", notifications: make(chan mcp.JSONRPCNotification, 10)}
+	if _, ok := callToolWithProgress(mcpServer, session, "count", map[string]any{}).(mcp.JSONRPCResponse); !ok {
+		t.Fatal("Expected the tool call to succeed")
+	}
+
+	if len(session.notifications) != 2 {
+		t.Fatalf("Expected 2 progress notifications, got %d", len(session.notifications))
+	}
+	first, second := <-session.notifications, <-session.notifications
+	if first.Method != methodNotificationProgress || first.Params.AdditionalFields["progressToken"] != "tok-1" {
+		t.Errorf("Unexpected notification %s %v", first.Method, first.Params.AdditionalFields)
+	}
+	if _, ok := first.Params.AdditionalFields["total"]; ok {
+		t.Errorf("Expected no total when it is unknown, got %v", first.Params.AdditionalFields)
+	}
+	if second.Params.AdditionalFields["total"] != float64(3) || second.Params.AdditionalFields["message"] != "two of three" {
+		t.Errorf("Unexpected notification %v", second.Params.AdditionalFields)
+	}
+}
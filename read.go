@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultMaxReadBytes caps how much of a file read_file returns in one call
+const defaultMaxReadBytes = 1 << 20
+
+// binarySniffLen is how many leading bytes are inspected to detect binary files
+const binarySniffLen = 8000
+
+// readResult is the structured content returned by read_file
+type readResult struct {
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`                   // total file size in bytes
+	Content     string `json:"content"`                // empty for binary files
+	StartLine   int    `json:"start_line,omitempty"`   // first line returned (line mode)
+	EndLine     int    `json:"end_line,omitempty"`     // last line returned (line mode)
+	ByteOffset  int64  `json:"byte_offset"`            // offset of the first byte returned
+	BytesRead   int    `json:"bytes_read"`             // bytes of file content returned
+	Truncated   bool   `json:"truncated"`              // output was cut by the size limit
+	PartialLine bool   `json:"partial_line,omitempty"` // end_line was cut short by the size limit (line mode)
+	Binary      bool   `json:"binary"`                 // file looks binary; no content returned
+}
+
+// readFileTool implements the read_file tool handler
+func readFileTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		// Parse the arguments
+		var args struct {
+			Path        string `json:"path"`
+			Offset      int    `json:"offset"`
+			Limit       int    `json:"limit"`
+			ByteOffset  int64  `json:"byte_offset"`
+			ByteLimit   int64  `json:"byte_limit"`
+			LineNumbers bool   `json:"line_numbers"`
+		}
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+
+		if args.Path == "" {
+			return nil, fmt.Errorf("path is required")
+		}
+		if args.Offset < 0 || args.Limit < 0 || args.ByteOffset < 0 || args.ByteLimit < 0 {
+			return nil, fmt.Errorf("offset and limit values must not be negative")
+		}
+		byteMode := args.ByteOffset > 0 || args.ByteLimit > 0
+		if byteMode && (args.Offset > 0 || args.Limit > 0) {
+			return nil, fmt.Errorf("line offset/limit and byte_offset/byte_limit cannot be combined")
+		}
+		if byteMode && args.LineNumbers {
+			return nil, fmt.Errorf("line_numbers is only supported when reading by line")
+		}
+
+		// Map the input path to actual filesystem path, enforcing root containment
+		target, err := resolvePath(cfg, args.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		// Check the type before opening: opening a FIFO blocks until a writer appears
+		info, err := os.Stat(target)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("path does not exist: %s", args.Path)
+			}
+			return nil, fmt.Errorf("failed to stat file: %w", err)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("path is a directory: %s (use walk_directory to list it)", args.Path)
+		}
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("path is not a regular file: %s", args.Path)
+		}
+
+		f, err := os.Open(target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file: %w", err)
+		}
+		defer f.Close()
+
+		formatter, _ := newPathFormatter(cfg.PathStyle, cfg.RootDir, ".")
+		result := readResult{
+			Path: formatter.format(relPath(cfg.RootDir, target)),
+			Size: info.Size(),
+		}
+
+		// Refuse to dump binary content into a text response
+		sniff := make([]byte, binarySniffLen)
+		n, err := io.ReadFull(f, sniff)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		if isBinary(sniff[:n]) {
+			result.Binary = true
+			log.Printf("[TOOL COMPLETED] %s - %s is binary (%d bytes)", request.Params.Name, args.Path, result.Size)
+			return mcp.NewToolResultStructured(result, fmt.Sprintf("%s is a binary file (%d bytes); content not returned", args.Path, result.Size)), nil
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+
+		if byteMode {
+			err = readByteRange(f, args.ByteOffset, args.ByteLimit, cfg.MaxReadBytes, &result)
+		} else {
+			err = readLineRange(f, args.Offset, args.Limit, args.LineNumbers, cfg.MaxReadBytes, &result)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+
+		// Tell the reader exactly where the output stopped and how to continue
+		text := result.Content
+		if result.Truncated {
+			text += truncationMarker(result, byteMode, cfg.MaxReadBytes)
+		}
+
+		log.Printf("[TOOL COMPLETED] %s - read %d bytes from %s", request.Params.Name, result.BytesRead, args.Path)
+
+		return mcp.NewToolResultStructured(result, text), nil
+	}
+}
+
+// readByteRange reads up to limit bytes (0 for the rest of the file) starting
+// at offset, never returning more than maxBytes
+func readByteRange(f *os.File, offset, limit, maxBytes int64, result *readResult) error {
+	result.ByteOffset = offset
+	if offset >= result.Size {
+		return nil
+	}
+
+	want := result.Size - offset
+	if limit > 0 && limit < want {
+		want = limit
+	}
+	if want > maxBytes {
+		want = maxBytes
+		result.Truncated = true
+	}
+
+	buf := make([]byte, want)
+	n, err := f.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	// Don't split a multi-byte character at the end of the window
+	data := buf[:n]
+	for len(data) > 0 && !utf8.Valid(data) && len(buf[:n])-len(data) < utf8.UTFMax {
+		data = data[:len(data)-1]
+	}
+	if len(data) == 0 {
+		data = buf[:n]
+	}
+
+	result.Content = string(data)
+	result.BytesRead = len(data)
+	return nil
+}
+
+// readLineRange reads limit lines (0 for all) starting at the 1-based line
+// offset (0 means the first line), stopping early once maxBytes would be exceeded
+func readLineRange(f *os.File, offset, limit int, lineNumbers bool, maxBytes int64, result *readResult) error {
+	if offset == 0 {
+		offset = 1
+	}
+
+	reader := bufio.NewReader(f)
+	var out strings.Builder
+	var pos int64
+	line := 0
+
+	for {
+		data, err := reader.ReadBytes('\n')
+		if len(data) > 0 {
+			line++
+			if line >= offset {
+				if result.StartLine == 0 {
+					result.StartLine = line
+					result.ByteOffset = pos
+				}
+
+				prefix := ""
+				if lineNumbers {
+					prefix = fmt.Sprintf("%6d\t", line)
+				}
+
+				// Always return at least part of the first line so progress is possible
+				if int64(out.Len()+len(prefix)+len(data)) > maxBytes {
+					if out.Len() == 0 {
+						n := partialLineLen(data, maxBytes-int64(len(prefix)))
+						out.WriteString(prefix)
+						out.Write(data[:n])
+						result.BytesRead = n
+						result.EndLine = line
+						result.PartialLine = true
+					}
+					result.Truncated = true
+					break
+				}
+
+				out.WriteString(prefix)
+				out.Write(data)
+				result.BytesRead += len(data)
+				result.EndLine = line
+
+				if limit > 0 && line-offset+1 >= limit {
+					break
+				}
+			}
+			pos += int64(len(data))
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	result.Content = out.String()
+	return nil
+}
+
+// partialLineLen returns how much of a line longer than budget fits in it,
+// backing off to a character boundary but keeping at least one character
+func partialLineLen(data []byte, budget int64) int {
+	n := int(max(budget, 0))
+	for n > 0 && !utf8.RuneStart(data[n]) {
+		n--
+	}
+	if n == 0 {
+		_, n = utf8.DecodeRune(data)
+	}
+	return n
+}
+
+// truncationMarker describes where truncated output stopped. A line cut short
+// is continued by byte offset, since a line offset would skip its remainder.
+func truncationMarker(result readResult, byteMode bool, maxBytes int64) string {
+	if byteMode || result.PartialLine {
+		next := result.ByteOffset + int64(result.BytesRead)
+		return fmt.Sprintf("\n[... truncated: output limited to %d bytes; file is %d bytes, continue with byte_offset=%d]", maxBytes, result.Size, next)
+	}
+	return fmt.Sprintf("\n[... truncated: output limited to %d bytes; file is %d bytes, continue with offset=%d]", maxBytes, result.Size, result.EndLine+1)
+}
+
+// isBinary reports whether data looks like binary content: it contains a NUL
+// byte or is not valid UTF-8 (allowing a character cut off at the end)
+func isBinary(data []byte) bool {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return true
+	}
+	for i := 0; i < utf8.UTFMax && len(data) > 0; i++ {
+		if utf8.Valid(data) {
+			return false
+		}
+		data = data[:len(data)-1]
+	}
+	return len(data) > 0
+}
+
+// readFileOutputSchema describes readResult for clients that validate structured content
+const readFileOutputSchema = `{
+	"type": "object",
+	"properties": {
+		"path": {"type": "string"},
+		"size": {"type": "integer", "description": "Total file size in bytes"},
+		"content": {"type": "string"},
+		"start_line": {"type": "integer"},
+		"end_line": {"type": "integer"},
+		"byte_offset": {"type": "integer"},
+		"bytes_read": {"type": "integer"},
+		"truncated": {"type": "boolean"},
+		"partial_line": {"type": "boolean", "description": "The last line was cut short; continue it with byte_offset"},
+		"binary": {"type": "boolean"}
+	},
+	"required": ["path", "size", "content", "truncated", "binary"]
+}`
+
+// readFileServerTool defines the read_file tool
+func readFileServerTool(cfg *serverConfig) server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "read_file",
+			Description: "Reads a text file under the root, optionally a range of lines or bytes",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "File path to read (relative to the root, e.g. '/src/main.go')",
+					},
+					"offset": map[string]interface{}{
+						"type":        "integer",
+						"description": "1-based line number to start reading from",
+						"minimum":     0,
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of lines to return (omit for all)",
+						"minimum":     0,
+					},
+					"byte_offset": map[string]interface{}{
+						"type":        "integer",
+						"description": "Byte offset to start reading from (cannot be combined with offset/limit)",
+						"minimum":     0,
+					},
+					"byte_limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of bytes to return (cannot be combined with offset/limit)",
+						"minimum":     0,
+					},
+					"line_numbers": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Prefix each line with its line number",
+						"default":     false,
+					},
+				},
+				Required: []string{"path"},
+			},
+			RawOutputSchema: json.RawMessage(readFileOutputSchema),
+		},
+		Handler: readFileTool(cfg),
+	}
+}
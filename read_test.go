@@ -0,0 +1,212 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// readFile calls read_file and returns its structured result and text content
+func readFile(t *testing.T, cfg *serverConfig, arguments string) (readResult, string) {
+	t.Helper()
+
+	result, err := callTool(readFileTool(cfg), "read_file", arguments)
+	if err != nil {
+		t.Fatalf("Handler returned error for %s: %v", arguments, err)
+	}
+
+	read, ok := result.StructuredContent.(readResult)
+	if !ok {
+		t.Fatalf("StructuredContent is not readResult, got %T", result.StructuredContent)
+	}
+	return read, result.Content[0].(mcp.TextContent).Text
+}
+
+func TestReadFileTool_WholeFile(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	read, text := readFile(t, newServerConfig(tempDir), `{"path": "/subdir/file2.go"}`)
+	if read.Content != "package main" || text != "package main" {
+		t.Errorf("Unexpected content %q / %q", read.Content, text)
+	}
+	if read.Size != int64(len("package main")) || read.Truncated || read.Binary {
+		t.Errorf("Unexpected result %+v", read)
+	}
+}
+
+func TestReadFileTool_LineRange(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	writeTestFile(t, filepath.Join(tempDir, "lines.txt"), "one\ntwo\nthree\nfour\nfive\n")
+
+	read, _ := readFile(t, newServerConfig(tempDir), `{"path": "/lines.txt", "offset": 2, "limit": 2, "line_numbers": true}`)
+
+	expected := "     2\ttwo\n     3\tthree\n"
+	if read.Content != expected {
+		t.Errorf("Expected %q, got %q", expected, read.Content)
+	}
+	if read.StartLine != 2 || read.EndLine != 3 || read.ByteOffset != 4 {
+		t.Errorf("Unexpected range %+v", read)
+	}
+}
+
+func TestReadFileTool_ByteRange(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	read, _ := readFile(t, newServerConfig(tempDir), `{"path": "/file1.txt", "byte_offset": 5, "byte_limit": 4}`)
+	if read.Content != "cont" || read.ByteOffset != 5 || read.BytesRead != 4 {
+		t.Errorf("Unexpected byte range result %+v", read)
+	}
+}
+
+func TestReadFileTool_Truncation(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	writeTestFile(t, filepath.Join(tempDir, "big.txt"), strings.Repeat("0123456789\n", 10))
+
+	cfg := newServerConfig(tempDir)
+	cfg.MaxReadBytes = 25
+
+	read, text := readFile(t, cfg, `{"path": "/big.txt"}`)
+	if !read.Truncated {
+		t.Fatal("Expected truncated result")
+	}
+	if read.EndLine != 2 || len(read.Content) != 22 {
+		t.Errorf("Expected two whole lines, got %+v", read)
+	}
+	if !contains(text, "[... truncated") || !contains(text, "offset=3") {
+		t.Errorf("Expected truncation marker with resume offset, got %q", text)
+	}
+
+	read, _ = readFile(t, cfg, `{"path": "/big.txt", "byte_offset": 10}`)
+	if !read.Truncated || read.BytesRead != 25 {
+		t.Errorf("Expected byte read capped at 25, got %+v", read)
+	}
+}
+
+func TestReadFileTool_LongLine(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	// 12 three-byte characters on the first line
+	line := strings.Repeat("日本語", 4)
+	writeTestFile(t, filepath.Join(tempDir, "long.txt"), "short\n"+line+"\nnext\n")
+
+	cfg := newServerConfig(tempDir)
+	cfg.MaxReadBytes = 20
+
+	// The line is cut at a character boundary and only its content is counted
+	read, text := readFile(t, cfg, `{"path": "/long.txt", "offset": 2, "line_numbers": true}`)
+	if !read.Truncated || !read.PartialLine || read.EndLine != 2 {
+		t.Fatalf("Expected a partial second line, got %+v", read)
+	}
+	if read.Content != "     2\t日本語日" || read.BytesRead != 12 {
+		t.Errorf("Expected 12 content bytes after the line number, got %q (%d bytes)", read.Content, read.BytesRead)
+	}
+	if !contains(text, "byte_offset=18") {
+		t.Errorf("Expected a byte_offset continuation, got %q", text)
+	}
+
+	// Continuing from there returns the rest of the line
+	cfg.MaxReadBytes = 64
+	read, _ = readFile(t, cfg, `{"path": "/long.txt", "byte_offset": 18, "byte_limit": 24}`)
+	if read.Content != "本語日本語日本語" {
+		t.Errorf("Expected the rest of the line, got %q", read.Content)
+	}
+}
+
+func TestReadFileTool_Binary(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	if err := os.WriteFile(filepath.Join(tempDir, "blob.bin"), []byte{0x7f, 'E', 'L', 'F', 0, 1, 2}, 0644); err != nil {
+		t.Fatalf("Failed to write binary file: %v", err)
+	}
+
+	read, text := readFile(t, newServerConfig(tempDir), `{"path": "/blob.bin"}`)
+	if !read.Binary || read.Content != "" {
+		t.Errorf("Expected binary result without content, got %+v", read)
+	}
+	if !contains(text, "binary file") {
+		t.Errorf("Expected binary notice, got %q", text)
+	}
+}
+
+func TestReadFileTool_Errors(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := readFileTool(newServerConfig(tempDir))
+
+	tests := []struct {
+		arguments string
+		errText   string
+	}{
+		{`{"path": "/missing.txt"}`, "does not exist"},
+		{`{"path": "/subdir"}`, "is a directory"},
+		{`{"path": "/../../etc/passwd"}`, "outside root directory"},
+		{`{"path": "/file1.txt", "offset": 1, "byte_limit": 3}`, "cannot be combined"},
+		{`{}`, "path is required"},
+	}
+
+	for _, tt := range tests {
+		_, err := callTool(handler, "read_file", tt.arguments)
+		if err == nil {
+			t.Fatalf("Expected error for %s, but got none", tt.arguments)
+		}
+		if !contains(err.Error(), tt.errText) {
+			t.Errorf("Expected '%s' error for %s, got: %v", tt.errText, tt.arguments, err)
+		}
+	}
+}
+
+func TestReadFileTool_FIFO(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	if err := makeFIFO(filepath.Join(tempDir, "pipe")); err != nil {
+		t.Skipf("Named pipes not supported: %v", err)
+	}
+
+	// Opening a pipe with no writer would block, so it must be refused first
+	done := make(chan error, 1)
+	go func() {
+		_, err := callTool(readFileTool(newServerConfig(tempDir)), "read_file", `{"path": "/pipe"}`)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || !contains(err.Error(), "not a regular file") {
+			t.Errorf("Expected a not a regular file error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("read_file blocked on a named pipe")
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		data   []byte
+		binary bool
+	}{
+		{[]byte("plain text\n"), false},
+		{[]byte("héllo"), false},
+		{[]byte("héllo")[:2], false}, // multi-byte character cut by the sniff window
+		{[]byte{0xff, 0xfe, 0x00, 0x41}, true},
+		{[]byte{0xc3, 0x28, 'a', 'b', 'c', 'd'}, true},
+	}
+
+	for _, tt := range tests {
+		if got := isBinary(tt.data); got != tt.binary {
+			t.Errorf("isBinary(%q) = %v, want %v", tt.data, got, tt.binary)
+		}
+	}
+}
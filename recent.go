@@ -0,0 +1,334 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Timestamps recent_files can filter and sort on
+const (
+	timeFieldModified = "mtime" // content modification time
+	timeFieldChanged  = "ctime" // inode change time, which also moves on creation, rename and chmod
+)
+
+// defaultMaxRecent caps recent_files results when the caller sets no limit
+const defaultMaxRecent = 100
+
+// recentEntry is a single recent_files entry
+type recentEntry struct {
+	Path       string `json:"path"`
+	Type       string `json:"type"` // file, dir, symlink or other
+	Size       int64  `json:"size"`
+	ModTime    string `json:"mtime"`
+	ChangeTime string `json:"ctime,omitempty"`
+
+	time time.Time // the time being filtered on
+}
+
+// recentResult is the structured content returned by recent_files
+type recentResult struct {
+	Since     string        `json:"since"`      // cutoff applied, RFC 3339
+	TimeField string        `json:"time_field"` // mtime or ctime
+	Entries   []recentEntry `json:"entries"`    // newest first
+	Matched   int           `json:"matched"`    // entries in the window, including those cut by max_results
+	Truncated bool          `json:"truncated"`  // max_results was reached
+}
+
+// recentFilesTool implements the recent_files tool handler
+func recentFilesTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		// Parse the arguments
+		var args struct {
+			Path          string   `json:"path"`
+			Within        string   `json:"within"`
+			Since         string   `json:"since"`
+			TimeField     string   `json:"time_field"`
+			Type          string   `json:"type"`
+			MaxDepth      int      `json:"max_depth"`
+			Include       []string `json:"include"`
+			Exclude       []string `json:"exclude"`
+			PatternSyntax string   `json:"pattern_syntax"`
+			IncludeHidden bool     `json:"include_hidden"`
+			RespectIgnore bool     `json:"respect_ignore_files"`
+			MaxResults    int      `json:"max_results"`
+		}
+		args.Path = "/"
+		args.TimeField = timeFieldModified
+		args.Type = entryTypeFiles
+		args.MaxDepth = -1
+		args.PatternSyntax = patternSyntaxGlob
+		args.IncludeHidden = true
+		args.RespectIgnore = cfg.RespectIgnoreFiles
+		args.MaxResults = defaultMaxRecent
+
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+
+		since, err := recentCutoff(args.Within, args.Since, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		switch args.TimeField {
+		case timeFieldModified:
+		case timeFieldChanged:
+			if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+				return nil, fmt.Errorf("time_field ctime is not supported on %s", runtime.GOOS)
+			}
+		default:
+			return nil, fmt.Errorf("invalid time_field %q: must be one of mtime, ctime", args.TimeField)
+		}
+		if !validEntryType(args.Type) {
+			return nil, fmt.Errorf("invalid type %q: must be one of files, dirs, symlinks, all", args.Type)
+		}
+		if args.MaxResults <= 0 {
+			return nil, fmt.Errorf("max_results must be positive")
+		}
+
+		opts := defaultWalkOptions()
+		opts.MaxDepth = args.MaxDepth
+		opts.Type = args.Type
+		opts.IncludeHidden = args.IncludeHidden
+		opts.RespectIgnore = args.RespectIgnore
+		opts.Ignore = cfg.IgnoreRules
+
+		if opts.Include, err = newPathMatcher(args.Include, args.PatternSyntax); err != nil {
+			return nil, fmt.Errorf("invalid include: %w", err)
+		}
+		if opts.Exclude, err = newPathMatcher(args.Exclude, args.PatternSyntax); err != nil {
+			return nil, fmt.Errorf("invalid exclude: %w", err)
+		}
+
+		// Map the input path to actual filesystem path, enforcing root containment
+		target, err := resolvePath(cfg, args.Path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Lstat(target); os.IsNotExist(err) {
+			return nil, fmt.Errorf("path does not exist: %s", args.Path)
+		}
+
+		formatter, _ := newPathFormatter(cfg.PathStyle, cfg.RootDir, relPath(cfg.RootDir, target))
+
+		// Keep the newest max_results entries seen so far
+		kept := &recentHeap{}
+		result := recentResult{Since: since.UTC().Format(time.RFC3339), TimeField: args.TimeField}
+		err = walkTree(cfg.RootDir, target, opts, func(entry walkEntry) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			info, err := entry.Entry.Info()
+			if err != nil {
+				return nil
+			}
+
+			item := recentEntry{
+				Path:    formatter.format(entry.Rel),
+				Type:    entryTypeName(info.Mode()),
+				Size:    info.Size(),
+				ModTime: info.ModTime().UTC().Format(time.RFC3339),
+				time:    info.ModTime(),
+			}
+			if sys, ok := platformStat(info); ok {
+				item.ChangeTime = sys.ChangeTime.UTC().Format(time.RFC3339)
+				if args.TimeField == timeFieldChanged {
+					item.time = sys.ChangeTime
+				}
+			}
+			if item.time.Before(since) {
+				return nil
+			}
+
+			result.Matched++
+			heap.Push(kept, item)
+			if kept.Len() > args.MaxResults {
+				heap.Pop(kept)
+			}
+			return nil
+		})
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			return nil, fmt.Errorf("failed to walk directory: %w", err)
+		}
+
+		result.Entries = make([]recentEntry, kept.Len())
+		for i := kept.Len() - 1; i >= 0; i-- {
+			result.Entries[i] = heap.Pop(kept).(recentEntry)
+		}
+		result.Truncated = result.Matched > len(result.Entries)
+
+		summary := fmt.Sprintf("Found %d entries with %s since %s", result.Matched, args.TimeField, result.Since)
+		if result.Truncated {
+			summary += fmt.Sprintf(" (showing the newest %d)", args.MaxResults)
+		}
+
+		log.Printf("[TOOL COMPLETED] %s - %s", request.Params.Name, summary)
+
+		text := summary + "\n"
+		for _, e := range result.Entries {
+			text += fmt.Sprintf("%s  %s\n", e.time.UTC().Format(time.RFC3339), e.Path)
+		}
+		return mcp.NewToolResultStructured(result, text), nil
+	}
+}
+
+// recentCutoff returns the oldest time recent_files reports, from either a
+// duration before now or an RFC 3339 timestamp
+func recentCutoff(within, since string, now time.Time) (time.Time, error) {
+	switch {
+	case within != "" && since != "":
+		return time.Time{}, fmt.Errorf("within and since cannot be combined")
+	case within != "":
+		d, err := time.ParseDuration(within)
+		if err != nil || d <= 0 {
+			return time.Time{}, fmt.Errorf("invalid within %q: must be a positive duration such as 10m or 2h", within)
+		}
+		return now.Add(-d), nil
+	case since != "":
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid since %q: must be an RFC 3339 timestamp", since)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("one of within or since is required")
+}
+
+// recentHeap is a min-heap of entries by time, oldest on top, so the newest
+// entries can be kept without holding every match in memory
+type recentHeap []recentEntry
+
+func (h recentHeap) Len() int { return len(h) }
+func (h recentHeap) Less(i, j int) bool {
+	if !h[i].time.Equal(h[j].time) {
+		return h[i].time.Before(h[j].time)
+	}
+	return h[i].Path > h[j].Path
+}
+func (h recentHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *recentHeap) Push(x any)   { *h = append(*h, x.(recentEntry)) }
+func (h *recentHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// recentFilesOutputSchema describes recentResult for clients that validate structured content
+const recentFilesOutputSchema = `{
+	"type": "object",
+	"properties": {
+		"since": {"type": "string", "format": "date-time"},
+		"time_field": {"type": "string", "enum": ["mtime", "ctime"]},
+		"entries": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"path": {"type": "string"},
+					"type": {"type": "string", "enum": ["file", "dir", "symlink", "other"]},
+					"size": {"type": "integer"},
+					"mtime": {"type": "string", "format": "date-time"},
+					"ctime": {"type": "string", "format": "date-time"}
+				},
+				"required": ["path", "type", "size", "mtime"]
+			}
+		},
+		"matched": {"type": "integer"},
+		"truncated": {"type": "boolean"}
+	},
+	"required": ["since", "time_field", "entries", "matched", "truncated"]
+}`
+
+// recentFilesServerTool defines the recent_files tool
+func recentFilesServerTool(cfg *serverConfig) server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "recent_files",
+			Description: "Lists entries under the specified path modified within a time window or since a timestamp, newest first",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Directory to search (use '/' for root directory)",
+						"default":     "/",
+					},
+					"within": map[string]interface{}{
+						"type":        "string",
+						"description": "Window before now as a Go duration, e.g. '10m' or '2h' (one of within or since is required)",
+					},
+					"since": map[string]interface{}{
+						"type":        "string",
+						"description": "Report entries at or after this RFC 3339 timestamp, e.g. '2024-05-01T12:00:00Z'",
+					},
+					"time_field": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{timeFieldModified, timeFieldChanged},
+						"description": "Timestamp to filter and sort on: mtime (content modified) or ctime (inode changed, which also covers newly created, renamed and chmodded entries; Linux and macOS only)",
+						"default":     timeFieldModified,
+					},
+					"type": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{entryTypeFiles, entryTypeDirs, entryTypeSymlinks, entryTypeAll},
+						"description": "Entry types to report",
+						"default":     entryTypeFiles,
+					},
+					"max_depth": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum depth to descend below the path (omit for unlimited)",
+						"minimum":     0,
+					},
+					"include": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Only report entries whose root-relative path matches one of these patterns (e.g. '*.log')",
+					},
+					"exclude": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Skip entries (and directory subtrees) matching these patterns",
+					},
+					"pattern_syntax": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{patternSyntaxGlob, patternSyntaxRegex},
+						"description": "Syntax of include and exclude patterns",
+						"default":     patternSyntaxGlob,
+					},
+					"include_hidden": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Report dot-files and descend into dot-directories",
+						"default":     true,
+					},
+					"respect_ignore_files": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Skip entries matched by .gitignore/.ignore files and the server ignore file",
+						"default":     cfg.RespectIgnoreFiles,
+					},
+					"max_results": map[string]interface{}{
+						"type":        "integer",
+						"description": "Return at most this many entries, newest first",
+						"minimum":     1,
+						"default":     defaultMaxRecent,
+					},
+				},
+			},
+			RawOutputSchema: json.RawMessage(recentFilesOutputSchema),
+		},
+		Handler: recentFilesTool(cfg),
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRecentFilesTool(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	now := time.Now()
+	for name, age := range map[string]time.Duration{
+		"file1.txt":              time.Hour,
+		"subdir/file2.go":        5 * time.Minute,
+		"subdir/deep/file3.json": 30 * time.Second,
+	} {
+		mtime := now.Add(-age)
+		if err := os.Chtimes(filepath.Join(tempDir, filepath.FromSlash(name)), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := newServerConfig(tempDir)
+	cfg.PathStyle = pathStyleVirtual
+	handler := recentFilesTool(cfg)
+
+	result, err := callTool(handler, "recent_files", `{"within": "10m"}`)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	recent := result.StructuredContent.(recentResult)
+	if len(recent.Entries) != 2 || recent.Entries[0].Path != "/subdir/deep/file3.json" || recent.Entries[1].Path != "/subdir/file2.go" {
+		t.Fatalf("Expected the two newest files newest first, got %+v", recent.Entries)
+	}
+	if recent.Matched != 2 || recent.Truncated || recent.TimeField != "mtime" || recent.Entries[0].Type != "file" {
+		t.Errorf("Unexpected result %+v", recent)
+	}
+
+	// An explicit timestamp, a glob filter and a result cap
+	since := now.Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	result, err = callTool(handler, "recent_files", `{"since": "`+since+`", "exclude": ["*.json"], "max_results": 1}`)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	recent = result.StructuredContent.(recentResult)
+	if len(recent.Entries) != 1 || recent.Entries[0].Path != "/subdir/file2.go" || recent.Matched != 2 || !recent.Truncated {
+		t.Errorf("Expected the newest of two matches, got %+v", recent)
+	}
+
+	// Everything was just created, so the inode change time puts all files in the window
+	if runtime.GOOS == "linux" || runtime.GOOS == "darwin" {
+		result, err = callTool(handler, "recent_files", `{"within": "1m", "time_field": "ctime"}`)
+		if err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		if recent := result.StructuredContent.(recentResult); recent.Matched != 3 || recent.Entries[0].ChangeTime == "" {
+			t.Errorf("Expected all files by ctime, got %+v", recent)
+		}
+	}
+}
+
+func TestRecentFilesTool_Errors(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := recentFilesTool(newServerConfig(tempDir))
+
+	tests := []struct {
+		args string
+		want string
+	}{
+		{`{}`, "one of within or since is required"},
+		{`{"within": "1h", "since": "2024-01-01T00:00:00Z"}`, "cannot be combined"},
+		{`{"within": "yesterday"}`, "invalid within"},
+		{`{"within": "-5m"}`, "invalid within"},
+		{`{"since": "2024-01-01"}`, "invalid since"},
+		{`{"within": "1h", "time_field": "atime"}`, "invalid time_field"},
+		{`{"within": "1h", "max_results": 0}`, "max_results must be positive"},
+	}
+	for _, tt := range tests {
+		if _, err := callTool(handler, "recent_files", tt.args); err == nil || !contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.args, tt.want, err)
+		}
+	}
+}
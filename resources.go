@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// resourceURIPrefix starts the URI of every file and directory resource. The
+// rest of the URI is the root-relative path, so "file:///src/main.go" is the
+// same file tools call "/src/main.go".
+const resourceURIPrefix = "file:///"
+
+// fileResourceTemplate matches any path below the root; "+" lets the path
+// variable span several segments
+const fileResourceTemplate = resourceURIPrefix + "{+path}"
+
+// maxResourceBytes is the largest file resources/read returns; bigger files
+// can still be read in pieces with read_file
+const maxResourceBytes = 10 << 20
+
+// maxDirectoryResources caps how many directory listings resources/list advertises
+const maxDirectoryResources = 1000
+
+// directoryMIMEType is the type of directory listing resources
+const directoryMIMEType = "application/json"
+
+// directoryListing is the content of a directory resource
+type directoryListing struct {
+	Path    string           `json:"path"`
+	Entries []directoryEntry `json:"entries"`
+}
+
+// directoryEntry is a single child in a directory listing
+type directoryEntry struct {
+	Name string `json:"name"`
+	URI  string `json:"uri"`
+	Type string `json:"type"` // file, dir, symlink or other
+	Size int64  `json:"size"`
+}
+
+// registerResources exposes the served tree as resources: a template that
+// reads any file or directory, and a listing resource per directory so
+// clients can browse from resources/list
+func registerResources(mcpServer *server.MCPServer, cfg *serverConfig) error {
+	mcpServer.AddResourceTemplate(
+		mcp.NewResourceTemplate(fileResourceTemplate, "Files",
+			mcp.WithTemplateDescription("Any file or directory under the served root; directories read as a JSON listing"),
+		),
+		readResourceHandler(cfg),
+	)
+
+	resources, err := directoryResources(cfg)
+	if err != nil {
+		return err
+	}
+	mcpServer.AddResources(resources...)
+	log.Printf("Registered %d directory resources", len(resources))
+	return nil
+}
+
+// directoryResources returns a listing resource for each directory in the
+// tree, honoring the server's ignore rules, up to maxDirectoryResources
+func directoryResources(cfg *serverConfig) ([]server.ServerResource, error) {
+	dirs, err := resourceDirectories(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	formatter, _ := newPathFormatter(pathStyleVirtual, cfg.RootDir, ".")
+	handler := readResourceHandler(cfg)
+
+	resources := make([]server.ServerResource, 0, len(dirs))
+	for _, rel := range dirs {
+		resources = append(resources, server.ServerResource{
+			Resource: mcp.NewResource(resourceURI(rel, true), formatter.format(rel),
+				mcp.WithResourceDescription("Directory listing"),
+				mcp.WithMIMEType(directoryMIMEType),
+			),
+			Handler: server.ResourceHandlerFunc(handler),
+		})
+	}
+	return resources, nil
+}
+
+// resourceDirectories returns the root-relative paths of the directories
+// listed as resources, in walk order
+func resourceDirectories(cfg *serverConfig) ([]string, error) {
+	opts := defaultWalkOptions()
+	opts.Type = entryTypeDirs
+	opts.RespectIgnore = cfg.RespectIgnoreFiles
+	opts.Ignore = cfg.IgnoreRules
+
+	var dirs []string
+	err := walkTree(cfg.RootDir, cfg.RootDir, opts, func(entry walkEntry) error {
+		if len(dirs) == maxDirectoryResources {
+			log.Printf("Only the first %d directories are listed as resources", maxDirectoryResources)
+			return io.EOF
+		}
+		dirs = append(dirs, entry.Rel)
+		return nil
+	})
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to list directory resources: %w", err)
+	}
+	return dirs, nil
+}
+
+// resourceURI returns the URI of the entry at rel, a root-relative path.
+// Directory URIs end with a slash.
+func resourceURI(rel string, dir bool) string {
+	if rel == "." {
+		return resourceURIPrefix
+	}
+	segments := strings.Split(rel, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	uri := resourceURIPrefix + strings.Join(segments, "/")
+	if dir {
+		uri += "/"
+	}
+	return uri
+}
+
+// resourcePath maps a resource URI back to a tool input path
+func resourcePath(uri string) (string, error) {
+	if !strings.HasPrefix(uri, resourceURIPrefix) {
+		return "", fmt.Errorf("invalid resource URI %q: must start with %s", uri, resourceURIPrefix)
+	}
+	p, err := url.PathUnescape(strings.TrimPrefix(uri, resourceURIPrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid resource URI %q: %w", uri, err)
+	}
+	return "/" + p, nil
+}
+
+// readResourceHandler serves resources/read for file and directory URIs with
+// the same root containment rules as the tools
+func readResourceHandler(cfg *serverConfig) server.ResourceTemplateHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		uri := request.Params.URI
+		log.Printf("[RESOURCE READ] %s", uri)
+
+		input, err := resourcePath(uri)
+		if err != nil {
+			return nil, err
+		}
+
+		// Map the input path to actual filesystem path, enforcing root containment
+		target, err := resolvePath(cfg, input)
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := os.Stat(target)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("path does not exist: %s", input)
+			}
+			return nil, fmt.Errorf("failed to stat path: %w", err)
+		}
+
+		var contents mcp.ResourceContents
+		if info.IsDir() {
+			contents, err = readDirectoryResource(ctx, cfg, uri, target)
+		} else {
+			contents, err = readFileResource(uri, target, info)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		log.Printf("[RESOURCE COMPLETED] %s", uri)
+		return []mcp.ResourceContents{contents}, nil
+	}
+}
+
+// readDirectoryResource lists the immediate children of dir as JSON. Entries
+// hidden by the server's ignore rules are left out, as in resources/list.
+func readDirectoryResource(ctx context.Context, cfg *serverConfig, uri, dir string) (mcp.ResourceContents, error) {
+	opts := defaultWalkOptions()
+	opts.MaxDepth = 1
+	opts.RespectIgnore = cfg.RespectIgnoreFiles
+	opts.Ignore = cfg.IgnoreRules
+
+	formatter, _ := newPathFormatter(pathStyleVirtual, cfg.RootDir, ".")
+	listing := directoryListing{Path: formatter.format(relPath(cfg.RootDir, dir)), Entries: []directoryEntry{}}
+
+	err := walkTree(cfg.RootDir, dir, opts, func(entry walkEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.Depth == 0 {
+			return nil
+		}
+		child := directoryEntry{
+			Name: entry.Entry.Name(),
+			URI:  resourceURI(entry.Rel, entry.Entry.IsDir()),
+			Type: entryTypeName(entry.Entry.Type()),
+		}
+		if info, err := entry.Entry.Info(); err == nil {
+			child.Size = info.Size()
+		}
+		listing.Entries = append(listing.Entries, child)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(listing, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode directory listing: %w", err)
+	}
+	return mcp.TextResourceContents{URI: uri, MIMEType: directoryMIMEType, Text: string(data)}, nil
+}
+
+// readFileResource returns a file's contents as text, or as a base64 blob
+// when the file looks binary
+func readFileResource(uri, target string, info os.FileInfo) (mcp.ResourceContents, error) {
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("not a regular file: %s", uri)
+	}
+	if info.Size() > maxResourceBytes {
+		return nil, fmt.Errorf("file is %d bytes, larger than the %d byte resource limit; use read_file to read it in pieces", info.Size(), maxResourceBytes)
+	}
+
+	f, err := os.Open(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReaderSize(f, binarySniffLen)
+	sniff, _ := reader.Peek(binarySniffLen)
+	binary := isBinary(sniff)
+	mimeType := resourceMIMEType(filepath.Base(target), sniff, binary)
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxResourceBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if binary {
+		return mcp.BlobResourceContents{URI: uri, MIMEType: mimeType, Blob: base64.StdEncoding.EncodeToString(data)}, nil
+	}
+	return mcp.TextResourceContents{URI: uri, MIMEType: mimeType, Text: string(data)}, nil
+}
+
+// resourceMIMEType picks a MIME type from the file extension, falling back to
+// sniffing the content
+func resourceMIMEType(name string, sniff []byte, binary bool) string {
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return t
+	}
+	if !binary {
+		return "text/plain; charset=utf-8"
+	}
+	return http.DetectContentType(sniff)
+}
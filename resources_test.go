@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// newResourceTestServer returns an MCP server with the resources of cfg registered
+func newResourceTestServer(t *testing.T, cfg *serverConfig) *server.MCPServer {
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	if err := registerResources(mcpServer, cfg); err != nil {
+		t.Fatalf("Failed to register resources: %v", err)
+	}
+	return mcpServer
+}
+
+// rpc sends a JSON-RPC request to mcpServer and decodes the result into v
+func rpc(t *testing.T, mcpServer *server.MCPServer, method string, params any, v any) *mcp.JSONRPCError {
+	msg, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	switch resp := mcpServer.HandleMessage(context.Background(), msg).(type) {
+	case mcp.JSONRPCResponse:
+		data, _ := json.Marshal(resp.Result)
+		if err := json.Unmarshal(data, v); err != nil {
+			t.Fatalf("Failed to decode %s result: %v", method, err)
+		}
+		return nil
+	case mcp.JSONRPCError:
+		return &resp
+	default:
+		t.Fatalf("Unexpected %s response %#v", method, resp)
+		return nil
+	}
+}
+
+func TestResourceURI(t *testing.T) {
+	tests := []struct {
+		rel  string
+		dir  bool
+		want string
+	}{
+		{".", true, "file:///"},
+		{"subdir", true, "file:///subdir/"},
+		{"subdir/file2.go", false, "file:///subdir/file2.go"},
+		{"my docs/a#b.txt", false, "file:///my%20docs/a%23b.txt"},
+	}
+	for _, tt := range tests {
+		uri := resourceURI(tt.rel, tt.dir)
+		if uri != tt.want {
+			t.Errorf("resourceURI(%q) = %q, want %q", tt.rel, uri, tt.want)
+		}
+		if p, err := resourcePath(uri); err != nil || filepath.ToSlash(filepath.Clean(p)) != filepath.ToSlash(filepath.Clean("/"+tt.rel)) {
+			t.Errorf("resourcePath(%q) = %q, %v", uri, p, err)
+		}
+	}
+}
+
+func TestResources_List(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	mcpServer := newResourceTestServer(t, newServerConfig(tempDir))
+
+	var list mcp.ListResourcesResult
+	if err := rpc(t, mcpServer, "resources/list", map[string]any{}, &list); err != nil {
+		t.Fatalf("resources/list failed: %+v", err)
+	}
+	uris := make(map[string]string)
+	for _, r := range list.Resources {
+		uris[r.URI] = r.MIMEType
+	}
+	for _, want := range []string{"file:///", "file:///emptydir/", "file:///subdir/", "file:///subdir/deep/"} {
+		if uris[want] != directoryMIMEType {
+			t.Errorf("Expected directory resource %s, got %v", want, uris)
+		}
+	}
+	if len(uris) != 4 {
+		t.Errorf("Expected only directory resources, got %v", uris)
+	}
+
+	var templates mcp.ListResourceTemplatesResult
+	if err := rpc(t, mcpServer, "resources/templates/list", map[string]any{}, &templates); err != nil {
+		t.Fatalf("resources/templates/list failed: %+v", err)
+	}
+	if len(templates.ResourceTemplates) != 1 || templates.ResourceTemplates[0].URITemplate.Raw() != fileResourceTemplate {
+		t.Errorf("Expected the file template, got %+v", templates.ResourceTemplates)
+	}
+}
+
+func TestResources_Read(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	if err := os.WriteFile(filepath.Join(tempDir, "subdir", "image.png"), png, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mcpServer := newResourceTestServer(t, newServerConfig(tempDir))
+
+	read := func(uri string) (map[string]any, *mcp.JSONRPCError) {
+		var result struct {
+			Contents []map[string]any `json:"contents"`
+		}
+		if err := rpc(t, mcpServer, "resources/read", map[string]any{"uri": uri}, &result); err != nil {
+			return nil, err
+		}
+		if len(result.Contents) != 1 {
+			t.Fatalf("Expected one content item for %s, got %+v", uri, result.Contents)
+		}
+		return result.Contents[0], nil
+	}
+
+	// Text file through the template
+	contents, rpcErr := read("file:///subdir/deep/file3.json")
+	if rpcErr != nil {
+		t.Fatalf("Read failed: %+v", rpcErr)
+	}
+	if contents["text"] != `{"test": true}` || contents["mimeType"] != "application/json" {
+		t.Errorf("Unexpected text contents %+v", contents)
+	}
+
+	// Binary file as a base64 blob
+	contents, rpcErr = read("file:///subdir/image.png")
+	if rpcErr != nil {
+		t.Fatalf("Read failed: %+v", rpcErr)
+	}
+	blob, _ := base64.StdEncoding.DecodeString(contents["blob"].(string))
+	if string(blob) != string(png) || contents["mimeType"] != "image/png" || contents["text"] != nil {
+		t.Errorf("Unexpected blob contents %+v", contents)
+	}
+
+	// Directory listing resource
+	contents, rpcErr = read("file:///subdir/")
+	if rpcErr != nil {
+		t.Fatalf("Read failed: %+v", rpcErr)
+	}
+	var listing directoryListing
+	if err := json.Unmarshal([]byte(contents["text"].(string)), &listing); err != nil {
+		t.Fatalf("Failed to decode listing: %v", err)
+	}
+	if listing.Path != "/subdir" || len(listing.Entries) != 3 {
+		t.Fatalf("Unexpected listing %+v", listing)
+	}
+	if e := listing.Entries[0]; e.Name != "deep" || e.Type != "dir" || e.URI != "file:///subdir/deep/" {
+		t.Errorf("Unexpected directory entry %+v", e)
+	}
+	if e := listing.Entries[1]; e.Name != "file2.go" || e.Type != "file" || e.Size != 12 || e.URI != "file:///subdir/file2.go" {
+		t.Errorf("Unexpected file entry %+v", e)
+	}
+
+	// Containment and missing paths
+	if _, rpcErr := read("file:///../etc/passwd"); rpcErr == nil || !contains(rpcErr.Error.Message, "outside root directory") {
+		t.Errorf("Expected containment error, got %+v", rpcErr)
+	}
+	if _, rpcErr := read("file:///missing.txt"); rpcErr == nil || !contains(rpcErr.Error.Message, "does not exist") {
+		t.Errorf("Expected missing path error, got %+v", rpcErr)
+	}
+}
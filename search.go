@@ -0,0 +1,432 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultMaxMatches caps search_files results when the caller sets no limit
+const defaultMaxMatches = 200
+
+// maxSnippetLen caps how much of a matching or context line is returned
+const maxSnippetLen = 500
+
+// searchMatch is a single matching line returned by search_files
+type searchMatch struct {
+	Path    string   `json:"path"`
+	Line    int      `json:"line"`   // 1-based line number
+	Column  int      `json:"column"` // 1-based byte column of the first match on the line
+	Snippet string   `json:"snippet"`
+	Before  []string `json:"before,omitempty"` // context lines preceding the match
+	After   []string `json:"after,omitempty"`  // context lines following the match
+}
+
+// searchResult is the structured content returned by search_files
+type searchResult struct {
+	Matches      []searchMatch `json:"matches"`
+	FilesScanned int           `json:"files_scanned"` // files read, up to the one that reached the cap
+	Truncated    bool          `json:"truncated"`     // max_matches was reached
+}
+
+// searchJob is a file queued for scanning, with its position in walk order
+type searchJob struct {
+	index int
+	entry walkEntry
+}
+
+// searchFileMatches holds the matches found in one file
+type searchFileMatches struct {
+	index   int
+	matches []searchMatch
+	failed  bool // the file could not be read, or its scan was cancelled
+}
+
+// searchFilesTool implements the search_files tool handler
+func searchFilesTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		// Parse the arguments
+		var args struct {
+			Path          string   `json:"path"`
+			Pattern       string   `json:"pattern"`
+			Literal       bool     `json:"literal"`
+			CaseSensitive bool     `json:"case_sensitive"`
+			Before        int      `json:"context_before"`
+			After         int      `json:"context_after"`
+			Include       []string `json:"include"`
+			Exclude       []string `json:"exclude"`
+			MaxMatches    int      `json:"max_matches"`
+			IncludeHidden bool     `json:"include_hidden"`
+			RespectIgnore bool     `json:"respect_ignore_files"`
+		}
+		args.Path = "/"
+		args.CaseSensitive = true
+		args.MaxMatches = defaultMaxMatches
+		args.IncludeHidden = true
+		args.RespectIgnore = cfg.RespectIgnoreFiles
+
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+
+		if args.Pattern == "" {
+			return nil, fmt.Errorf("pattern is required")
+		}
+		if args.Before < 0 || args.After < 0 || args.MaxMatches <= 0 {
+			return nil, fmt.Errorf("context lines must not be negative and max_matches must be positive")
+		}
+
+		re, err := compileSearchPattern(args.Pattern, args.Literal, args.CaseSensitive)
+		if err != nil {
+			return nil, err
+		}
+
+		opts := defaultWalkOptions()
+		opts.Type = entryTypeFiles
+		opts.IncludeHidden = args.IncludeHidden
+		opts.RespectIgnore = args.RespectIgnore
+		opts.Ignore = cfg.IgnoreRules
+
+		if opts.Include, err = newPathMatcher(args.Include, patternSyntaxGlob); err != nil {
+			return nil, fmt.Errorf("invalid include: %w", err)
+		}
+		if opts.Exclude, err = newPathMatcher(args.Exclude, patternSyntaxGlob); err != nil {
+			return nil, fmt.Errorf("invalid exclude: %w", err)
+		}
+
+		// Map the input path to actual filesystem path, enforcing root containment
+		target, err := resolvePath(cfg, args.Path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Lstat(target); os.IsNotExist(err) {
+			return nil, fmt.Errorf("path does not exist: %s", args.Path)
+		}
+
+		formatter, _ := newPathFormatter(cfg.PathStyle, cfg.RootDir, relPath(cfg.RootDir, target))
+		scanner := fileScanner{re: re, before: args.Before, after: args.After, outPath: formatter.format}
+
+		result, err := searchTree(ctx, cfg.RootDir, target, opts, scanner, args.MaxMatches)
+		if err != nil {
+			return nil, err
+		}
+
+		summary := fmt.Sprintf("Found %d matches in %d files scanned", len(result.Matches), result.FilesScanned)
+		if result.Truncated {
+			summary += fmt.Sprintf(" (stopped at max_matches=%d)", args.MaxMatches)
+		}
+
+		log.Printf("[TOOL COMPLETED] %s - %s", request.Params.Name, summary)
+
+		return mcp.NewToolResultStructured(result, summary), nil
+	}
+}
+
+// compileSearchPattern builds the matcher for a search pattern
+func compileSearchPattern(pattern string, literal, caseSensitive bool) (*regexp.Regexp, error) {
+	if literal {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if !caseSensitive {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return re, nil
+}
+
+// searchTree scans every file walkTree reports using a bounded pool of
+// workers. Matches are returned in walk order and capped at maxMatches: the
+// cap is applied to the files in walk order, so the result does not depend on
+// which worker finished first.
+func searchTree(ctx context.Context, rootDir, target string, opts walkOptions, scanner fileScanner, maxMatches int) (searchResult, error) {
+	searchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan searchJob)
+	found := make(chan searchFileMatches)
+
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				matches, err := scanner.scan(searchCtx, job.entry)
+				if err != nil && !errors.Is(err, context.Canceled) {
+					log.Printf("Failed to search %s: %v", job.entry.Path, err)
+				}
+				// Every file is reported, so the collector can tell when a prefix of the walk is complete
+				found <- searchFileMatches{index: job.index, matches: matches, failed: err != nil}
+			}
+		}()
+	}
+
+	// Feed files from the traversal to the workers
+	var walkErr error
+	go func() {
+		defer close(jobs)
+		index := 0
+		walkErr = walkTree(rootDir, target, opts, func(entry walkEntry) error {
+			select {
+			case jobs <- searchJob{index: index, entry: entry}:
+				index++
+				return nil
+			case <-searchCtx.Done():
+				return searchCtx.Err()
+			}
+		})
+	}()
+
+	go func() {
+		wg.Wait()
+		close(found)
+	}()
+
+	// Collect files in walk order. Once the files up to some index hold
+	// maxMatches, that index is the cutoff: every earlier file has already
+	// been scanned, and later ones are cancelled and discarded.
+	result := searchResult{Matches: []searchMatch{}}
+	waiting := make(map[int]searchFileMatches)
+	next, cutoff := 0, -1
+	for fm := range found {
+		if cutoff >= 0 {
+			continue
+		}
+		waiting[fm.index] = fm
+		for fm, ok := waiting[next]; ok && cutoff < 0; fm, ok = waiting[next] {
+			delete(waiting, next)
+			if !fm.failed {
+				result.FilesScanned++
+				result.Matches = append(result.Matches, fm.matches...)
+			}
+			if len(result.Matches) >= maxMatches {
+				cutoff = next
+				cancel()
+			}
+			next++
+		}
+	}
+
+	// found is closed only after the walk goroutine has closed jobs, so walkErr is settled
+	if err := ctx.Err(); err != nil {
+		return searchResult{}, err
+	}
+	if walkErr != nil && !errors.Is(walkErr, context.Canceled) {
+		return searchResult{}, fmt.Errorf("failed to walk directory: %w", walkErr)
+	}
+
+	if len(result.Matches) >= maxMatches {
+		result.Truncated = true
+		result.Matches = result.Matches[:maxMatches]
+	}
+	return result, nil
+}
+
+// fileScanner finds matching lines, with context, in a single file
+type fileScanner struct {
+	re      *regexp.Regexp
+	before  int
+	after   int
+	outPath func(rel string) string
+}
+
+// scan returns the matches in entry's file. Binary files yield no matches;
+// a cancelled scan returns ctx's error rather than a partial list.
+func (s fileScanner) scan(ctx context.Context, entry walkEntry) ([]searchMatch, error) {
+	f, err := os.Open(entry.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	sniff, _ := reader.Peek(binarySniffLen)
+	if isBinary(sniff) {
+		return nil, nil
+	}
+
+	var matches []searchMatch
+	var previous []string // ring of up to s.before preceding lines
+	var pending []int     // indexes of matches still collecting after-context
+	outPath := s.outPath(entry.Rel)
+
+	for line := 1; ; line++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		data, err := reader.ReadString('\n')
+		if data == "" && err != nil {
+			if err == io.EOF {
+				return matches, nil
+			}
+			return matches, err
+		}
+		text := truncateSnippet(strings.TrimRight(data, "\r\n"))
+
+		// Feed after-context to earlier matches
+		remaining := pending[:0]
+		for _, i := range pending {
+			matches[i].After = append(matches[i].After, text)
+			if len(matches[i].After) < s.after {
+				remaining = append(remaining, i)
+			}
+		}
+		pending = remaining
+
+		if loc := s.re.FindStringIndex(data); loc != nil {
+			matches = append(matches, searchMatch{
+				Path:    outPath,
+				Line:    line,
+				Column:  loc[0] + 1,
+				Snippet: text,
+				Before:  append([]string(nil), previous...),
+			})
+			if s.after > 0 {
+				pending = append(pending, len(matches)-1)
+			}
+		}
+
+		if s.before > 0 {
+			previous = append(previous, text)
+			if len(previous) > s.before {
+				previous = previous[1:]
+			}
+		}
+
+		if err == io.EOF {
+			return matches, nil
+		}
+	}
+}
+
+// truncateSnippet shortens very long lines (e.g. minified files) in results,
+// backing off to a character boundary so no UTF-8 sequence is split
+func truncateSnippet(line string) string {
+	if len(line) <= maxSnippetLen {
+		return line
+	}
+	n := maxSnippetLen
+	for n > 0 && !utf8.RuneStart(line[n]) {
+		n--
+	}
+	return line[:n] + "..."
+}
+
+// searchFilesOutputSchema describes searchResult for clients that validate structured content
+const searchFilesOutputSchema = `{
+	"type": "object",
+	"properties": {
+		"matches": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"path": {"type": "string"},
+					"line": {"type": "integer"},
+					"column": {"type": "integer"},
+					"snippet": {"type": "string"},
+					"before": {"type": "array", "items": {"type": "string"}},
+					"after": {"type": "array", "items": {"type": "string"}}
+				},
+				"required": ["path", "line", "column", "snippet"]
+			}
+		},
+		"files_scanned": {"type": "integer"},
+		"truncated": {"type": "boolean"}
+	},
+	"required": ["matches", "files_scanned", "truncated"]
+}`
+
+// searchFilesServerTool defines the search_files tool
+func searchFilesServerTool(cfg *serverConfig) server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "search_files",
+			Description: "Searches file contents under the specified path for a regex or literal pattern",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Directory or file to search (use '/' for root directory)",
+						"default":     "/",
+					},
+					"pattern": map[string]interface{}{
+						"type":        "string",
+						"description": "Go regular expression to search for (or plain text when literal is true)",
+					},
+					"literal": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Treat pattern as plain text instead of a regular expression",
+						"default":     false,
+					},
+					"case_sensitive": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Match case exactly",
+						"default":     true,
+					},
+					"context_before": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of lines to include before each match",
+						"minimum":     0,
+					},
+					"context_after": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of lines to include after each match",
+						"minimum":     0,
+					},
+					"include": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Only search files whose root-relative path matches one of these globs (e.g. '*.go')",
+					},
+					"exclude": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Skip files and directories matching these globs (e.g. 'vendor')",
+					},
+					"max_matches": map[string]interface{}{
+						"type":        "integer",
+						"description": "Stop after this many matching lines",
+						"minimum":     1,
+						"default":     defaultMaxMatches,
+					},
+					"include_hidden": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Search dot-files and dot-directories",
+						"default":     true,
+					},
+					"respect_ignore_files": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Skip files matched by .gitignore/.ignore files and the server ignore file",
+						"default":     cfg.RespectIgnoreFiles,
+					},
+				},
+				Required: []string{"pattern"},
+			},
+			RawOutputSchema: json.RawMessage(searchFilesOutputSchema),
+		},
+		Handler: searchFilesTool(cfg),
+	}
+}
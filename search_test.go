@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// searchFiles calls search_files and returns its structured result
+func searchFiles(t *testing.T, cfg *serverConfig, arguments string) searchResult {
+	t.Helper()
+
+	result, err := callTool(searchFilesTool(cfg), "search_files", arguments)
+	if err != nil {
+		t.Fatalf("Handler returned error for %s: %v", arguments, err)
+	}
+
+	search, ok := result.StructuredContent.(searchResult)
+	if !ok {
+		t.Fatalf("StructuredContent is not searchResult, got %T", result.StructuredContent)
+	}
+	return search
+}
+
+func TestSearchFilesTool_RegexWithContext(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	writeTestFile(t, filepath.Join(tempDir, "src", "app.go"), "package app\n\nfunc Run() {\n\treturn\n}\n")
+
+	cfg := newServerConfig(tempDir)
+	cfg.PathStyle = pathStyleVirtual
+
+	search := searchFiles(t, cfg, `{"pattern": "func \\w+", "context_before": 1, "context_after": 2}`)
+	if len(search.Matches) != 1 {
+		t.Fatalf("Expected 1 match, got %+v", search.Matches)
+	}
+
+	m := search.Matches[0]
+	if m.Path != "/src/app.go" || m.Line != 3 || m.Column != 1 || m.Snippet != "func Run() {" {
+		t.Errorf("Unexpected match %+v", m)
+	}
+	if len(m.Before) != 1 || m.Before[0] != "" {
+		t.Errorf("Unexpected before context %q", m.Before)
+	}
+	if len(m.After) != 2 || m.After[0] != "\treturn" || m.After[1] != "}" {
+		t.Errorf("Unexpected after context %q", m.After)
+	}
+}
+
+func TestSearchFilesTool_LiteralCaseInsensitive(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	writeTestFile(t, filepath.Join(tempDir, "notes.txt"), "a+b\nA+B\nab\n")
+
+	search := searchFiles(t, newServerConfig(tempDir), `{"pattern": "a+b", "literal": true, "case_sensitive": false}`)
+	if len(search.Matches) != 2 {
+		t.Fatalf("Expected 2 literal matches, got %+v", search.Matches)
+	}
+}
+
+func TestSearchFilesTool_FiltersAndBinary(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	writeTestFile(t, filepath.Join(tempDir, "vendor", "lib.go"), "package main")
+	writeTestFile(t, filepath.Join(tempDir, "ignored", "gen.go"), "package main")
+	writeTestFile(t, filepath.Join(tempDir, ".gitignore"), "ignored/\n")
+	if err := os.WriteFile(filepath.Join(tempDir, "blob.go"), []byte("package main\x00"), 0644); err != nil {
+		t.Fatalf("Failed to write binary file: %v", err)
+	}
+
+	search := searchFiles(t, newServerConfig(tempDir), `{"pattern": "package", "include": ["*.go"], "exclude": ["vendor"]}`)
+	if len(search.Matches) != 1 || !contains(search.Matches[0].Path, "subdir/file2.go") {
+		t.Errorf("Expected only subdir/file2.go to match, got %+v", search.Matches)
+	}
+}
+
+func TestSearchFilesTool_MaxMatches(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	for i := 0; i < 20; i++ {
+		writeTestFile(t, filepath.Join(tempDir, "many", fmt.Sprintf("f%02d.txt", i)), "hit\nhit\n")
+	}
+
+	cfg := newServerConfig(tempDir)
+	cfg.PathStyle = pathStyleVirtual
+
+	// The result is the first matches in walk order regardless of which
+	// worker finished first, so it is the same on every run
+	want := []string{"/many/f00.txt:1", "/many/f00.txt:2", "/many/f01.txt:1", "/many/f01.txt:2", "/many/f02.txt:1"}
+	for run := 0; run < 20; run++ {
+		search := searchFiles(t, cfg, `{"path": "/many", "pattern": "hit", "max_matches": 5}`)
+		if len(search.Matches) != 5 || !search.Truncated {
+			t.Fatalf("Expected 5 matches and truncated, got %d (truncated=%v)", len(search.Matches), search.Truncated)
+		}
+		for i, m := range search.Matches {
+			if got := fmt.Sprintf("%s:%d", m.Path, m.Line); got != want[i] {
+				t.Fatalf("Run %d: expected match %d to be %s, got %s", run, i, want[i], got)
+			}
+		}
+		if search.FilesScanned != 3 {
+			t.Fatalf("Run %d: expected 3 files scanned up to the cutoff, got %d", run, search.FilesScanned)
+		}
+	}
+}
+
+func TestTruncateSnippet(t *testing.T) {
+	short := strings.Repeat("a", maxSnippetLen)
+	if got := truncateSnippet(short); got != short {
+		t.Errorf("Expected a line of the maximum length to be kept, got %d bytes", len(got))
+	}
+
+	// A three-byte character straddling the limit is dropped whole
+	line := strings.Repeat("a", maxSnippetLen-1) + "€" + "tail"
+	got := truncateSnippet(line)
+	if want := strings.Repeat("a", maxSnippetLen-1) + "..."; got != want {
+		t.Errorf("Expected the cut before the split character, got %q", got[maxSnippetLen-5:])
+	}
+	if !utf8.ValidString(got) {
+		t.Error("Expected a valid UTF-8 snippet")
+	}
+}
+
+func TestSearchFilesTool_Errors(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := searchFilesTool(newServerConfig(tempDir))
+
+	tests := []struct {
+		arguments string
+		errText   string
+	}{
+		{`{}`, "pattern is required"},
+		{`{"pattern": "("}`, "invalid pattern"},
+		{`{"pattern": "x", "path": "/../.."}`, "outside root directory"},
+		{`{"pattern": "x", "path": "/missing"}`, "does not exist"},
+	}
+
+	for _, tt := range tests {
+		_, err := callTool(handler, "search_files", tt.arguments)
+		if err == nil {
+			t.Fatalf("Expected error for %s, but got none", tt.arguments)
+		}
+		if !contains(err.Error(), tt.errText) {
+			t.Errorf("Expected '%s' error for %s, got: %v", tt.errText, tt.arguments, err)
+		}
+	}
+}
+
+func TestSearchTree_Cancelled(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	re, _ := compileSearchPattern("test", false, true)
+	scanner := fileScanner{re: re, outPath: func(rel string) string { return rel }}
+
+	if _, err := searchTree(ctx, tempDir, tempDir, defaultWalkOptions(), scanner, 10); err == nil {
+		t.Error("Expected error for cancelled context")
+	}
+}
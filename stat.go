@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"strconv"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// statResult is the structured content returned by stat_path. Fields the
+// platform cannot provide are left out.
+type statResult struct {
+	Path          string  `json:"path"`
+	Type          string  `json:"type"` // file, dir, symlink or other
+	Size          int64   `json:"size"`
+	Mode          string  `json:"mode"`        // octal permission bits, e.g. "0644"
+	Permissions   string  `json:"permissions"` // symbolic, e.g. "-rw-r--r--"
+	UID           *uint32 `json:"uid,omitempty"`
+	GID           *uint32 `json:"gid,omitempty"`
+	Owner         string  `json:"owner,omitempty"`
+	Group         string  `json:"group,omitempty"`
+	ModTime       string  `json:"mtime"`
+	ChangeTime    string  `json:"ctime,omitempty"`
+	AccessTime    string  `json:"atime,omitempty"`
+	Inode         *uint64 `json:"inode,omitempty"`
+	Links         *uint64 `json:"nlink,omitempty"`
+	SymlinkTarget string  `json:"symlink_target,omitempty"`
+	ChildCount    *int    `json:"child_count,omitempty"` // immediate children, for directories
+}
+
+// sysStat holds the platform-specific parts of a file's metadata
+type sysStat struct {
+	UID, GID     uint32
+	Device       uint64
+	Inode, Links uint64
+	Blocks       int64 // 512-byte blocks allocated on disk
+	AccessTime   time.Time
+	ChangeTime   time.Time
+}
+
+// statPathTool implements the stat_path tool handler
+func statPathTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		// Parse the arguments
+		var args struct {
+			Path string `json:"path"`
+		}
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+		if args.Path == "" {
+			return nil, fmt.Errorf("path is required")
+		}
+
+		// Map the input path to actual filesystem path, enforcing root containment
+		target, err := resolvePath(cfg, args.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		// A missing path is an answer, not a failure of the tool
+		info, err := os.Lstat(target)
+		if os.IsNotExist(err) {
+			log.Printf("[TOOL COMPLETED] %s - %s does not exist", request.Params.Name, args.Path)
+			return mcp.NewToolResultError(fmt.Sprintf("path does not exist: %s", args.Path)), nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat path: %w", err)
+		}
+
+		formatter, _ := newPathFormatter(cfg.PathStyle, cfg.RootDir, ".")
+		result := newStatResult(formatter.format(relPath(cfg.RootDir, target)), target, info)
+
+		log.Printf("[TOOL COMPLETED] %s - %s is a %s", request.Params.Name, args.Path, result.Type)
+
+		return mcp.NewToolResultStructured(result, formatStat(result)), nil
+	}
+}
+
+// newStatResult collects the metadata of the entry at target
+func newStatResult(outPath, target string, info os.FileInfo) statResult {
+	result := statResult{
+		Path:        outPath,
+		Type:        entryTypeName(info.Mode()),
+		Size:        info.Size(),
+		Mode:        fmt.Sprintf("%04o", info.Mode().Perm()),
+		Permissions: info.Mode().String(),
+		ModTime:     info.ModTime().UTC().Format(time.RFC3339),
+	}
+
+	if sys, ok := platformStat(info); ok {
+		result.UID, result.GID = &sys.UID, &sys.GID
+		result.Inode, result.Links = &sys.Inode, &sys.Links
+		result.AccessTime = sys.AccessTime.UTC().Format(time.RFC3339)
+		result.ChangeTime = sys.ChangeTime.UTC().Format(time.RFC3339)
+
+		// Names are best effort; ids without an account are common in containers
+		if u, err := user.LookupId(strconv.FormatUint(uint64(sys.UID), 10)); err == nil {
+			result.Owner = u.Username
+		}
+		if g, err := user.LookupGroupId(strconv.FormatUint(uint64(sys.GID), 10)); err == nil {
+			result.Group = g.Name
+		}
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err := os.Readlink(target); err == nil {
+			result.SymlinkTarget = link
+		}
+	}
+	if info.IsDir() {
+		if n, err := countChildren(target); err == nil {
+			result.ChildCount = &n
+		}
+	}
+	return result
+}
+
+// formatStat renders a stat result as text, in the spirit of stat(1)
+func formatStat(r statResult) string {
+	text := fmt.Sprintf("%s: %s, %d bytes, %s (%s)\n", r.Path, r.Type, r.Size, r.Permissions, r.Mode)
+	if r.UID != nil {
+		text += fmt.Sprintf("owner %s (%d), group %s (%d), inode %d, %d links\n", r.Owner, *r.UID, r.Group, *r.GID, *r.Inode, *r.Links)
+	}
+	text += fmt.Sprintf("modified %s", r.ModTime)
+	if r.ChangeTime != "" {
+		text += fmt.Sprintf(", changed %s, accessed %s", r.ChangeTime, r.AccessTime)
+	}
+	text += "\n"
+	if r.SymlinkTarget != "" {
+		text += fmt.Sprintf("-> %s\n", r.SymlinkTarget)
+	}
+	if r.ChildCount != nil {
+		text += fmt.Sprintf("%d children\n", *r.ChildCount)
+	}
+	return text
+}
+
+// statPathOutputSchema describes statResult for clients that validate structured content
+const statPathOutputSchema = `{
+	"type": "object",
+	"properties": {
+		"path": {"type": "string"},
+		"type": {"type": "string", "enum": ["file", "dir", "symlink", "other"]},
+		"size": {"type": "integer"},
+		"mode": {"type": "string", "description": "Octal permission bits"},
+		"permissions": {"type": "string", "description": "Symbolic mode, as shown by ls -l"},
+		"uid": {"type": "integer"},
+		"gid": {"type": "integer"},
+		"owner": {"type": "string"},
+		"group": {"type": "string"},
+		"mtime": {"type": "string", "format": "date-time"},
+		"ctime": {"type": "string", "format": "date-time"},
+		"atime": {"type": "string", "format": "date-time"},
+		"inode": {"type": "integer"},
+		"nlink": {"type": "integer"},
+		"symlink_target": {"type": "string"},
+		"child_count": {"type": "integer"}
+	},
+	"required": ["path", "type", "size", "mode", "permissions", "mtime"]
+}`
+
+// statPathServerTool defines the stat_path tool
+func statPathServerTool(cfg *serverConfig) server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "stat_path",
+			Description: "Reports whether a path exists and its metadata: type, size, permissions, owner, times, inode and link count",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Path to inspect (relative to the root); symlinks are described, not followed",
+					},
+				},
+				Required: []string{"path"},
+			},
+			RawOutputSchema: json.RawMessage(statPathOutputSchema),
+		},
+		Handler: statPathTool(cfg),
+	}
+}
@@ -0,0 +1,28 @@
+//go:build darwin
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// platformStat extracts ownership, inode, allocation and change/access times
+// from info
+func platformStat(info os.FileInfo) (sysStat, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return sysStat{}, false
+	}
+	return sysStat{
+		UID:        st.Uid,
+		GID:        st.Gid,
+		Device:     uint64(st.Dev),
+		Inode:      st.Ino,
+		Links:      uint64(st.Nlink),
+		Blocks:     st.Blocks,
+		AccessTime: time.Unix(st.Atimespec.Sec, st.Atimespec.Nsec),
+		ChangeTime: time.Unix(st.Ctimespec.Sec, st.Ctimespec.Nsec),
+	}, true
+}
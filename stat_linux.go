@@ -0,0 +1,28 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// platformStat extracts ownership, inode, allocation and change/access times
+// from info
+func platformStat(info os.FileInfo) (sysStat, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return sysStat{}, false
+	}
+	return sysStat{
+		UID:        st.Uid,
+		GID:        st.Gid,
+		Device:     uint64(st.Dev),
+		Inode:      st.Ino,
+		Links:      uint64(st.Nlink),
+		Blocks:     st.Blocks,
+		AccessTime: time.Unix(int64(st.Atim.Sec), int64(st.Atim.Nsec)),
+		ChangeTime: time.Unix(int64(st.Ctim.Sec), int64(st.Ctim.Nsec)),
+	}, true
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package main
+
+import "os"
+
+// platformStat reports that ownership, inode, allocation and change/access
+// times are not available on this platform
+func platformStat(info os.FileInfo) (sysStat, bool) {
+	return sysStat{}, false
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestStatPathTool(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	if err := os.Chmod(filepath.Join(tempDir, "file1.txt"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("file1.txt", filepath.Join(tempDir, "link")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	cfg := newServerConfig(tempDir)
+	cfg.PathStyle = pathStyleVirtual
+	handler := statPathTool(cfg)
+
+	result, err := callTool(handler, "stat_path", `{"path": "/file1.txt"}`)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	st := result.StructuredContent.(statResult)
+	if st.Path != "/file1.txt" || st.Type != "file" || st.Size != 12 || st.Mode != "0640" || st.Permissions != "-rw-r-----" {
+		t.Errorf("Unexpected file stat %+v", st)
+	}
+	if runtime.GOOS == "linux" || runtime.GOOS == "darwin" {
+		if st.UID == nil || *st.UID != uint32(os.Getuid()) || st.Inode == nil || st.Links == nil || *st.Links != 1 || st.ChangeTime == "" {
+			t.Errorf("Expected platform metadata, got %+v", st)
+		}
+	}
+
+	result, _ = callTool(handler, "stat_path", `{"path": "/subdir"}`)
+	if st := result.StructuredContent.(statResult); st.Type != "dir" || st.ChildCount == nil || *st.ChildCount != 2 {
+		t.Errorf("Unexpected directory stat %+v", st)
+	}
+
+	result, _ = callTool(handler, "stat_path", `{"path": "/link"}`)
+	if st := result.StructuredContent.(statResult); st.Type != "symlink" || st.SymlinkTarget != "file1.txt" {
+		t.Errorf("Unexpected symlink stat %+v", st)
+	}
+}
+
+func TestStatPathTool_Missing(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := statPathTool(newServerConfig(tempDir))
+
+	// A missing path is reported as an MCP error result, not a Go error
+	result, err := callTool(handler, "stat_path", `{"path": "/missing.txt"}`)
+	if err != nil {
+		t.Fatalf("Expected an error result, got Go error %v", err)
+	}
+	if !result.IsError || !contains(result.Content[0].(mcp.TextContent).Text, "does not exist") {
+		t.Errorf("Expected 'does not exist' error result, got %+v", result)
+	}
+
+	// Containment violations remain errors
+	if _, err := callTool(handler, "stat_path", `{"path": "/../.."}`); err == nil || !contains(err.Error(), "outside root directory") {
+		t.Errorf("Expected containment error, got %v", err)
+	}
+}
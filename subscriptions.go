@@ -0,0 +1,517 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Subscription methods; mcp-go advertises the capability but does not route them
+const (
+	methodResourcesSubscribe   = "resources/subscribe"
+	methodResourcesUnsubscribe = "resources/unsubscribe"
+)
+
+// stdioSessionID is the session ID mcp-go gives the single stdio client
+const stdioSessionID = "stdio"
+
+// watchDebounce is how long changes are collected before subscribers are
+// notified, so a burst of writes to a file sends a single update
+const watchDebounce = 200 * time.Millisecond
+
+// maxWatchedDirectories caps the directories the watcher asks the OS to
+// watch, which are a limited per-user resource on Linux
+const maxWatchedDirectories = 8192
+
+// subscription is a resource a session asked to be told about
+type subscription struct {
+	rel  string // root-relative path of the resource
+	path string // absolute filesystem path
+	dir  bool   // changes anywhere below path are reported
+}
+
+// resourceWatcher watches the served tree and sends
+// notifications/resources/updated to sessions subscribed to a changed file or
+// to one of its ancestor directories, and notifications/resources/list_changed
+// when directories listed by resources/list come or go. Changes are collected
+// for the debounce interval before anything is sent.
+type resourceWatcher struct {
+	cfg       *serverConfig
+	debounce  time.Duration
+	mcpServer *server.MCPServer
+	fsw       *fsnotify.Watcher
+
+	mu            sync.Mutex
+	sessions      map[string]bool                    // IDs of the sessions registered with the server
+	subscriptions map[string]map[string]subscription // session ID -> URI -> subscription
+	watched       map[string]bool                    // watched directories -> new subdirectories are watched too
+	listed        map[string]bool                    // directory URIs advertised in resources/list
+	pending       map[string]map[string]bool         // session ID -> changed URIs not yet sent
+	listChanged   bool                               // a directory was created or removed since the last flush
+	timer         *time.Timer                        // runs the next flush; nil when nothing is pending
+}
+
+// newResourceWatcher returns a watcher for cfg's root. It does nothing until
+// start is called.
+func newResourceWatcher(cfg *serverConfig, debounce time.Duration) *resourceWatcher {
+	return &resourceWatcher{
+		cfg:           cfg,
+		debounce:      debounce,
+		sessions:      make(map[string]bool),
+		subscriptions: make(map[string]map[string]subscription),
+		watched:       make(map[string]bool),
+		listed:        make(map[string]bool),
+		pending:       make(map[string]map[string]bool),
+	}
+}
+
+// addHooks adds the server hooks that track live sessions and drop a
+// session's subscriptions when it ends
+func (w *resourceWatcher) addHooks(hooks *server.Hooks) {
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.sessions[session.SessionID()] = true
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		w.removeSession(session.SessionID())
+	})
+}
+
+// start begins watching the directories listed as resources and sending
+// notifications through mcpServer
+func (w *resourceWatcher) start(mcpServer *server.MCPServer) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start file watcher: %w", err)
+	}
+	w.mcpServer = mcpServer
+	w.fsw = fsw
+
+	dirs, err := resourceDirectories(w.cfg)
+	if err != nil {
+		fsw.Close()
+		return err
+	}
+
+	w.mu.Lock()
+	for _, rel := range dirs {
+		w.listed[resourceURI(rel, true)] = true
+		w.watchDir(filepath.Join(w.cfg.RootDir, filepath.FromSlash(rel)), true)
+	}
+	w.mu.Unlock()
+
+	go w.run()
+	log.Printf("Watching %d directories for resource changes", len(w.watched))
+	return nil
+}
+
+// close stops watching. Pending notifications are dropped.
+func (w *resourceWatcher) close() error {
+	w.mu.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.mu.Unlock()
+	return w.fsw.Close()
+}
+
+// run handles filesystem events until the watcher is closed
+func (w *resourceWatcher) run() {
+	for {
+		select {
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			w.handleEvent(event)
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("File watcher error: %v", err)
+		}
+	}
+}
+
+// handleEvent records which subscriptions an event affects and schedules a flush
+func (w *resourceWatcher) handleEvent(event fsnotify.Event) {
+	// Permission and timestamp changes leave resource contents alone
+	if event.Op == fsnotify.Chmod {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	recursive, isDir := w.watched[event.Name]
+	switch {
+	case event.Has(fsnotify.Create):
+		info, err := os.Lstat(event.Name)
+		if err != nil || !info.IsDir() {
+			break
+		}
+		isDir = true
+		// A directory made inside a recursively watched one is watched the same way,
+		// along with anything created in it before the watch was in place
+		if w.watched[filepath.Dir(event.Name)] && !w.ignored(event.Name) {
+			w.watchTree(event.Name)
+		}
+		w.listChanged = true
+	case event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename):
+		if !isDir {
+			break
+		}
+		w.unwatchTree(event.Name)
+		if recursive {
+			w.listChanged = true
+		}
+	}
+
+	for sessionID, subs := range w.subscriptions {
+		for _, sub := range subs {
+			if event.Name != sub.path && !(sub.dir && withinRoot(sub.path, event.Name)) {
+				continue
+			}
+			rel := sub.rel
+			if event.Name != sub.path {
+				rel = path.Join(rel, relPath(sub.path, event.Name))
+			}
+			if w.pending[sessionID] == nil {
+				w.pending[sessionID] = make(map[string]bool)
+			}
+			w.pending[sessionID][resourceURI(rel, isDir)] = true
+		}
+	}
+
+	if w.timer == nil && (len(w.pending) > 0 || w.listChanged) {
+		w.timer = time.AfterFunc(w.debounce, w.flush)
+	}
+}
+
+// flush sends the notifications collected since the last flush
+func (w *resourceWatcher) flush() {
+	w.mu.Lock()
+	pending, listChanged := w.pending, w.listChanged
+	w.pending = make(map[string]map[string]bool)
+	w.listChanged = false
+	w.timer = nil
+	w.mu.Unlock()
+
+	for sessionID, changed := range pending {
+		uris := make([]string, 0, len(changed))
+		for uri := range changed {
+			uris = append(uris, uri)
+		}
+		sort.Strings(uris)
+		for _, uri := range uris {
+			err := w.mcpServer.SendNotificationToSpecificClient(sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+			if err != nil {
+				log.Printf("Failed to notify session %s of %s: %v", sessionID, uri, err)
+			}
+		}
+	}
+
+	if listChanged {
+		w.resyncResources()
+	}
+}
+
+// resyncResources re-lists the directory resources and replaces the server's
+// list, which notifies every client, when the set of directories changed
+func (w *resourceWatcher) resyncResources() {
+	resources, err := directoryResources(w.cfg)
+	if err != nil {
+		log.Printf("Failed to refresh resources: %v", err)
+		return
+	}
+
+	listed := make(map[string]bool, len(resources))
+	for _, r := range resources {
+		listed[r.Resource.URI] = true
+	}
+
+	w.mu.Lock()
+	changed := len(listed) != len(w.listed)
+	for uri := range listed {
+		changed = changed || !w.listed[uri]
+	}
+	w.listed = listed
+	w.mu.Unlock()
+
+	if changed {
+		w.mcpServer.SetResources(resources...)
+		log.Printf("Resource list changed: %d directory resources", len(resources))
+	}
+}
+
+// subscribe starts reporting changes to the resource at uri to a session. A
+// directory subscription covers everything below it.
+func (w *resourceWatcher) subscribe(sessionID, uri string) error {
+	input, err := resourcePath(uri)
+	if err != nil {
+		return err
+	}
+
+	// Map the input path to actual filesystem path, enforcing root containment
+	target, err := resolvePath(w.cfg, input)
+	if err != nil {
+		return err
+	}
+
+	sub := subscription{rel: relPath(w.cfg.RootDir, target), path: target}
+	info, err := os.Stat(target)
+	switch {
+	case err == nil:
+		sub.dir = info.IsDir()
+	case os.IsNotExist(err):
+		// Files may be subscribed to before they are created, as long as their directory exists
+		if _, err := os.Stat(filepath.Dir(target)); err != nil {
+			return fmt.Errorf("path does not exist: %s", input)
+		}
+	default:
+		return fmt.Errorf("failed to stat path: %w", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Only sessions the server knows about are ever unregistered, so a made-up
+	// ID would keep its subscriptions forever
+	if !w.sessions[sessionID] {
+		return fmt.Errorf("unknown session %q: subscriptions need a session with an open notification stream", sessionID)
+	}
+
+	watchedAt := filepath.Dir(target)
+	if sub.dir {
+		watchedAt = target
+		w.watchTree(target)
+	} else {
+		w.watchDir(watchedAt, false)
+	}
+	if _, ok := w.watched[watchedAt]; !ok {
+		return fmt.Errorf("cannot watch %s: the limit of %d watched directories has been reached", input, maxWatchedDirectories)
+	}
+
+	if w.subscriptions[sessionID] == nil {
+		w.subscriptions[sessionID] = make(map[string]subscription)
+	}
+	w.subscriptions[sessionID][uri] = sub
+	log.Printf("[RESOURCE SUBSCRIBE] %s by session %s", uri, sessionID)
+	return nil
+}
+
+// unsubscribe stops reporting changes to the resource at uri to a session.
+// Watches are kept; they cost little and the directory is often watched for
+// the resource list anyway.
+func (w *resourceWatcher) unsubscribe(sessionID, uri string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.subscriptions[sessionID], uri)
+	if len(w.subscriptions[sessionID]) == 0 {
+		delete(w.subscriptions, sessionID)
+	}
+	log.Printf("[RESOURCE UNSUBSCRIBE] %s by session %s", uri, sessionID)
+}
+
+// removeSession drops every subscription held by a session that has ended
+func (w *resourceWatcher) removeSession(sessionID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.sessions, sessionID)
+	delete(w.subscriptions, sessionID)
+	delete(w.pending, sessionID)
+}
+
+// watchDir adds a watch on dir. recursive marks directories whose new
+// subdirectories should be watched as they appear. The caller holds w.mu.
+func (w *resourceWatcher) watchDir(dir string, recursive bool) {
+	if current, ok := w.watched[dir]; ok {
+		w.watched[dir] = current || recursive
+		return
+	}
+	if len(w.watched) >= maxWatchedDirectories {
+		return
+	}
+	if err := w.fsw.Add(dir); err != nil {
+		log.Printf("Failed to watch %s: %v", dir, err)
+		return
+	}
+	w.watched[dir] = recursive
+}
+
+// watchTree recursively watches dir and the directories below it that the
+// server's ignore rules let through. The caller holds w.mu.
+func (w *resourceWatcher) watchTree(dir string) {
+	opts := defaultWalkOptions()
+	opts.Type = entryTypeDirs
+	opts.RespectIgnore = w.cfg.RespectIgnoreFiles
+	opts.Ignore = w.cfg.IgnoreRules
+
+	err := walkTree(w.cfg.RootDir, dir, opts, func(entry walkEntry) error {
+		w.watchDir(entry.Path, true)
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to watch %s: %v", dir, err)
+	}
+}
+
+// unwatchTree forgets dir and everything below it after it was removed or
+// renamed. The caller holds w.mu.
+func (w *resourceWatcher) unwatchTree(dir string) {
+	for p := range w.watched {
+		if withinRoot(dir, p) {
+			// Removed directories lose their watch on their own; renamed ones do not
+			_ = w.fsw.Remove(p)
+			delete(w.watched, p)
+		}
+	}
+}
+
+// ignored reports whether the server's ignore rules hide the directory p
+func (w *resourceWatcher) ignored(p string) bool {
+	if !w.cfg.RespectIgnoreFiles {
+		return false
+	}
+	if filepath.Base(p) == ".git" {
+		return true
+	}
+	parent := ignoreMatcherFor(w.cfg.RootDir, filepath.Dir(p), w.cfg.IgnoreRules)
+	return parent.Match(relPath(w.cfg.RootDir, p), true)
+}
+
+// subscriptionRequest is the part of a resources/subscribe or
+// resources/unsubscribe request the watcher needs
+type subscriptionRequest struct {
+	ID     mcp.RequestId `json:"id"`
+	Method string        `json:"method"`
+	Params struct {
+		URI string `json:"uri"`
+	} `json:"params"`
+}
+
+// handleMessage answers a client message if it is a subscription request.
+// ok is false for every other message, which should be passed on to the
+// MCP server unchanged.
+func (w *resourceWatcher) handleMessage(sessionID string, message []byte) (response any, ok bool) {
+	var request subscriptionRequest
+	if err := json.Unmarshal(message, &request); err != nil {
+		return nil, false
+	}
+
+	var err error
+	switch request.Method {
+	case methodResourcesSubscribe:
+		if sessionID == "" {
+			err = fmt.Errorf("subscriptions need a session: send the %s header", server.HeaderKeySessionID)
+		} else {
+			err = w.subscribe(sessionID, request.Params.URI)
+		}
+	case methodResourcesUnsubscribe:
+		w.unsubscribe(sessionID, request.Params.URI)
+	default:
+		return nil, false
+	}
+
+	if err != nil {
+		return mcp.NewJSONRPCError(request.ID, mcp.INVALID_PARAMS, err.Error(), nil), true
+	}
+	return mcp.NewJSONRPCResponse(request.ID, mcp.Result{}), true
+}
+
+// httpHandler answers subscription requests posted to the streamable HTTP
+// endpoint and passes everything else to next. Ending a session drops its
+// subscriptions.
+func (w *resourceWatcher) httpHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		sessionID := r.Header.Get(server.HeaderKeySessionID)
+		switch r.Method {
+		case http.MethodDelete:
+			w.removeSession(sessionID)
+		case http.MethodPost:
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(rw, "failed to read request body", http.StatusBadRequest)
+				return
+			}
+			if response, ok := w.handleMessage(sessionID, body); ok {
+				rw.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(rw).Encode(response)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// filterStdio returns a reader of the client messages on in, minus the
+// subscription requests, which are answered on out directly
+func (w *resourceWatcher) filterStdio(in io.Reader, out io.Writer) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				if response, ok := w.handleMessage(stdioSessionID, line); ok {
+					data, _ := json.Marshal(response)
+					if _, err := out.Write(append(data, '\n')); err != nil {
+						log.Printf("Failed to write response: %v", err)
+					}
+				} else if _, err := pw.Write(line); err != nil {
+					return
+				}
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return pr
+}
+
+// lockedWriter serializes writes from the MCP server and the subscription
+// filter so their messages are never interleaved
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// serveStdio serves mcpServer on stdin and stdout like server.ServeStdio,
+// with subscription requests handled by watcher
+func serveStdio(mcpServer *server.MCPServer, watcher *resourceWatcher) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	stdout := &lockedWriter{w: os.Stdout}
+	return server.NewStdioServer(mcpServer).Listen(ctx, watcher.filterStdio(os.Stdin, stdout), stdout)
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// testSession is a client session that collects the notifications sent to it
+type testSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testSession) Initialize()       {}
+func (s *testSession) Initialized() bool { return true }
+func (s *testSession) SessionID() string { return s.id }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+// newWatcherTestServer returns an MCP server for cfg with a started resource
+// watcher and a registered client session
+func newWatcherTestServer(t *testing.T, cfg *serverConfig) (*server.MCPServer, *resourceWatcher, *testSession) {
+	watcher := newResourceWatcher(cfg, 20*time.Millisecond)
+	hooks := &server.Hooks{}
+	watcher.addHooks(hooks)
+	mcpServer := server.NewMCPServer("test", "1.0.0",
+		server.WithResourceCapabilities(true, true),
+		server.WithHooks(hooks),
+	)
+	if err := registerResources(mcpServer, cfg); err != nil {
+		t.Fatalf("Failed to register resources: %v", err)
+	}
+	if err := watcher.start(mcpServer); err != nil {
+		t.Fatalf("Failed to start watcher: %v", err)
+	}
+	t.Cleanup(func() { watcher.close() })
+
+	session := &testSession{id: "test-session", notifications: make(chan mcp.JSONRPCNotification, 100)}
+	if err := mcpServer.RegisterSession(context.Background(), session); err != nil {
+		t.Fatalf("Failed to register session: %v", err)
+	}
+	return mcpServer, watcher, session
+}
+
+// registerTestSession registers another client session with mcpServer
+func registerTestSession(t *testing.T, mcpServer *server.MCPServer, id string) {
+	t.Helper()
+	session := &testSession{id: id, notifications: make(chan mcp.JSONRPCNotification, 100)}
+	if err := mcpServer.RegisterSession(context.Background(), session); err != nil {
+		t.Fatalf("Failed to register session %s: %v", id, err)
+	}
+}
+
+// subscribeMessage returns a JSON-RPC subscription request for uri
+func subscribeMessage(method, uri string) []byte {
+	msg, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 7, "method": method, "params": map[string]any{"uri": uri}})
+	return msg
+}
+
+// mustSubscribe subscribes the session to uri, failing the test on error
+func mustSubscribe(t *testing.T, watcher *resourceWatcher, session *testSession, uri string) {
+	t.Helper()
+	response, ok := watcher.handleMessage(session.id, subscribeMessage(methodResourcesSubscribe, uri))
+	if !ok {
+		t.Fatalf("Subscription request for %s was not handled", uri)
+	}
+	if _, ok := response.(mcp.JSONRPCResponse); !ok {
+		t.Fatalf("Subscribing to %s failed: %+v", uri, response)
+	}
+}
+
+// waitForNotification returns the first notification with method (and, for
+// resource updates, uri) that arrives within a second
+func waitForNotification(t *testing.T, session *testSession, method, uri string) mcp.JSONRPCNotification {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case n := <-session.notifications:
+			if n.Method == method && (uri == "" || n.Params.AdditionalFields["uri"] == uri) {
+				return n
+			}
+		case <-timeout:
+			t.Fatalf("Timed out waiting for %s %s", method, uri)
+		}
+	}
+}
+
+// expectNoNotification fails the test if a notification arrives within d
+func expectNoNotification(t *testing.T, session *testSession, d time.Duration) {
+	t.Helper()
+	select {
+	case n := <-session.notifications:
+		t.Errorf("Unexpected notification %s %v", n.Method, n.Params.AdditionalFields)
+	case <-time.After(d):
+	}
+}
+
+func TestResourceWatcher_FileUpdated(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	_, watcher, session := newWatcherTestServer(t, newServerConfig(tempDir))
+	mustSubscribe(t, watcher, session, "file:///file1.txt")
+
+	// A burst of writes is reported once
+	for i := 0; i < 5; i++ {
+		writeTestFile(t, filepath.Join(tempDir, "file1.txt"), strings.Repeat("x", i))
+	}
+	waitForNotification(t, session, mcp.MethodNotificationResourceUpdated, "file:///file1.txt")
+	expectNoNotification(t, session, 100*time.Millisecond)
+
+	// Other files are not reported
+	writeTestFile(t, filepath.Join(tempDir, "subdir", "file2.go"), "package other")
+	expectNoNotification(t, session, 100*time.Millisecond)
+}
+
+func TestResourceWatcher_DirectorySubscription(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	_, watcher, session := newWatcherTestServer(t, newServerConfig(tempDir))
+	mustSubscribe(t, watcher, session, "file:///subdir/")
+
+	writeTestFile(t, filepath.Join(tempDir, "subdir", "deep", "new file.txt"), "new")
+	waitForNotification(t, session, mcp.MethodNotificationResourceUpdated, "file:///subdir/deep/new%20file.txt")
+
+	if err := os.Remove(filepath.Join(tempDir, "subdir", "file2.go")); err != nil {
+		t.Fatal(err)
+	}
+	waitForNotification(t, session, mcp.MethodNotificationResourceUpdated, "file:///subdir/file2.go")
+}
+
+func TestResourceWatcher_ListChanged(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	mcpServer, watcher, session := newWatcherTestServer(t, newServerConfig(tempDir))
+	mustSubscribe(t, watcher, session, "file:///")
+
+	if err := os.MkdirAll(filepath.Join(tempDir, "newdir", "inner"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	waitForNotification(t, session, mcp.MethodNotificationResourcesListChanged, "")
+
+	var list mcp.ListResourcesResult
+	if err := rpc(t, mcpServer, "resources/list", map[string]any{}, &list); err != nil {
+		t.Fatalf("resources/list failed: %+v", err)
+	}
+	listed := make(map[string]bool)
+	for _, r := range list.Resources {
+		listed[r.URI] = true
+	}
+	if !listed["file:///newdir/"] || !listed["file:///newdir/inner/"] {
+		t.Errorf("Expected the new directories to be listed, got %v", listed)
+	}
+
+	// Directories created after the watcher started are watched too
+	writeTestFile(t, filepath.Join(tempDir, "newdir", "inner", "x.txt"), "x")
+	waitForNotification(t, session, mcp.MethodNotificationResourceUpdated, "file:///newdir/inner/x.txt")
+
+	// Removing a directory changes the list again
+	if err := os.RemoveAll(filepath.Join(tempDir, "emptydir")); err != nil {
+		t.Fatal(err)
+	}
+	waitForNotification(t, session, mcp.MethodNotificationResourcesListChanged, "")
+}
+
+func TestResourceWatcher_Unsubscribe(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	mcpServer, watcher, session := newWatcherTestServer(t, newServerConfig(tempDir))
+	mustSubscribe(t, watcher, session, "file:///file1.txt")
+
+	response, ok := watcher.handleMessage(session.id, subscribeMessage(methodResourcesUnsubscribe, "file:///file1.txt"))
+	if _, isResponse := response.(mcp.JSONRPCResponse); !ok || !isResponse {
+		t.Fatalf("Unsubscribe failed: %+v", response)
+	}
+	writeTestFile(t, filepath.Join(tempDir, "file1.txt"), "changed")
+	expectNoNotification(t, session, 100*time.Millisecond)
+
+	// Ending the session drops its subscriptions
+	mustSubscribe(t, watcher, session, "file:///")
+	mcpServer.UnregisterSession(context.Background(), session.id)
+	watcher.mu.Lock()
+	defer watcher.mu.Unlock()
+	if len(watcher.subscriptions) != 0 {
+		t.Errorf("Expected no subscriptions after the session ended, got %v", watcher.subscriptions)
+	}
+}
+
+func TestResourceWatcher_SubscribeErrors(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	_, watcher, session := newWatcherTestServer(t, newServerConfig(tempDir))
+
+	// Files may be subscribed to before they exist
+	mustSubscribe(t, watcher, session, "file:///not-yet.txt")
+
+	for _, uri := range []string{"http://example.com/", "file:///missing/file.txt", "file:///%zz"} {
+		response, ok := watcher.handleMessage(session.id, subscribeMessage(methodResourcesSubscribe, uri))
+		if _, isError := response.(mcp.JSONRPCError); !ok || !isError {
+			t.Errorf("Expected an error subscribing to %s, got %+v", uri, response)
+		}
+	}
+
+	response, _ := watcher.handleMessage("", subscribeMessage(methodResourcesSubscribe, "file:///file1.txt"))
+	if _, isError := response.(mcp.JSONRPCError); !isError {
+		t.Errorf("Expected an error subscribing without a session, got %+v", response)
+	}
+
+	if _, ok := watcher.handleMessage(session.id, []byte(`{"jsonrpc":"2.0","id":1,"method":"ping"}`)); ok {
+		t.Error("Expected other methods to be passed through")
+	}
+}
+
+func TestResourceWatcher_HTTPHandler(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	mcpServer, watcher, _ := newWatcherTestServer(t, newServerConfig(tempDir))
+	registerTestSession(t, mcpServer, "http-session")
+
+	var delegated string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		delegated = string(body)
+	})
+	handler := watcher.httpHandler(next)
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(subscribeMessage(methodResourcesSubscribe, "file:///file1.txt")))
+	req.Header.Set(server.HeaderKeySessionID, "http-session")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if !contains(rec.Body.String(), `"result":{}`) || delegated != "" {
+		t.Errorf("Expected the subscription to be answered directly, got %q (delegated %q)", rec.Body.String(), delegated)
+	}
+	if _, ok := watcher.subscriptions["http-session"]["file:///file1.txt"]; !ok {
+		t.Error("Expected the subscription to be recorded for the request's session")
+	}
+
+	// Session IDs the server has not registered are refused
+	req = httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(subscribeMessage(methodResourcesSubscribe, "file:///file1.txt")))
+	req.Header.Set(server.HeaderKeySessionID, "made-up")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if !contains(rec.Body.String(), "unknown session") {
+		t.Errorf("Expected an unknown session error, got %q", rec.Body.String())
+	}
+	if _, ok := watcher.subscriptions["made-up"]; ok {
+		t.Error("Expected no subscription to be recorded for an unknown session")
+	}
+
+	ping := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(ping)))
+	if delegated != ping {
+		t.Errorf("Expected other messages to reach the MCP handler unchanged, got %q", delegated)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/mcp", nil)
+	req.Header.Set(server.HeaderKeySessionID, "http-session")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if _, ok := watcher.subscriptions["http-session"]; ok {
+		t.Error("Expected deleting the session to drop its subscriptions")
+	}
+}
+
+func TestResourceWatcher_FilterStdio(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	mcpServer, watcher, _ := newWatcherTestServer(t, newServerConfig(tempDir))
+	registerTestSession(t, mcpServer, stdioSessionID)
+
+	ping := `{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n"
+	in := string(subscribeMessage(methodResourcesSubscribe, "file:///file1.txt")) + "\n" + ping
+	var out bytes.Buffer
+	passed, err := io.ReadAll(watcher.filterStdio(strings.NewReader(in), &lockedWriter{w: &out}))
+	if err != nil {
+		t.Fatalf("Failed to read filtered input: %v", err)
+	}
+	if string(passed) != ping {
+		t.Errorf("Expected only the ping to be passed on, got %q", passed)
+	}
+	if !contains(out.String(), `"id":7`) || !contains(out.String(), `"result":{}`) {
+		t.Errorf("Expected the subscription response on stdout, got %q", out.String())
+	}
+	if _, ok := watcher.subscriptions[stdioSessionID]["file:///file1.txt"]; !ok {
+		t.Error("Expected the subscription to be recorded for the stdio session")
+	}
+}
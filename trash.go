@@ -0,0 +1,645 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Layout of one trashed entry: <trash dir>/<id>/meta.json and <trash dir>/<id>/data
+const (
+	trashMetaFile = "meta.json"
+	trashDataName = "data"
+)
+
+// trashEntry is the metadata stored with every trashed path
+type trashEntry struct {
+	ID           string     `json:"id"`
+	OriginalPath string     `json:"original_path"` // virtual path the entry was trashed from
+	Type         string     `json:"type"`          // file, dir or symlink
+	Entries      int        `json:"entries"`       // files, directories and links it contains
+	TrashedAt    time.Time  `json:"trashed_at"`
+	Client       string     `json:"client"`               // client that requested the trashing
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"` // when retention removes it; nil if kept forever
+}
+
+// errTrashDisabled is returned by the trash tools when no trash directory is configured
+var errTrashDisabled = errors.New("trash is disabled; start the server with -trash-dir")
+
+// trash manages the server's trash directory, which lives outside the served root
+type trash struct {
+	dir       string
+	retention time.Duration // 0 keeps entries until emptied
+}
+
+// newTrash returns the trash stored in dir, creating it if needed. dir must
+// not be inside rootDir, or trashed entries would still show up in walks.
+func newTrash(dir, rootDir string, retention time.Duration) (*trash, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	if err := os.MkdirAll(absDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create trash directory: %w", err)
+	}
+
+	realDir, err := filepath.EvalSymlinks(absDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve trash directory: %w", err)
+	}
+	realRoot, err := filepath.EvalSymlinks(rootDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve root directory: %w", err)
+	}
+	if withinRoot(realRoot, realDir) || withinRoot(realDir, realRoot) {
+		return nil, fmt.Errorf("trash directory %s must be outside the served root", absDir)
+	}
+
+	return &trash{dir: realDir, retention: retention}, nil
+}
+
+// put moves the entry at target into the trash
+func (t *trash) put(target, originalPath, client string) (trashEntry, error) {
+	info, err := os.Lstat(target)
+	if err != nil {
+		return trashEntry{}, fmt.Errorf("failed to stat path: %w", err)
+	}
+	entries, err := countEntries(target)
+	if err != nil {
+		return trashEntry{}, err
+	}
+
+	id, err := newTrashID(time.Now())
+	if err != nil {
+		return trashEntry{}, err
+	}
+	entry := trashEntry{
+		ID:           id,
+		OriginalPath: originalPath,
+		Type:         entryTypeName(info.Mode()),
+		Entries:      entries,
+		TrashedAt:    time.Now().UTC().Truncate(time.Second),
+		Client:       client,
+	}
+
+	if err := t.store(entry, target); err != nil {
+		return trashEntry{}, err
+	}
+	return t.withExpiry(entry), nil
+}
+
+// putBack moves a restored entry from target back into the trash under its
+// old id and metadata. It fails if an entry with that id exists again.
+func (t *trash) putBack(entry trashEntry, target string) error {
+	if err := checkTrashID(entry.ID); err != nil {
+		return err
+	}
+	entry.ExpiresAt = nil
+	return t.store(entry, target)
+}
+
+// store creates the slot for entry and moves target into it
+func (t *trash) store(entry trashEntry, target string) error {
+	slot := filepath.Join(t.dir, entry.ID)
+	if err := os.Mkdir(slot, 0700); err != nil {
+		return fmt.Errorf("failed to create trash entry: %w", err)
+	}
+
+	// Write the metadata first so an interrupted move still leaves a restorable record
+	if err := writeTrashMeta(slot, entry); err != nil {
+		os.RemoveAll(slot)
+		return err
+	}
+	if err := movePath(target, filepath.Join(slot, trashDataName)); err != nil {
+		os.RemoveAll(slot)
+		return err
+	}
+	return nil
+}
+
+// list returns all trashed entries, most recently trashed first
+func (t *trash) list() ([]trashEntry, error) {
+	dirs, err := os.ReadDir(t.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trash directory: %w", err)
+	}
+
+	entries := []trashEntry{}
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		entry, err := t.get(d.Name())
+		if err != nil {
+			log.Printf("Skipping unreadable trash entry %s: %v", d.Name(), err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].TrashedAt.Equal(entries[j].TrashedAt) {
+			return entries[i].TrashedAt.After(entries[j].TrashedAt)
+		}
+		return entries[i].ID > entries[j].ID
+	})
+	return entries, nil
+}
+
+// get returns the metadata of the entry with the given id
+func (t *trash) get(id string) (trashEntry, error) {
+	if err := checkTrashID(id); err != nil {
+		return trashEntry{}, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(t.dir, id, trashMetaFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return trashEntry{}, fmt.Errorf("no trash entry with id %s", id)
+		}
+		return trashEntry{}, fmt.Errorf("failed to read trash entry: %w", err)
+	}
+
+	var entry trashEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return trashEntry{}, fmt.Errorf("failed to parse trash entry: %w", err)
+	}
+	return t.withExpiry(entry), nil
+}
+
+// restore moves the entry with the given id back to target
+func (t *trash) restore(id, target string) (trashEntry, error) {
+	entry, err := t.get(id)
+	if err != nil {
+		return trashEntry{}, err
+	}
+
+	slot := filepath.Join(t.dir, id)
+	if err := movePath(filepath.Join(slot, trashDataName), target); err != nil {
+		return trashEntry{}, err
+	}
+	if err := os.RemoveAll(slot); err != nil {
+		log.Printf("Failed to remove restored trash entry %s: %v", id, err)
+	}
+	return entry, nil
+}
+
+// remove permanently deletes the entry with the given id
+func (t *trash) remove(id string) error {
+	if _, err := t.get(id); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(t.dir, id)); err != nil {
+		return fmt.Errorf("failed to remove trash entry: %w", err)
+	}
+	return nil
+}
+
+// purgeExpired removes entries older than the retention period and returns
+// how many were removed
+func (t *trash) purgeExpired(now time.Time) (int, error) {
+	if t.retention <= 0 {
+		return 0, nil
+	}
+
+	entries, err := t.list()
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, entry := range entries {
+		if entry.ExpiresAt == nil || now.Before(*entry.ExpiresAt) {
+			continue
+		}
+		if err := t.remove(entry.ID); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// withExpiry fills in ExpiresAt from the retention policy
+func (t *trash) withExpiry(entry trashEntry) trashEntry {
+	if t.retention > 0 {
+		expires := entry.TrashedAt.Add(t.retention)
+		entry.ExpiresAt = &expires
+	}
+	return entry
+}
+
+// writeTrashMeta stores entry's metadata in its slot
+func writeTrashMeta(slot string, entry trashEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode trash metadata: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(slot, trashMetaFile), data, 0600); err != nil {
+		return fmt.Errorf("failed to write trash metadata: %w", err)
+	}
+	return nil
+}
+
+// checkTrashID rejects ids that would name something other than one slot
+func checkTrashID(id string) error {
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return fmt.Errorf("invalid trash id %q", id)
+	}
+	return nil
+}
+
+// newTrashID returns a unique, time-ordered id for a trash entry
+func newTrashID(now time.Time) (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to generate trash id: %w", err)
+	}
+	return now.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix), nil
+}
+
+// clientName identifies the client that made a request, for audit records
+func clientName(ctx context.Context) string {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return "unknown"
+	}
+
+	name := "session " + session.SessionID()
+	if withInfo, ok := session.(server.SessionWithClientInfo); ok {
+		if info := withInfo.GetClientInfo(); info.Name != "" {
+			name = strings.TrimSpace(info.Name + " " + info.Version)
+		}
+	}
+	return name
+}
+
+// purgeTrash applies the retention policy before a trash tool runs
+func purgeTrash(t *trash) {
+	if n, err := t.purgeExpired(time.Now()); err != nil {
+		log.Printf("Failed to purge expired trash entries: %v", err)
+	} else if n > 0 {
+		log.Printf("Purged %d expired trash entries", n)
+	}
+}
+
+// trashPathTool implements the trash_path tool handler
+func trashPathTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		if cfg.Trash == nil {
+			return nil, errTrashDisabled
+		}
+		if !cfg.AllowWrite {
+			return nil, errWriteDisabled
+		}
+		purgeTrash(cfg.Trash)
+
+		// Parse the arguments
+		var args struct {
+			Path string `json:"path"`
+		}
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+		if args.Path == "" {
+			return nil, fmt.Errorf("path is required")
+		}
+
+		target, err := resolveEntryPath(cfg, args.Path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Lstat(target); os.IsNotExist(err) {
+			return nil, fmt.Errorf("path does not exist: %s", args.Path)
+		}
+
+		entry, err := cfg.Trash.put(target, virtualPath(relPath(cfg.RootDir, target)), clientName(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		log.Printf("[TOOL COMPLETED] %s - moved %s to trash as %s", request.Params.Name, entry.OriginalPath, entry.ID)
+
+		return mcp.NewToolResultStructured(entry, fmt.Sprintf("Moved %s to trash (id %s)", entry.OriginalPath, entry.ID)), nil
+	}
+}
+
+// trashListResult is the structured content returned by list_trash
+type trashListResult struct {
+	Entries []trashEntry `json:"entries"`
+}
+
+// listTrashTool implements the list_trash tool handler
+func listTrashTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		if cfg.Trash == nil {
+			return nil, errTrashDisabled
+		}
+		purgeTrash(cfg.Trash)
+
+		entries, err := cfg.Trash.list()
+		if err != nil {
+			return nil, err
+		}
+
+		var sb strings.Builder
+		for _, e := range entries {
+			sb.WriteString(fmt.Sprintf("%s  %s  %s (%s, %d entries)\n", e.ID, e.TrashedAt.Format(time.RFC3339), e.OriginalPath, e.Type, e.Entries))
+		}
+		if len(entries) == 0 {
+			sb.WriteString("Trash is empty\n")
+		}
+
+		log.Printf("[TOOL COMPLETED] %s - %d entries in trash", request.Params.Name, len(entries))
+
+		return mcp.NewToolResultStructured(trashListResult{Entries: entries}, sb.String()), nil
+	}
+}
+
+// trashRestoreResult is the structured content returned by restore_from_trash
+type trashRestoreResult struct {
+	trashEntry
+	RestoredTo string `json:"restored_to"`
+	Displaced  string `json:"displaced,omitempty"` // trash id of what was at the destination
+}
+
+// restoreFromTrashTool implements the restore_from_trash tool handler
+func restoreFromTrashTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		if cfg.Trash == nil {
+			return nil, errTrashDisabled
+		}
+		if !cfg.AllowWrite {
+			return nil, errWriteDisabled
+		}
+
+		// Parse the arguments
+		var args struct {
+			ID          string `json:"id"`
+			Destination string `json:"destination"`
+			Overwrite   bool   `json:"overwrite"`
+		}
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+		if args.ID == "" {
+			return nil, fmt.Errorf("id is required")
+		}
+
+		entry, err := cfg.Trash.get(args.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Restore to the original location unless told otherwise
+		dest := args.Destination
+		if dest == "" {
+			dest = entry.OriginalPath
+		}
+		target, err := resolveEntryPath(cfg, dest)
+		if err != nil {
+			return nil, err
+		}
+		if info, err := os.Stat(filepath.Dir(target)); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("parent directory of destination does not exist: %s", filepath.Dir(dest))
+		}
+		replace, err := checkDestination(target, args.Overwrite)
+		if err != nil {
+			return nil, err
+		}
+
+		// Whatever is in the way goes to the trash too, so nothing is unlinked
+		var displaced trashEntry
+		if replace {
+			if displaced, err = cfg.Trash.put(target, virtualPath(relPath(cfg.RootDir, target)), clientName(ctx)); err != nil {
+				return nil, fmt.Errorf("failed to move the existing destination to trash: %w", err)
+			}
+		}
+		if _, err := cfg.Trash.restore(args.ID, target); err != nil {
+			if replace {
+				if _, putBackErr := cfg.Trash.restore(displaced.ID, target); putBackErr != nil {
+					log.Printf("Failed to put back %s from trash entry %s: %v", dest, displaced.ID, putBackErr)
+				}
+			}
+			return nil, err
+		}
+
+		formatter, _ := newPathFormatter(cfg.PathStyle, cfg.RootDir, ".")
+		result := trashRestoreResult{trashEntry: entry, RestoredTo: formatter.format(relPath(cfg.RootDir, target)), Displaced: displaced.ID}
+
+		text := fmt.Sprintf("Restored %s to %s", args.ID, result.RestoredTo)
+		if replace {
+			text += fmt.Sprintf(", moving what was there to trash (id %s)", displaced.ID)
+		}
+
+		log.Printf("[TOOL COMPLETED] %s - restored %s to %s", request.Params.Name, args.ID, dest)
+
+		return mcp.NewToolResultStructured(result, text), nil
+	}
+}
+
+// trashEmptyResult is the structured content returned by empty_trash
+type trashEmptyResult struct {
+	Removed []string `json:"removed"` // ids of the deleted entries
+}
+
+// emptyTrashTool implements the empty_trash tool handler
+func emptyTrashTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		if cfg.Trash == nil {
+			return nil, errTrashDisabled
+		}
+		if !cfg.AllowWrite {
+			return nil, errWriteDisabled
+		}
+
+		// Parse the arguments
+		var args struct {
+			ID        string `json:"id"`
+			OlderThan string `json:"older_than"`
+		}
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+
+		var olderThan time.Duration
+		if args.OlderThan != "" {
+			d, err := time.ParseDuration(args.OlderThan)
+			if err != nil || d < 0 {
+				return nil, fmt.Errorf("invalid older_than %q: must be a duration such as '24h'", args.OlderThan)
+			}
+			olderThan = d
+		}
+
+		result := trashEmptyResult{Removed: []string{}}
+		if args.ID != "" {
+			if err := cfg.Trash.remove(args.ID); err != nil {
+				return nil, err
+			}
+			result.Removed = append(result.Removed, args.ID)
+		} else {
+			entries, err := cfg.Trash.list()
+			if err != nil {
+				return nil, err
+			}
+			cutoff := time.Now().Add(-olderThan)
+			for _, e := range entries {
+				if e.TrashedAt.After(cutoff) {
+					continue
+				}
+				if err := cfg.Trash.remove(e.ID); err != nil {
+					return nil, err
+				}
+				result.Removed = append(result.Removed, e.ID)
+			}
+		}
+
+		log.Printf("[TOOL COMPLETED] %s - permanently removed %d trash entries", request.Params.Name, len(result.Removed))
+
+		return mcp.NewToolResultStructured(result, fmt.Sprintf("Permanently removed %d trash entries", len(result.Removed))), nil
+	}
+}
+
+// trashEntrySchema describes trashEntry in output schemas
+const trashEntrySchema = `{
+	"type": "object",
+	"properties": {
+		"id": {"type": "string"},
+		"original_path": {"type": "string"},
+		"type": {"type": "string", "enum": ["file", "dir", "symlink", "other"]},
+		"entries": {"type": "integer"},
+		"trashed_at": {"type": "string", "format": "date-time"},
+		"client": {"type": "string"},
+		"expires_at": {"type": "string", "format": "date-time"},
+		"restored_to": {"type": "string"},
+		"displaced": {"type": "string", "description": "Trash id of the entry that was at the destination"}
+	},
+	"required": ["id", "original_path", "type", "entries", "trashed_at", "client"]
+}`
+
+// trashServerTools defines list_trash, which only reads the trash
+func trashServerTools(cfg *serverConfig) []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name:        "list_trash",
+				Description: "Lists entries in the server's trash, most recent first",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: map[string]interface{}{},
+				},
+				RawOutputSchema: json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"entries": {"type": "array", "items": ` + trashEntrySchema + `}
+	},
+	"required": ["entries"]
+}`),
+			},
+			Handler: listTrashTool(cfg),
+		},
+	}
+}
+
+// trashWriteServerTools defines trash_path and restore_from_trash, which move
+// entries out of and into the root, and empty_trash, which deletes them for good
+func trashWriteServerTools(cfg *serverConfig) []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name:        "trash_path",
+				Description: "Moves a file or directory under the root into the server's trash, from which it can be restored",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "Path to trash (relative to the root); symlinks are trashed as links",
+						},
+					},
+					Required: []string{"path"},
+				},
+				RawOutputSchema: json.RawMessage(trashEntrySchema),
+			},
+			Handler: trashPathTool(cfg),
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "restore_from_trash",
+				Description: "Moves a trashed entry back into the root",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"id": map[string]interface{}{
+							"type":        "string",
+							"description": "Trash entry id, as returned by trash_path or list_trash",
+						},
+						"destination": map[string]interface{}{
+							"type":        "string",
+							"description": "Where to restore to (default: the original path)",
+						},
+						"overwrite": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Move an existing file or empty directory at the destination to the trash and restore in its place",
+							"default":     false,
+						},
+					},
+					Required: []string{"id"},
+				},
+				RawOutputSchema: json.RawMessage(trashEntrySchema),
+			},
+			Handler: restoreFromTrashTool(cfg),
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "empty_trash",
+				Description: "Permanently deletes trashed entries",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"id": map[string]interface{}{
+							"type":        "string",
+							"description": "Delete only this entry",
+						},
+						"older_than": map[string]interface{}{
+							"type":        "string",
+							"description": "Delete only entries trashed longer ago than this duration, e.g. '24h'",
+						},
+					},
+				},
+				RawOutputSchema: json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"removed": {"type": "array", "items": {"type": "string"}}
+	},
+	"required": ["removed"]
+}`),
+			},
+			Handler: emptyTrashTool(cfg),
+		},
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// trashConfig returns a configuration for tempDir with writes enabled and a
+// trash outside it
+func trashConfig(t *testing.T, tempDir string, retention time.Duration) *serverConfig {
+	t.Helper()
+
+	tr, err := newTrash(filepath.Join(t.TempDir(), "trash"), tempDir, retention)
+	if err != nil {
+		t.Fatalf("newTrash failed: %v", err)
+	}
+	cfg := newServerConfig(tempDir)
+	cfg.PathStyle = pathStyleVirtual
+	cfg.AllowWrite = true
+	cfg.Trash = tr
+	return cfg
+}
+
+func TestTrashTools_RoundTrip(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := trashConfig(t, tempDir, 0)
+
+	result, err := callTool(trashPathTool(cfg), "trash_path", `{"path": "/subdir"}`)
+	if err != nil {
+		t.Fatalf("trash_path failed: %v", err)
+	}
+	entry := result.StructuredContent.(trashEntry)
+	if entry.OriginalPath != "/subdir" || entry.Type != "dir" || entry.Entries != 4 || entry.Client == "" {
+		t.Errorf("Unexpected trash entry %+v", entry)
+	}
+	if entry.ExpiresAt != nil {
+		t.Errorf("Expected no expiry without retention, got %v", entry.ExpiresAt)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "subdir")); !os.IsNotExist(err) {
+		t.Fatal("Expected trashed directory to leave the root")
+	}
+
+	result, err = callTool(listTrashTool(cfg), "list_trash", `{}`)
+	if err != nil {
+		t.Fatalf("list_trash failed: %v", err)
+	}
+	listed := result.StructuredContent.(trashListResult)
+	if len(listed.Entries) != 1 || listed.Entries[0].ID != entry.ID {
+		t.Fatalf("Expected the trashed entry to be listed, got %+v", listed)
+	}
+
+	// Restoring onto an existing path needs overwrite
+	writeTestFile(t, filepath.Join(tempDir, "subdir"), "in the way")
+	_, err = callTool(restoreFromTrashTool(cfg), "restore_from_trash", `{"id": "`+entry.ID+`"}`)
+	if err == nil || !contains(err.Error(), "already exists") {
+		t.Errorf("Expected existing destination error, got %v", err)
+	}
+
+	result, err = callTool(restoreFromTrashTool(cfg), "restore_from_trash", `{"id": "`+entry.ID+`", "destination": "/restored"}`)
+	if err != nil {
+		t.Fatalf("restore_from_trash failed: %v", err)
+	}
+	if restored := result.StructuredContent.(trashRestoreResult); restored.RestoredTo != "/restored" {
+		t.Errorf("Unexpected restore result %+v", restored)
+	}
+	if data, err := os.ReadFile(filepath.Join(tempDir, "restored", "file2.go")); err != nil || string(data) != "package main" {
+		t.Errorf("Expected restored contents, got %q (%v)", data, err)
+	}
+
+	if entries, _ := cfg.Trash.list(); len(entries) != 0 {
+		t.Errorf("Expected restored entry to leave the trash, got %+v", entries)
+	}
+}
+
+func TestTrashTools_EmptyAndRetention(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := trashConfig(t, tempDir, time.Hour)
+
+	old, err := cfg.Trash.put(filepath.Join(tempDir, "file1.txt"), "/file1.txt", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recent, err := cfg.Trash.put(filepath.Join(tempDir, "emptydir"), "/emptydir", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recent.ExpiresAt == nil || !recent.ExpiresAt.Equal(recent.TrashedAt.Add(time.Hour)) {
+		t.Errorf("Expected expiry one hour after trashing, got %v", recent.ExpiresAt)
+	}
+
+	// Age the first entry past the retention period
+	old.TrashedAt = old.TrashedAt.Add(-2 * time.Hour)
+	old.ExpiresAt = nil
+	if err := writeTrashMeta(filepath.Join(cfg.Trash.dir, old.ID), old); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := cfg.Trash.purgeExpired(time.Now())
+	if err != nil || n != 1 {
+		t.Fatalf("Expected 1 purged entry, got %d (%v)", n, err)
+	}
+	if _, err := cfg.Trash.get(old.ID); err == nil {
+		t.Error("Expected expired entry to be purged")
+	}
+
+	result, err := callTool(emptyTrashTool(cfg), "empty_trash", `{"older_than": "24h"}`)
+	if err != nil {
+		t.Fatalf("empty_trash failed: %v", err)
+	}
+	if removed := result.StructuredContent.(trashEmptyResult).Removed; len(removed) != 0 {
+		t.Errorf("Expected recent entry to survive older_than, removed %v", removed)
+	}
+
+	result, err = callTool(emptyTrashTool(cfg), "empty_trash", `{}`)
+	if err != nil {
+		t.Fatalf("empty_trash failed: %v", err)
+	}
+	if removed := result.StructuredContent.(trashEmptyResult).Removed; len(removed) != 1 || removed[0] != recent.ID {
+		t.Errorf("Expected the remaining entry to be removed, got %v", removed)
+	}
+}
+
+func TestTrashTools_Errors(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	if _, err := newTrash(filepath.Join(tempDir, ".trash"), tempDir, 0); err == nil {
+		t.Error("Expected a trash directory inside the root to be rejected")
+	}
+
+	cfg := trashConfig(t, tempDir, 0)
+	tests := []struct {
+		name      string
+		arguments string
+		errText   string
+	}{
+		{"trash_path", `{"path": "/"}`, "root directory itself"},
+		{"trash_path", `{"path": "/missing"}`, "does not exist"},
+		{"trash_path", `{"path": "/../x"}`, "outside root directory"},
+		{"restore_from_trash", `{"id": "../escape"}`, "invalid trash id"},
+		{"restore_from_trash", `{"id": "20260101T000000Z-00000000"}`, "no trash entry"},
+		{"empty_trash", `{"older_than": "soon"}`, "invalid older_than"},
+	}
+
+	handlers := map[string]func(*serverConfig) server.ToolHandlerFunc{
+		"trash_path":         trashPathTool,
+		"restore_from_trash": restoreFromTrashTool,
+		"empty_trash":        emptyTrashTool,
+	}
+	for _, tt := range tests {
+		_, err := callTool(handlers[tt.name](cfg), tt.name, tt.arguments)
+		if err == nil || !contains(err.Error(), tt.errText) {
+			t.Errorf("Expected '%s' error for %s %s, got %v", tt.errText, tt.name, tt.arguments, err)
+		}
+	}
+
+	_, err := callTool(trashPathTool(newServerConfig(tempDir)), "trash_path", `{"path": "/file1.txt"}`)
+	if err != errTrashDisabled {
+		t.Errorf("Expected errTrashDisabled, got %v", err)
+	}
+
+	// Moving entries into and out of the trash changes the root, and emptying
+	// it destroys them, so these need writes
+	cfg.AllowWrite = false
+	for name, handler := range map[string]server.ToolHandlerFunc{"trash_path": trashPathTool(cfg), "restore_from_trash": restoreFromTrashTool(cfg), "empty_trash": emptyTrashTool(cfg)} {
+		if _, err := callTool(handler, name, `{"path": "/file1.txt", "id": "x"}`); err != errWriteDisabled {
+			t.Errorf("Expected errWriteDisabled from %s, got %v", name, err)
+		}
+	}
+}
+
+func TestTrashTools_RestoreOverwrite(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := trashConfig(t, tempDir, 0)
+
+	entry, err := cfg.Trash.put(filepath.Join(tempDir, "file1.txt"), "/file1.txt", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(tempDir, "file1.txt"), "newer")
+
+	// The file in the way is trashed rather than unlinked
+	result, err := callTool(restoreFromTrashTool(cfg), "restore_from_trash", `{"id": "`+entry.ID+`", "overwrite": true}`)
+	if err != nil {
+		t.Fatalf("restore_from_trash failed: %v", err)
+	}
+	restored := result.StructuredContent.(trashRestoreResult)
+	if restored.Displaced == "" {
+		t.Fatalf("Expected the displaced entry's id, got %+v", restored)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "file1.txt")); string(data) != "test content" {
+		t.Errorf("Expected the restored file, got %q", data)
+	}
+	data, err := os.ReadFile(filepath.Join(cfg.Trash.dir, restored.Displaced, trashDataName))
+	if err != nil || string(data) != "newer" {
+		t.Errorf("Expected the displaced file in the trash, got %q (%v)", data, err)
+	}
+
+	// A failed restore puts the displaced entry back
+	os.RemoveAll(filepath.Join(cfg.Trash.dir, restored.Displaced, trashDataName))
+	writeTestFile(t, filepath.Join(tempDir, "file1.txt"), "kept")
+	if _, err := callTool(restoreFromTrashTool(cfg), "restore_from_trash", `{"id": "`+restored.Displaced+`", "overwrite": true}`); err == nil {
+		t.Fatal("Expected restoring an entry without data to fail")
+	}
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "file1.txt")); string(data) != "kept" {
+		t.Errorf("Expected the destination to be put back, got %q", data)
+	}
+}
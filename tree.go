@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Output formats accepted by walk_directory's "format" argument
+const (
+	formatList       = "list"
+	formatTree       = "tree"
+	formatNestedJSON = "nested_json"
+)
+
+// treeNode is one entry in a nested walk result. Metadata requested through
+// "fields" is flattened into the node alongside its children.
+type treeNode struct {
+	walkItem
+	Name     string      `json:"name"`
+	Children []*treeNode `json:"children,omitempty"`
+	Omitted  int         `json:"omitted,omitempty"` // children left out by the per-directory cap
+
+	isDir bool
+}
+
+// treeBuilder assembles walk entries, which arrive in walk order, into a tree
+// rooted at the walk target. Directories that were filtered out of the walk
+// results but have reported descendants are synthesized so the tree stays connected.
+type treeBuilder struct {
+	root      *treeNode
+	nodes     map[string]*treeNode // by root-relative path
+	dropped   map[string]bool      // synthesized directories cut by the per-directory cap
+	targetRel string
+	maxPerDir int // 0 for no cap
+	outPath   func(rel string) string
+}
+
+// newTreeBuilder returns a builder for a walk of targetRel
+func newTreeBuilder(targetRel string, maxPerDir int, outPath func(rel string) string) *treeBuilder {
+	return &treeBuilder{
+		nodes:     make(map[string]*treeNode),
+		dropped:   make(map[string]bool),
+		targetRel: targetRel,
+		maxPerDir: maxPerDir,
+		outPath:   outPath,
+	}
+}
+
+// add inserts an entry into the tree. It returns false when the entry was
+// left out because its directory already holds maxPerDir children.
+func (b *treeBuilder) add(rel string, item walkItem, isDir bool) bool {
+	if rel == b.targetRel {
+		b.root = b.newNode(rel, item, isDir)
+		b.nodes[rel] = b.root
+		return true
+	}
+
+	parent := b.dir(path.Dir(rel))
+	if parent == nil || !b.hasRoom(parent) {
+		return false
+	}
+
+	node := b.newNode(rel, item, isDir)
+	parent.Children = append(parent.Children, node)
+	b.nodes[rel] = node
+	return true
+}
+
+// dir returns the node for directory rel, synthesizing it (and its ancestors)
+// if it was not reported itself. It returns nil if the cap cut it off.
+func (b *treeBuilder) dir(rel string) *treeNode {
+	if node, ok := b.nodes[rel]; ok {
+		return node
+	}
+	if b.dropped[rel] {
+		return nil
+	}
+
+	item := walkItem{Path: b.outPath(rel), Type: "dir"}
+	if rel == b.targetRel {
+		b.root = b.newNode(rel, item, true)
+		b.nodes[rel] = b.root
+		return b.root
+	}
+
+	parent := b.dir(path.Dir(rel))
+	if parent == nil || !b.hasRoom(parent) {
+		b.dropped[rel] = true
+		return nil
+	}
+
+	node := b.newNode(rel, item, true)
+	parent.Children = append(parent.Children, node)
+	b.nodes[rel] = node
+	return node
+}
+
+// hasRoom reports whether parent can take another child, counting the child as
+// omitted when it cannot
+func (b *treeBuilder) hasRoom(parent *treeNode) bool {
+	if b.maxPerDir > 0 && len(parent.Children) >= b.maxPerDir {
+		parent.Omitted++
+		return false
+	}
+	return true
+}
+
+// newNode creates a node for rel
+func (b *treeBuilder) newNode(rel string, item walkItem, isDir bool) *treeNode {
+	name := path.Base(rel)
+	if rel == "." {
+		name = "/"
+	}
+	return &treeNode{walkItem: item, Name: name, isDir: isDir}
+}
+
+// renderTree draws the tree with box-drawing characters, like the tree command
+func renderTree(root *treeNode) string {
+	if root == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(root.Path)
+	if root.isDir && !strings.HasSuffix(root.Path, "/") {
+		sb.WriteString("/")
+	}
+	sb.WriteString("\n")
+	renderChildren(&sb, root, "")
+	return sb.String()
+}
+
+// renderChildren writes the children of node, each line prefixed by prefix
+func renderChildren(sb *strings.Builder, node *treeNode, prefix string) {
+	for i, child := range node.Children {
+		last := i == len(node.Children)-1 && node.Omitted == 0
+
+		branch, indent := "├── ", "│   "
+		if last {
+			branch, indent = "└── ", "    "
+		}
+
+		sb.WriteString(prefix + branch + child.Name)
+		if child.isDir {
+			sb.WriteString("/")
+		}
+		sb.WriteString("\n")
+		renderChildren(sb, child, prefix+indent)
+	}
+
+	if node.Omitted > 0 {
+		sb.WriteString(fmt.Sprintf("%s└── ... %d more entries\n", prefix, node.Omitted))
+	}
+}
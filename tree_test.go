@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// buildTestTree walks the standard test directory into a tree
+func buildTestTree(t *testing.T, tempDir string, opts walkOptions, maxPerDir int) *treeNode {
+	t.Helper()
+
+	b := newTreeBuilder(".", maxPerDir, func(rel string) string { return rel })
+	err := walkTree(tempDir, tempDir, opts, func(entry walkEntry) error {
+		item := walkItem{Path: entry.Rel, Type: entryTypeName(entry.Entry.Type())}
+		if !b.add(entry.Rel, item, entry.Entry.IsDir()) {
+			return skipEntry(entry.Entry)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walkTree returned error: %v", err)
+	}
+	return b.root
+}
+
+func TestRenderTree(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	root := buildTestTree(t, tempDir, defaultWalkOptions(), 0)
+
+	expected := `./
+├── emptydir/
+├── file1.txt
+└── subdir/
+    ├── deep/
+    │   └── file3.json
+    └── file2.go
+`
+	if got := renderTree(root); got != expected {
+		t.Errorf("renderTree mismatch:\ngot:\n%s\nwant:\n%s", got, expected)
+	}
+}
+
+func TestTreeBuilder_MaxPerDir(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	root := buildTestTree(t, tempDir, defaultWalkOptions(), 1)
+
+	if len(root.Children) != 1 || root.Omitted != 2 {
+		t.Fatalf("Expected 1 child and 2 omitted at root, got %d and %d", len(root.Children), root.Omitted)
+	}
+
+	text := renderTree(root)
+	if !strings.Contains(text, "└── ... 2 more entries") {
+		t.Errorf("Expected collapsed marker in:\n%s", text)
+	}
+}
+
+func TestTreeBuilder_SynthesizesFilteredDirectories(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	opts := defaultWalkOptions()
+	opts.Type = entryTypeFiles
+
+	root := buildTestTree(t, tempDir, opts, 0)
+	if root == nil {
+		t.Fatal("Root should be synthesized when directories are filtered out")
+	}
+
+	var subdir *treeNode
+	for _, child := range root.Children {
+		if child.Name == "subdir" {
+			subdir = child
+		}
+	}
+	if subdir == nil || !subdir.isDir || subdir.Type != "dir" {
+		t.Fatalf("Expected synthesized subdir node, got %+v", subdir)
+	}
+	if len(subdir.Children) != 2 {
+		t.Errorf("Expected deep/ and file2.go under subdir, got %d children", len(subdir.Children))
+	}
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultUsageDepth is how far below the path disk_usage lists directories by default
+const defaultUsageDepth = 1
+
+// defaultUsageTop is how many of the largest directories and files disk_usage returns by default
+const defaultUsageTop = 10
+
+// noExtension is the extension reported for files without one
+const noExtension = "(none)"
+
+// usageTotals are the sizes accumulated for a directory, extension or tree.
+// Allocated size falls back to the apparent size where the platform does not
+// report allocated blocks.
+type usageTotals struct {
+	Files         int   `json:"files"`
+	ApparentSize  int64 `json:"apparent_size"`
+	AllocatedSize int64 `json:"allocated_size"`
+}
+
+// add counts one file
+func (t *usageTotals) add(apparent, allocated int64) {
+	t.Files++
+	t.ApparentSize += apparent
+	t.AllocatedSize += allocated
+}
+
+// dirUsage is the recursive usage of one directory
+type dirUsage struct {
+	Path string `json:"path"`
+	usageTotals
+}
+
+// fileUsage is the size of one file
+type fileUsage struct {
+	Path          string `json:"path"`
+	ApparentSize  int64  `json:"apparent_size"`
+	AllocatedSize int64  `json:"allocated_size"`
+}
+
+// extensionUsage is the usage of all files sharing an extension
+type extensionUsage struct {
+	Extension string `json:"extension"` // lower-case, with the leading dot
+	usageTotals
+}
+
+// usageResult is the structured content returned by disk_usage
+type usageResult struct {
+	Path string `json:"path"`
+	usageTotals
+	Dirs               int              `json:"dirs"`
+	HardLinksSkipped   int              `json:"hard_links_skipped"` // extra names of files already counted
+	Directories        []dirUsage       `json:"directories"`        // down to the requested depth, in walk order
+	LargestDirectories []dirUsage       `json:"largest_directories"`
+	LargestFiles       []fileUsage      `json:"largest_files"`
+	Extensions         []extensionUsage `json:"extensions"`
+}
+
+// diskUsageTool implements the disk_usage tool handler
+func diskUsageTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		// Parse the arguments
+		var args struct {
+			Path          string   `json:"path"`
+			Depth         int      `json:"depth"`
+			Top           int      `json:"top"`
+			Include       []string `json:"include"`
+			Exclude       []string `json:"exclude"`
+			IncludeHidden bool     `json:"include_hidden"`
+			RespectIgnore bool     `json:"respect_ignore_files"`
+		}
+		args.Path = "/"
+		args.Depth = defaultUsageDepth
+		args.Top = defaultUsageTop
+		args.IncludeHidden = true
+		// Ignored trees (node_modules, build output, .git) are usually where the
+		// space goes, so unlike the other tools this one counts them by default
+		args.RespectIgnore = false
+
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+
+		if args.Depth < 0 || args.Top <= 0 {
+			return nil, fmt.Errorf("depth must not be negative and top must be positive")
+		}
+
+		opts := defaultWalkOptions()
+		opts.IncludeHidden = args.IncludeHidden
+		opts.RespectIgnore = args.RespectIgnore
+		opts.Ignore = cfg.IgnoreRules
+
+		var err error
+		if opts.Include, err = newPathMatcher(args.Include, patternSyntaxGlob); err != nil {
+			return nil, fmt.Errorf("invalid include: %w", err)
+		}
+		if opts.Exclude, err = newPathMatcher(args.Exclude, patternSyntaxGlob); err != nil {
+			return nil, fmt.Errorf("invalid exclude: %w", err)
+		}
+
+		// Map the input path to actual filesystem path, enforcing root containment
+		target, err := resolvePath(cfg, args.Path)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Lstat(target)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("path does not exist: %s", args.Path)
+		}
+		if err == nil && !info.IsDir() {
+			return nil, fmt.Errorf("path is not a directory: %s", args.Path)
+		}
+
+		result, err := diskUsage(ctx, cfg.RootDir, target, opts, args.Depth, args.Top)
+		if err != nil {
+			return nil, err
+		}
+
+		formatter, _ := newPathFormatter(cfg.PathStyle, cfg.RootDir, relPath(cfg.RootDir, target))
+		result.Path = formatter.format(result.Path)
+		for _, dirs := range [][]dirUsage{result.Directories, result.LargestDirectories} {
+			for i := range dirs {
+				dirs[i].Path = formatter.format(dirs[i].Path)
+			}
+		}
+		for i := range result.LargestFiles {
+			result.LargestFiles[i].Path = formatter.format(result.LargestFiles[i].Path)
+		}
+
+		summary := fmt.Sprintf("%s: %d files in %d directories, %d bytes apparent, %d bytes allocated",
+			result.Path, result.Files, result.Dirs, result.ApparentSize, result.AllocatedSize)
+
+		log.Printf("[TOOL COMPLETED] %s - %s", request.Params.Name, summary)
+
+		return mcp.NewToolResultStructured(result, formatUsage(summary, result)), nil
+	}
+}
+
+// diskUsage aggregates the sizes of everything under target in a single
+// traversal. Every non-directory entry is charged to each of its ancestor
+// directories up to target; a file with several hard links is counted once.
+// Paths in the result are root-relative.
+func diskUsage(ctx context.Context, rootDir, target string, opts walkOptions, depth, top int) (usageResult, error) {
+	targetRel := relPath(rootDir, target)
+	result := usageResult{Path: targetRel}
+
+	dirs := map[string]*dirUsage{targetRel: {Path: targetRel}}
+	extensions := make(map[string]*usageTotals)
+	files := &fileHeap{}
+
+	type fileID struct{ device, inode uint64 }
+	seen := make(map[fileID]bool)
+
+	err := walkTree(rootDir, target, opts, func(entry walkEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.Entry.IsDir() {
+			if _, ok := dirs[entry.Rel]; !ok {
+				dirs[entry.Rel] = &dirUsage{Path: entry.Rel}
+			}
+			return nil
+		}
+
+		info, err := entry.Entry.Info()
+		if err != nil {
+			return nil
+		}
+		apparent, allocated := info.Size(), info.Size()
+		if sys, ok := platformStat(info); ok {
+			allocated = sys.Blocks * 512
+			if sys.Links > 1 {
+				id := fileID{sys.Device, sys.Inode}
+				if seen[id] {
+					result.HardLinksSkipped++
+					return nil
+				}
+				seen[id] = true
+			}
+		}
+
+		result.add(apparent, allocated)
+
+		// Charge every directory between the file and the target
+		for dir := path.Dir(entry.Rel); ; dir = path.Dir(dir) {
+			usage, ok := dirs[dir]
+			if !ok {
+				usage = &dirUsage{Path: dir}
+				dirs[dir] = usage
+			}
+			usage.add(apparent, allocated)
+			if dir == targetRel || dir == "." {
+				break
+			}
+		}
+
+		ext := strings.ToLower(filepath.Ext(entry.Entry.Name()))
+		if ext == "" {
+			ext = noExtension
+		}
+		if extensions[ext] == nil {
+			extensions[ext] = &usageTotals{}
+		}
+		extensions[ext].add(apparent, allocated)
+
+		heap.Push(files, fileUsage{Path: entry.Rel, ApparentSize: apparent, AllocatedSize: allocated})
+		if files.Len() > top {
+			heap.Pop(files)
+		}
+		return nil
+	})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return usageResult{}, ctxErr
+		}
+		return usageResult{}, fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	// The target is the tree's total, so it is listed but not counted as a subdirectory
+	result.Dirs = len(dirs) - 1
+	result.Directories = []dirUsage{}
+	all := make([]dirUsage, 0, len(dirs))
+	for _, usage := range dirs {
+		all = append(all, *usage)
+		if pathDepth(target, filepath.Join(rootDir, filepath.FromSlash(usage.Path))) <= depth {
+			result.Directories = append(result.Directories, *usage)
+		}
+	}
+	sort.Slice(result.Directories, func(i, j int) bool {
+		return compareWalkOrder(result.Directories[i].Path, result.Directories[j].Path) < 0
+	})
+
+	sort.Slice(all, func(i, j int) bool {
+		return largerUsage(all[i].AllocatedSize, all[j].AllocatedSize, all[i].Path, all[j].Path)
+	})
+	result.LargestDirectories = all[:min(top, len(all))]
+
+	result.LargestFiles = make([]fileUsage, files.Len())
+	for i := files.Len() - 1; i >= 0; i-- {
+		result.LargestFiles[i] = heap.Pop(files).(fileUsage)
+	}
+
+	result.Extensions = make([]extensionUsage, 0, len(extensions))
+	for ext, totals := range extensions {
+		result.Extensions = append(result.Extensions, extensionUsage{Extension: ext, usageTotals: *totals})
+	}
+	sort.Slice(result.Extensions, func(i, j int) bool {
+		a, b := result.Extensions[i], result.Extensions[j]
+		return largerUsage(a.AllocatedSize, b.AllocatedSize, a.Extension, b.Extension)
+	})
+
+	return result, nil
+}
+
+// largerUsage orders by allocated size, largest first, then by name so the
+// order is stable
+func largerUsage(sizeA, sizeB int64, nameA, nameB string) bool {
+	if sizeA != sizeB {
+		return sizeA > sizeB
+	}
+	return nameA < nameB
+}
+
+// fileHeap is a min-heap of files by allocated size, used to keep the
+// largest files seen so far without holding every file in memory
+type fileHeap []fileUsage
+
+func (h fileHeap) Len() int { return len(h) }
+func (h fileHeap) Less(i, j int) bool {
+	return largerUsage(h[j].AllocatedSize, h[i].AllocatedSize, h[j].Path, h[i].Path)
+}
+func (h fileHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *fileHeap) Push(x any)   { *h = append(*h, x.(fileUsage)) }
+func (h *fileHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// formatUsage renders a disk usage result as text, du-style
+func formatUsage(summary string, r usageResult) string {
+	var b strings.Builder
+	b.WriteString(summary)
+	b.WriteString("\n\nDirectories (allocated / apparent bytes):\n")
+	for _, d := range r.Directories {
+		fmt.Fprintf(&b, "  %12d %12d  %s\n", d.AllocatedSize, d.ApparentSize, d.Path)
+	}
+	b.WriteString("\nLargest directories:\n")
+	for _, d := range r.LargestDirectories {
+		fmt.Fprintf(&b, "  %12d  %s\n", d.AllocatedSize, d.Path)
+	}
+	b.WriteString("\nLargest files:\n")
+	for _, f := range r.LargestFiles {
+		fmt.Fprintf(&b, "  %12d  %s\n", f.AllocatedSize, f.Path)
+	}
+	b.WriteString("\nExtensions:\n")
+	for _, e := range r.Extensions {
+		fmt.Fprintf(&b, "  %12d  %6d files  %s\n", e.AllocatedSize, e.Files, e.Extension)
+	}
+	return b.String()
+}
+
+// diskUsageOutputSchema describes usageResult for clients that validate structured content
+const diskUsageOutputSchema = `{
+	"type": "object",
+	"definitions": {
+		"dir": {
+			"type": "object",
+			"properties": {
+				"path": {"type": "string"},
+				"files": {"type": "integer"},
+				"apparent_size": {"type": "integer"},
+				"allocated_size": {"type": "integer"}
+			},
+			"required": ["path", "files", "apparent_size", "allocated_size"]
+		}
+	},
+	"properties": {
+		"path": {"type": "string"},
+		"files": {"type": "integer"},
+		"apparent_size": {"type": "integer"},
+		"allocated_size": {"type": "integer"},
+		"dirs": {"type": "integer"},
+		"hard_links_skipped": {"type": "integer"},
+		"directories": {"type": "array", "items": {"$ref": "#/definitions/dir"}},
+		"largest_directories": {"type": "array", "items": {"$ref": "#/definitions/dir"}},
+		"largest_files": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"path": {"type": "string"},
+					"apparent_size": {"type": "integer"},
+					"allocated_size": {"type": "integer"}
+				},
+				"required": ["path", "apparent_size", "allocated_size"]
+			}
+		},
+		"extensions": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"extension": {"type": "string"},
+					"files": {"type": "integer"},
+					"apparent_size": {"type": "integer"},
+					"allocated_size": {"type": "integer"}
+				},
+				"required": ["extension", "files", "apparent_size", "allocated_size"]
+			}
+		}
+	},
+	"required": ["path", "files", "apparent_size", "allocated_size", "dirs", "hard_links_skipped", "directories", "largest_directories", "largest_files", "extensions"]
+}`
+
+// diskUsageServerTool defines the disk_usage tool
+func diskUsageServerTool(cfg *serverConfig) server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "disk_usage",
+			Description: "Summarizes where space goes under a directory: per-directory totals, the largest directories and files, and usage per file extension",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Directory to summarize (use '/' for root directory)",
+						"default":     "/",
+					},
+					"depth": map[string]interface{}{
+						"type":        "integer",
+						"description": "List directory totals down to this depth below the path (0 lists only the path itself); the whole tree is always counted",
+						"minimum":     0,
+						"default":     defaultUsageDepth,
+					},
+					"top": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of largest directories and files to return",
+						"minimum":     1,
+						"default":     defaultUsageTop,
+					},
+					"include": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Only count files whose root-relative path matches one of these globs (e.g. '*.log')",
+					},
+					"exclude": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Skip files and directories matching these globs",
+					},
+					"include_hidden": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Count dot-files and dot-directories",
+						"default":     true,
+					},
+					"respect_ignore_files": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Skip files matched by .gitignore/.ignore files and the server ignore file, and .git directories",
+						"default":     false,
+					},
+				},
+			},
+			RawOutputSchema: json.RawMessage(diskUsageOutputSchema),
+		},
+		Handler: diskUsageTool(cfg),
+	}
+}
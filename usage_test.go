@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDiskUsageTool(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	writeTestFile(t, filepath.Join(tempDir, "subdir", "deep", "big.log"), strings.Repeat("x", 5000))
+	writeTestFile(t, filepath.Join(tempDir, "README"), "readme")
+
+	cfg := newServerConfig(tempDir)
+	cfg.PathStyle = pathStyleVirtual
+	handler := diskUsageTool(cfg)
+
+	result, err := callTool(handler, "disk_usage", `{"top": 2}`)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	usage := result.StructuredContent.(usageResult)
+
+	if usage.Path != "/" || usage.Files != 5 || usage.ApparentSize != 5044 || usage.Dirs != 3 {
+		t.Errorf("Unexpected totals %+v", usage)
+	}
+	if usage.AllocatedSize <= 0 {
+		t.Errorf("Expected allocated size, got %d", usage.AllocatedSize)
+	}
+
+	// depth 1 lists the path and its immediate subdirectories in walk order
+	var listed []string
+	for _, d := range usage.Directories {
+		listed = append(listed, d.Path)
+	}
+	if strings.Join(listed, ",") != "/,/emptydir,/subdir" {
+		t.Errorf("Expected directories down to depth 1, got %v", listed)
+	}
+	if sub := usage.Directories[2]; sub.Files != 3 || sub.ApparentSize != 5026 {
+		t.Errorf("Expected /subdir to include its whole subtree, got %+v", sub)
+	}
+
+	if len(usage.LargestDirectories) != 2 || usage.LargestDirectories[0].Path != "/" || usage.LargestDirectories[1].Path != "/subdir" {
+		t.Errorf("Unexpected largest directories %+v", usage.LargestDirectories)
+	}
+	if len(usage.LargestFiles) != 2 || usage.LargestFiles[0].Path != "/subdir/deep/big.log" {
+		t.Errorf("Unexpected largest files %+v", usage.LargestFiles)
+	}
+
+	extensions := make(map[string]int)
+	for _, e := range usage.Extensions {
+		extensions[e.Extension] = e.Files
+	}
+	if len(extensions) != 5 || extensions[".log"] != 1 || extensions[".txt"] != 1 || extensions[noExtension] != 1 {
+		t.Errorf("Unexpected extensions %+v", usage.Extensions)
+	}
+	if usage.Extensions[0].Extension != ".log" {
+		t.Errorf("Expected .log to use the most space, got %+v", usage.Extensions[0])
+	}
+}
+
+func TestDiskUsageTool_IgnoredFiles(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	writeTestFile(t, filepath.Join(tempDir, ".gitignore"), "node_modules/\n")
+	writeTestFile(t, filepath.Join(tempDir, "node_modules", "dep", "index.js"), strings.Repeat("x", 1000))
+	writeTestFile(t, filepath.Join(tempDir, ".git", "objects", "pack"), strings.Repeat("x", 1000))
+
+	// Ignored trees are counted unless asked otherwise, even though the server respects ignore files
+	cfg := newServerConfig(tempDir)
+	result, err := callTool(diskUsageTool(cfg), "disk_usage", `{}`)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if usage := result.StructuredContent.(usageResult); usage.Files != 6 {
+		t.Errorf("Expected ignored files to be counted by default, got %d files", usage.Files)
+	}
+
+	result, err = callTool(diskUsageTool(cfg), "disk_usage", `{"respect_ignore_files": true}`)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if usage := result.StructuredContent.(usageResult); usage.Files != 4 {
+		t.Errorf("Expected ignored files to be skipped on request, got %d files", usage.Files)
+	}
+}
+
+func TestDiskUsageTool_HardLinks(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("Hard link detection needs inode numbers")
+	}
+
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	if err := os.Link(filepath.Join(tempDir, "file1.txt"), filepath.Join(tempDir, "subdir", "link.txt")); err != nil {
+		t.Skipf("Hard links not supported: %v", err)
+	}
+
+	handler := diskUsageTool(newServerConfig(tempDir))
+
+	result, err := callTool(handler, "disk_usage", `{"depth": 0}`)
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	usage := result.StructuredContent.(usageResult)
+	if usage.Files != 3 || usage.ApparentSize != 38 || usage.HardLinksSkipped != 1 {
+		t.Errorf("Expected the hard link to be counted once, got %+v", usage)
+	}
+	if len(usage.Directories) != 1 {
+		t.Errorf("Expected only the path itself at depth 0, got %+v", usage.Directories)
+	}
+}
+
+func TestDiskUsageTool_Errors(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := diskUsageTool(newServerConfig(tempDir))
+
+	tests := []struct {
+		args string
+		want string
+	}{
+		{`{"path": "/file1.txt"}`, "not a directory"},
+		{`{"path": "/missing"}`, "does not exist"},
+		{`{"depth": -1}`, "must not be negative"},
+	}
+	for _, tt := range tests {
+		if _, err := callTool(handler, "disk_usage", tt.args); err == nil || !contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.args, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Entry type filters accepted by walk_directory's "type" argument
+const (
+	entryTypeFiles    = "files"
+	entryTypeDirs     = "dirs"
+	entryTypeSymlinks = "symlinks"
+	entryTypeAll      = "all"
+)
+
+// Pattern syntaxes accepted for include/exclude patterns
+const (
+	patternSyntaxGlob  = "glob"
+	patternSyntaxRegex = "regex"
+)
+
+// walkOptions controls which entries walkTree descends into and reports
+type walkOptions struct {
+	MaxDepth      int    // maximum depth below the target, negative for unlimited
+	Type          string // one of the entryType* constants
+	IncludeHidden bool   // report dot-entries and descend into dot-directories
+	Include       *pathMatcher
+	Exclude       *pathMatcher
+	RespectIgnore bool           // honor .gitignore/.ignore files and skip .git directories
+	Ignore        *ignoreMatcher // server-level ignore rules applied before per-directory files
+	After         string         // resume after this root-relative path (from a walk cursor)
+
+	Context context.Context   // ends the walk with the context's error once it is done; nil never ends it
+	Visit   func(scanned int) // called with the running count of every entry visited, matching or not
+}
+
+// defaultWalkOptions returns options that report every entry in the tree
+func defaultWalkOptions() walkOptions {
+	return walkOptions{
+		MaxDepth:      -1,
+		Type:          entryTypeAll,
+		IncludeHidden: true,
+	}
+}
+
+// walkEntry describes a single entry visited by walkTree
+type walkEntry struct {
+	Path  string      // absolute filesystem path
+	Rel   string      // slash-separated path relative to the served root ("." for the root)
+	Depth int         // depth below the walk target (the target itself is 0)
+	Entry fs.DirEntry // directory entry as returned by filepath.WalkDir
+}
+
+// walkTree walks target (which must be inside rootDir) and calls fn for every
+// entry that passes opts. Excluded, ignored, hidden and too-deep directories
+// are pruned with fs.SkipDir instead of being walked and filtered afterwards.
+// fn may itself return fs.SkipDir for a directory entry to prune it.
+func walkTree(rootDir, target string, opts walkOptions, fn func(walkEntry) error) error {
+	// Ignore matchers for each directory being walked, keyed by absolute path
+	var ignores map[string]*ignoreMatcher
+	if opts.RespectIgnore {
+		ignores = make(map[string]*ignoreMatcher)
+	}
+
+	var scanned int
+	return filepath.WalkDir(target, func(p string, d fs.DirEntry, err error) error {
+		// Checked before filtering so selective walks over large trees still stop promptly
+		if opts.Context != nil {
+			if err := opts.Context.Err(); err != nil {
+				return err
+			}
+		}
+		scanned++
+		if opts.Visit != nil {
+			opts.Visit(scanned)
+		}
+
+		if err != nil {
+			// Log permission errors but continue walking
+			if os.IsPermission(err) {
+				log.Printf("Permission denied: %s", p)
+				return nil
+			}
+			return err
+		}
+
+		entry := walkEntry{
+			Path:  p,
+			Rel:   relPath(rootDir, p),
+			Depth: pathDepth(target, p),
+			Entry: d,
+		}
+
+		// Skip everything up to and including the cursor position. Directories
+		// wholly before the cursor are pruned; the cursor entry itself and its
+		// ancestors are descended because their contents come after it.
+		resumed := true
+		if opts.After != "" {
+			order := compareWalkOrder(entry.Rel, opts.After)
+			resumed = order > 0
+			if order < 0 && d.IsDir() && !isAncestorPath(entry.Rel, opts.After) {
+				return fs.SkipDir
+			}
+		}
+
+		// The walk target itself is always reported when it matches the type filter
+		if entry.Depth == 0 {
+			if resumed && matchesEntryType(d, opts.Type) {
+				if err := fn(entry); err != nil {
+					return err
+				}
+			}
+			if d.IsDir() && opts.MaxDepth == 0 {
+				return fs.SkipDir
+			}
+			if d.IsDir() && ignores != nil {
+				ignores[p] = ignoreMatcherFor(rootDir, p, opts.Ignore)
+			}
+			return nil
+		}
+
+		// Skip hidden entries (and everything below hidden directories)
+		if !opts.IncludeHidden && isHidden(d.Name()) {
+			return skipEntry(d)
+		}
+
+		// Prune excluded entries
+		if opts.Exclude.Match(entry.Rel) {
+			return skipEntry(d)
+		}
+
+		// Prune entries matched by ignore files
+		var ignore *ignoreMatcher
+		if ignores != nil {
+			if d.IsDir() && d.Name() == ".git" {
+				return fs.SkipDir
+			}
+			ignore = ignores[filepath.Dir(p)]
+			if ignore.Match(entry.Rel, d.IsDir()) {
+				return skipEntry(d)
+			}
+		}
+
+		if resumed && matchesEntryType(d, opts.Type) && (opts.Include == nil || opts.Include.Match(entry.Rel)) {
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+
+		// Stop descending once the depth limit has been reached
+		if d.IsDir() && opts.MaxDepth >= 0 && entry.Depth >= opts.MaxDepth {
+			return fs.SkipDir
+		}
+
+		// Pick up ignore files in directories we are about to descend into
+		if d.IsDir() && ignores != nil {
+			ignores[p] = ignore.withRules(loadDirIgnoreRules(p, entry.Rel))
+		}
+		return nil
+	})
+}
+
+// skipEntry returns the WalkDir result that drops d and, for directories, its contents
+func skipEntry(d fs.DirEntry) error {
+	if d.IsDir() {
+		return fs.SkipDir
+	}
+	return nil
+}
+
+// validEntryType reports whether t is a supported entry type filter
+func validEntryType(t string) bool {
+	switch t {
+	case entryTypeFiles, entryTypeDirs, entryTypeSymlinks, entryTypeAll:
+		return true
+	}
+	return false
+}
+
+// matchesEntryType reports whether a directory entry passes the entry type filter
+func matchesEntryType(d fs.DirEntry, t string) bool {
+	switch t {
+	case entryTypeFiles:
+		return d.Type().IsRegular()
+	case entryTypeDirs:
+		return d.IsDir()
+	case entryTypeSymlinks:
+		return d.Type()&fs.ModeSymlink != 0
+	}
+	return true
+}
+
+// isHidden reports whether a file name is a dot-file
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".") && name != "." && name != ".."
+}
+
+// pathDepth returns how many path segments p is below base
+func pathDepth(base, p string) int {
+	rel, err := filepath.Rel(base, p)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(filepath.ToSlash(rel), "/") + 1
+}
+
+// relPath returns p relative to rootDir using forward slashes
+func relPath(rootDir, p string) string {
+	rel, err := filepath.Rel(rootDir, p)
+	if err != nil {
+		return filepath.ToSlash(p)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// pathMatcher matches root-relative paths against a list of glob or regex patterns.
+// A nil *pathMatcher matches nothing.
+type pathMatcher struct {
+	globs   []string
+	regexes []*regexp.Regexp
+}
+
+// newPathMatcher compiles patterns using the given syntax. It returns nil when
+// there are no patterns. Glob patterns use doublestar syntax ("**" crosses
+// directories); a glob without a "/" is matched against the entry's base name
+// at any depth, so "*.go" and "vendor" behave as they would in .gitignore.
+// Regex patterns are unanchored and matched against the full relative path.
+func newPathMatcher(patterns []string, syntax string) (*pathMatcher, error) {
+	// Check the syntax first so a bad value is reported even without patterns
+	regex := syntax == patternSyntaxRegex
+	if !regex && syntax != patternSyntaxGlob && syntax != "" {
+		return nil, fmt.Errorf("invalid pattern syntax %q: must be glob or regex", syntax)
+	}
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	m := &pathMatcher{}
+	for _, pattern := range patterns {
+		if regex {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex pattern %q: %w", pattern, err)
+			}
+			m.regexes = append(m.regexes, re)
+			continue
+		}
+		pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "/"), "/")
+		if !doublestar.ValidatePattern(pattern) {
+			return nil, fmt.Errorf("invalid glob pattern %q", pattern)
+		}
+		m.globs = append(m.globs, pattern)
+	}
+	return m, nil
+}
+
+// Match reports whether rel matches any of the patterns
+func (m *pathMatcher) Match(rel string) bool {
+	if m == nil {
+		return false
+	}
+
+	for _, glob := range m.globs {
+		name := rel
+		if !strings.Contains(glob, "/") {
+			name = path.Base(rel)
+		}
+		if ok, _ := doublestar.Match(glob, name); ok {
+			return true
+		}
+	}
+
+	for _, re := range m.regexes {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPathMatcher_Glob(t *testing.T) {
+	m, err := newPathMatcher([]string{"*.go", "vendor", "docs/**/*.md"}, patternSyntaxGlob)
+	if err != nil {
+		t.Fatalf("newPathMatcher returned error: %v", err)
+	}
+
+	tests := []struct {
+		rel   string
+		match bool
+	}{
+		{"main.go", true},
+		{"cmd/tool/main.go", true},
+		{"vendor", true},
+		{"third_party/vendor", true},
+		{"docs/a/b/readme.md", true},
+		{"readme.md", false},
+		{"main.go.txt", false},
+	}
+
+	for _, tt := range tests {
+		if got := m.Match(tt.rel); got != tt.match {
+			t.Errorf("Match(%q) = %v, want %v", tt.rel, got, tt.match)
+		}
+	}
+}
+
+func TestPathMatcher_Regex(t *testing.T) {
+	m, err := newPathMatcher([]string{`_test\.go$`}, patternSyntaxRegex)
+	if err != nil {
+		t.Fatalf("newPathMatcher returned error: %v", err)
+	}
+
+	if !m.Match("pkg/walk_test.go") {
+		t.Error("Expected regex to match pkg/walk_test.go")
+	}
+	if m.Match("pkg/walk.go") {
+		t.Error("Expected regex not to match pkg/walk.go")
+	}
+}
+
+func TestPathMatcher_Invalid(t *testing.T) {
+	if _, err := newPathMatcher([]string{"[abc"}, patternSyntaxGlob); err == nil {
+		t.Error("Expected error for invalid glob")
+	}
+	if _, err := newPathMatcher([]string{"(abc"}, patternSyntaxRegex); err == nil {
+		t.Error("Expected error for invalid regex")
+	}
+	if _, err := newPathMatcher([]string{"abc"}, "fuzzy"); err == nil {
+		t.Error("Expected error for unknown syntax")
+	}
+	if _, err := newPathMatcher(nil, "fuzzy"); err == nil {
+		t.Error("Expected error for unknown syntax without patterns")
+	}
+}
+
+func TestPathMatcher_Nil(t *testing.T) {
+	var m *pathMatcher
+	if m.Match("anything") {
+		t.Error("Nil matcher should match nothing")
+	}
+}
+
+func TestWalkTree_PrunesExcludedDirectories(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	exclude, err := newPathMatcher([]string{"subdir"}, patternSyntaxGlob)
+	if err != nil {
+		t.Fatalf("newPathMatcher returned error: %v", err)
+	}
+
+	opts := defaultWalkOptions()
+	opts.Exclude = exclude
+
+	var visited []string
+	err = walkTree(tempDir, tempDir, opts, func(entry walkEntry) error {
+		visited = append(visited, entry.Rel)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walkTree returned error: %v", err)
+	}
+
+	// Root, file1.txt and emptydir only
+	if len(visited) != 3 {
+		t.Fatalf("Expected 3 entries, got %d: %v", len(visited), visited)
+	}
+	for _, rel := range visited {
+		if contains(rel, "subdir") {
+			t.Errorf("Excluded entry %s was visited", rel)
+		}
+	}
+}
+
+func TestWalkTree_DepthAndRel(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	target := filepath.Join(tempDir, "subdir")
+	depths := map[string]int{}
+	err := walkTree(tempDir, target, defaultWalkOptions(), func(entry walkEntry) error {
+		depths[entry.Rel] = entry.Depth
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walkTree returned error: %v", err)
+	}
+
+	expected := map[string]int{
+		"subdir":                 0,
+		"subdir/file2.go":        1,
+		"subdir/deep":            1,
+		"subdir/deep/file3.json": 2,
+	}
+	for rel, depth := range expected {
+		got, ok := depths[rel]
+		if !ok {
+			t.Errorf("Expected %s to be visited", rel)
+		} else if got != depth {
+			t.Errorf("Depth of %s = %d, want %d", rel, got, depth)
+		}
+	}
+}
+
+func TestMatchesEntryType_Symlink(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	link := filepath.Join(tempDir, "link")
+	if err := os.Symlink(filepath.Join(tempDir, "file1.txt"), link); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+
+	var linkEntry fs.DirEntry
+	for _, e := range entries {
+		if e.Name() == "link" {
+			linkEntry = e
+		}
+	}
+	if linkEntry == nil {
+		t.Fatal("Symlink entry not found")
+	}
+
+	if !matchesEntryType(linkEntry, entryTypeSymlinks) {
+		t.Error("Symlink should match symlinks filter")
+	}
+	if matchesEntryType(linkEntry, entryTypeFiles) {
+		t.Error("Symlink should not match files filter")
+	}
+}
+
+func TestWalkTree_ContextAndVisit(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	// Visit counts entries that the filters drop too
+	opts := defaultWalkOptions()
+	opts.Include, _ = newPathMatcher([]string{"*.none"}, patternSyntaxGlob)
+	var scanned int
+	opts.Visit = func(n int) { scanned = n }
+	err := walkTree(tempDir, tempDir, opts, func(entry walkEntry) error {
+		if entry.Depth > 0 {
+			t.Errorf("Unexpected match %s", entry.Rel)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walkTree returned error: %v", err)
+	}
+	if scanned != 7 {
+		t.Errorf("Expected 7 entries visited, got %d", scanned)
+	}
+
+	// A done context ends the walk with its error
+	ctx, cancel := context.WithCancel(context.Background())
+	opts = defaultWalkOptions()
+	opts.Context = ctx
+	var seen int
+	err = walkTree(tempDir, tempDir, opts, func(entry walkEntry) error {
+		seen++
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || seen != 1 {
+		t.Errorf("Expected the walk to stop after one entry with context.Canceled, got %d entries and %v", seen, err)
+	}
+}
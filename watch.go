@@ -0,0 +1,425 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Change types watch_directory reports. Renames are reported as a delete of
+// the old name and a create of the new one.
+const (
+	watchEventCreate = "create"
+	watchEventModify = "modify"
+	watchEventDelete = "delete"
+)
+
+// defaultWatchTimeout is how long watch_directory waits when the caller sets no timeout
+const defaultWatchTimeout = 30 * time.Second
+
+// maxWatchTimeout is the longest a single watch_directory call may block
+const maxWatchTimeout = 10 * time.Minute
+
+// watchEvent is a single change reported by watch_directory
+type watchEvent struct {
+	Path string `json:"path"`
+	Type string `json:"type"` // create, modify or delete
+	Dir  bool   `json:"dir"`
+	Time string `json:"time"` // when the server saw the change, RFC 3339
+}
+
+// watchResult is the structured content returned by watch_directory
+type watchResult struct {
+	Events        []watchEvent `json:"events"`         // in the order they were seen
+	TimedOut      bool         `json:"timed_out"`      // the timeout passed before max_events changes were seen
+	UnwatchedDirs int          `json:"unwatched_dirs"` // directories that could not be watched, whose changes were missed
+}
+
+// watchDirectoryTool implements the watch_directory tool handler
+func watchDirectoryTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		// Parse the arguments
+		var args struct {
+			Path          string   `json:"path"`
+			Events        []string `json:"events"`
+			Include       []string `json:"include"`
+			Exclude       []string `json:"exclude"`
+			Recursive     bool     `json:"recursive"`
+			IncludeHidden bool     `json:"include_hidden"`
+			RespectIgnore bool     `json:"respect_ignore_files"`
+			Timeout       string   `json:"timeout"`
+			MaxEvents     int      `json:"max_events"`
+		}
+		args.Path = "/"
+		args.Events = []string{watchEventCreate, watchEventModify, watchEventDelete}
+		args.Recursive = true
+		args.IncludeHidden = true
+		args.RespectIgnore = cfg.RespectIgnoreFiles
+		args.Timeout = defaultWatchTimeout.String()
+		args.MaxEvents = 1
+
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+
+		timeout, err := time.ParseDuration(args.Timeout)
+		if err != nil || timeout <= 0 || timeout > maxWatchTimeout {
+			return nil, fmt.Errorf("invalid timeout %q: must be a positive duration up to %s, such as 30s or 5m", args.Timeout, maxWatchTimeout)
+		}
+		if args.MaxEvents <= 0 {
+			return nil, fmt.Errorf("max_events must be positive")
+		}
+		kinds := make(map[string]bool)
+		for _, kind := range args.Events {
+			switch kind {
+			case watchEventCreate, watchEventModify, watchEventDelete:
+				kinds[kind] = true
+			default:
+				return nil, fmt.Errorf("invalid event %q: must be one of create, modify, delete", kind)
+			}
+		}
+
+		opts := defaultWalkOptions()
+		opts.Type = entryTypeDirs
+		opts.IncludeHidden = args.IncludeHidden
+		opts.RespectIgnore = args.RespectIgnore
+		opts.Ignore = cfg.IgnoreRules
+		if !args.Recursive {
+			opts.MaxDepth = 0
+		}
+
+		include, err := newPathMatcher(args.Include, patternSyntaxGlob)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include: %w", err)
+		}
+		if opts.Exclude, err = newPathMatcher(args.Exclude, patternSyntaxGlob); err != nil {
+			return nil, fmt.Errorf("invalid exclude: %w", err)
+		}
+
+		// Map the input path to actual filesystem path, enforcing root containment
+		target, err := resolvePath(cfg, args.Path)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(target)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("path does not exist: %s", args.Path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat path: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("not a directory: %s", args.Path)
+		}
+
+		watch, err := newDirectoryWatch(cfg.RootDir, target, opts, include, kinds)
+		if err != nil {
+			return nil, err
+		}
+		defer watch.close()
+
+		formatter, _ := newPathFormatter(cfg.PathStyle, cfg.RootDir, relPath(cfg.RootDir, target))
+		progress := newProgressReporter(ctx, request)
+
+		// Collect changes until there are enough, the timeout passes or the client cancels
+		waitCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		result := watchResult{Events: []watchEvent{}}
+		for len(result.Events) < args.MaxEvents && !result.TimedOut {
+			select {
+			case <-waitCtx.Done():
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				result.TimedOut = true
+			case event := <-watch.fsw.Events:
+				for _, e := range watch.changes(event) {
+					if len(result.Events) == args.MaxEvents {
+						break
+					}
+					e.Path = formatter.format(e.Path)
+					result.Events = append(result.Events, e)
+					progress.report(float64(len(result.Events)), float64(args.MaxEvents), e.Type+" "+e.Path)
+				}
+			case err := <-watch.fsw.Errors:
+				log.Printf("File watcher error: %v", err)
+			}
+		}
+
+		result.UnwatchedDirs = watch.unwatched
+		summary := fmt.Sprintf("Saw %d changes", len(result.Events))
+		if result.TimedOut {
+			summary += fmt.Sprintf(" before the %s timeout", timeout)
+		}
+		if result.UnwatchedDirs > 0 {
+			summary += fmt.Sprintf(" (%d directories could not be watched)", result.UnwatchedDirs)
+		}
+
+		log.Printf("[TOOL COMPLETED] %s - %s", request.Params.Name, summary)
+
+		text := summary + "\n"
+		for _, e := range result.Events {
+			text += fmt.Sprintf("%-6s  %s\n", e.Type, e.Path)
+		}
+		return mcp.NewToolResultStructured(result, text), nil
+	}
+}
+
+// directoryWatch follows changes below one directory for a watch_directory call
+type directoryWatch struct {
+	rootDir string
+	opts    walkOptions     // which directories are watched and which entries are pruned
+	include *pathMatcher    // entries reported, when set
+	kinds   map[string]bool // change types reported
+	fsw     *fsnotify.Watcher
+
+	watched   map[string]bool           // directories added to fsw
+	unwatched int                       // directories fsw refused, such as past the inotify watch limit
+	ignores   map[string]*ignoreMatcher // ignore rules by directory, loaded on first use
+	seen      map[watchEvent]bool       // path and type of changes already reported
+	created   map[string]bool           // entries created during the call, whose writes are not modifications
+}
+
+// newDirectoryWatch starts watching target and, unless opts.MaxDepth is 0,
+// the directories below it that opts lets through
+func newDirectoryWatch(rootDir, target string, opts walkOptions, include *pathMatcher, kinds map[string]bool) (*directoryWatch, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start file watcher: %w", err)
+	}
+	w := &directoryWatch{
+		rootDir: rootDir,
+		opts:    opts,
+		include: include,
+		kinds:   kinds,
+		fsw:     fsw,
+		watched: make(map[string]bool),
+		ignores: make(map[string]*ignoreMatcher),
+		seen:    make(map[watchEvent]bool),
+		created: make(map[string]bool),
+	}
+	if err := fsw.Add(target); err != nil {
+		fsw.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", relPath(rootDir, target), err)
+	}
+	w.watched[target] = true
+	if _, err := w.addTree(target); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// close stops watching
+func (w *directoryWatch) close() {
+	w.fsw.Close()
+}
+
+// addTree watches dir and the directories below it, and returns the entries
+// already inside it. Anything created in a new directory before its watch was
+// in place is reported through them. Directories that cannot be watched are
+// logged and counted, and the rest of the tree is still watched.
+func (w *directoryWatch) addTree(dir string) ([]walkEntry, error) {
+	opts := w.opts
+	opts.Type = entryTypeAll
+
+	var entries []walkEntry
+	err := walkTree(w.rootDir, dir, opts, func(entry walkEntry) error {
+		if !entry.Entry.IsDir() {
+			entries = append(entries, entry)
+			return nil
+		}
+		if w.opts.MaxDepth == 0 && entry.Path != dir {
+			return nil
+		}
+		if !w.watched[entry.Path] {
+			if err := w.fsw.Add(entry.Path); err != nil {
+				log.Printf("Cannot watch %s: %v", entry.Rel, err)
+				w.unwatched++
+			} else {
+				w.watched[entry.Path] = true
+			}
+		}
+		if entry.Path != dir {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// changes turns a filesystem event into the changes it reports, with
+// root-relative paths. Events for filtered entries and repeats of changes
+// already reported yield nothing.
+func (w *directoryWatch) changes(event fsnotify.Event) []watchEvent {
+	now := time.Now().UTC().Format(time.RFC3339)
+	var changes []watchEvent
+	add := func(p, kind string, dir bool) {
+		change := watchEvent{Path: relPath(w.rootDir, p), Type: kind, Dir: dir}
+		if !w.kinds[kind] || w.seen[change] || w.pruned(p, dir) || w.include != nil && !w.include.Match(change.Path) {
+			return
+		}
+		w.seen[change] = true
+		change.Time = now
+		changes = append(changes, change)
+	}
+
+	switch {
+	case event.Has(fsnotify.Create):
+		info, err := os.Lstat(event.Name)
+		if err != nil {
+			return nil
+		}
+		w.created[event.Name] = true
+		add(event.Name, watchEventCreate, info.IsDir())
+		if info.IsDir() && w.opts.MaxDepth != 0 && !w.pruned(event.Name, true) {
+			entries, err := w.addTree(event.Name)
+			if err != nil {
+				log.Printf("Failed to watch new directory: %v", err)
+			}
+			for _, entry := range entries {
+				w.created[entry.Path] = true
+				add(entry.Path, watchEventCreate, entry.Entry.IsDir())
+			}
+		}
+	case event.Has(fsnotify.Write):
+		if !w.created[event.Name] {
+			add(event.Name, watchEventModify, false)
+		}
+	case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+		dir := w.watched[event.Name]
+		delete(w.created, event.Name)
+		add(event.Name, watchEventDelete, dir)
+		for p := range w.watched {
+			if withinRoot(event.Name, p) {
+				// Removed directories lose their watch on their own; renamed ones do not
+				_ = w.fsw.Remove(p)
+				delete(w.watched, p)
+			}
+		}
+	}
+	return changes
+}
+
+// pruned reports whether the hidden, exclude or ignore filters drop the
+// entry at p, as they would in a walk
+func (w *directoryWatch) pruned(p string, dir bool) bool {
+	rel := relPath(w.rootDir, p)
+	if !w.opts.IncludeHidden && isHidden(filepath.Base(p)) {
+		return true
+	}
+	if w.opts.Exclude.Match(rel) {
+		return true
+	}
+	if !w.opts.RespectIgnore {
+		return false
+	}
+	if strings.Contains("/"+rel+"/", "/.git/") {
+		return true
+	}
+	parent := filepath.Dir(p)
+	if w.ignores[parent] == nil {
+		w.ignores[parent] = ignoreMatcherFor(w.rootDir, parent, w.opts.Ignore)
+	}
+	return w.ignores[parent].Match(rel, dir)
+}
+
+// watchDirectoryOutputSchema describes watchResult for clients that validate structured content
+const watchDirectoryOutputSchema = `{
+	"type": "object",
+	"properties": {
+		"events": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"path": {"type": "string"},
+					"type": {"type": "string", "enum": ["create", "modify", "delete"]},
+					"dir": {"type": "boolean"},
+					"time": {"type": "string", "format": "date-time"}
+				},
+				"required": ["path", "type", "dir", "time"]
+			}
+		},
+		"timed_out": {"type": "boolean"},
+		"unwatched_dirs": {"type": "integer"}
+	},
+	"required": ["events", "timed_out", "unwatched_dirs"]
+}`
+
+// watchDirectoryServerTool defines the watch_directory tool
+func watchDirectoryServerTool(cfg *serverConfig) server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "watch_directory",
+			Description: "Waits for files under the specified directory to be created, modified or deleted and returns the changes seen, instead of polling with walk_directory",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Directory to watch (use '/' for root directory)",
+						"default":     "/",
+					},
+					"events": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string", "enum": []string{watchEventCreate, watchEventModify, watchEventDelete}},
+						"description": "Change types to report; renames are a delete of the old name and a create of the new one",
+						"default":     []string{watchEventCreate, watchEventModify, watchEventDelete},
+					},
+					"include": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Only report entries whose root-relative path matches one of these globs (e.g. 'dist/*.tar.gz')",
+					},
+					"exclude": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Ignore entries, and changes inside directories, matching these globs",
+					},
+					"recursive": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Watch subdirectories too, including ones created during the call",
+						"default":     true,
+					},
+					"include_hidden": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Report changes to dot-files and inside dot-directories",
+						"default":     true,
+					},
+					"respect_ignore_files": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Ignore changes to entries matched by .gitignore/.ignore files and the server ignore file",
+						"default":     cfg.RespectIgnoreFiles,
+					},
+					"timeout": map[string]interface{}{
+						"type":        "string",
+						"description": "Longest to wait, as a Go duration up to 10m; the changes seen so far are returned with timed_out set",
+						"default":     defaultWatchTimeout.String(),
+					},
+					"max_events": map[string]interface{}{
+						"type":        "integer",
+						"description": "Return as soon as this many changes have been seen; each path is reported once per change type, and files created during the call are not also reported as modified",
+						"minimum":     1,
+						"default":     1,
+					},
+				},
+			},
+			RawOutputSchema: json.RawMessage(watchDirectoryOutputSchema),
+		},
+		Handler: watchDirectoryTool(cfg),
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// watchInBackground starts a watch_directory call and returns a channel that
+// receives its result once it returns. Changes made after the returned delay
+// are seen by the watch.
+func watchInBackground(ctx context.Context, cfg *serverConfig, arguments string) <-chan watchOutcome {
+	done := make(chan watchOutcome, 1)
+	go func() {
+		request := mcp.CallToolRequest{Params: mcp.CallToolParams{Name: "watch_directory", Arguments: json.RawMessage(arguments)}}
+		result, err := watchDirectoryTool(cfg)(ctx, request)
+		done <- watchOutcome{result, err}
+	}()
+	time.Sleep(200 * time.Millisecond)
+	return done
+}
+
+// watchOutcome is what a background watch_directory call returned
+type watchOutcome struct {
+	result *mcp.CallToolResult
+	err    error
+}
+
+// watchEvents waits for a background watch and returns its structured result
+func watchEvents(t *testing.T, done <-chan watchOutcome) watchResult {
+	t.Helper()
+	select {
+	case outcome := <-done:
+		if outcome.err != nil {
+			t.Fatalf("watch_directory failed: %v", outcome.err)
+		}
+		return outcome.result.StructuredContent.(watchResult)
+	case <-time.After(10 * time.Second):
+		t.Fatal("watch_directory did not return")
+		return watchResult{}
+	}
+}
+
+func TestWatchDirectory_Create(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := newServerConfig(tempDir)
+	cfg.PathStyle = pathStyleVirtual
+
+	done := watchInBackground(context.Background(), cfg, `{"include": ["*.out"], "timeout": "5s"}`)
+	writeTestFile(t, filepath.Join(tempDir, "subdir", "build.log"), "building")
+	writeTestFile(t, filepath.Join(tempDir, "subdir", "result.out"), "done")
+
+	result := watchEvents(t, done)
+	if result.TimedOut || len(result.Events) != 1 {
+		t.Fatalf("Expected one change, got %+v", result)
+	}
+	if e := result.Events[0]; e.Path != "/subdir/result.out" || e.Type != watchEventCreate || e.Dir {
+		t.Errorf("Unexpected change %+v", e)
+	}
+}
+
+func TestWatchDirectory_ModifyAndDelete(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := newServerConfig(tempDir)
+	cfg.PathStyle = pathStyleVirtual
+
+	done := watchInBackground(context.Background(), cfg, `{"events": ["modify", "delete"], "max_events": 2, "timeout": "5s"}`)
+	for i := 0; i < 3; i++ {
+		writeTestFile(t, filepath.Join(tempDir, "file1.txt"), "changed")
+	}
+	writeTestFile(t, filepath.Join(tempDir, "new.txt"), "not reported")
+	if err := os.Remove(filepath.Join(tempDir, "subdir", "file2.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Repeated writes to one file are reported once
+	result := watchEvents(t, done)
+	if len(result.Events) != 2 {
+		t.Fatalf("Expected two changes, got %+v", result)
+	}
+	if e := result.Events[0]; e.Path != "/file1.txt" || e.Type != watchEventModify {
+		t.Errorf("Unexpected first change %+v", e)
+	}
+	if e := result.Events[1]; e.Path != "/subdir/file2.go" || e.Type != watchEventDelete {
+		t.Errorf("Unexpected second change %+v", e)
+	}
+}
+
+func TestWatchDirectory_NewDirectory(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := newServerConfig(tempDir)
+	cfg.PathStyle = pathStyleVirtual
+
+	// Files created before the new directory is watched are still reported
+	done := watchInBackground(context.Background(), cfg, `{"path": "/subdir", "events": ["create"], "max_events": 3, "timeout": "5s"}`)
+	writeTestFile(t, filepath.Join(tempDir, "subdir", "out", "a", "f.txt"), "x")
+
+	result := watchEvents(t, done)
+	got := make(map[string]bool)
+	for _, e := range result.Events {
+		got[e.Path] = e.Dir
+	}
+	want := map[string]bool{"/subdir/out": true, "/subdir/out/a": true, "/subdir/out/a/f.txt": false}
+	if len(got) != len(want) {
+		t.Fatalf("Expected changes %v, got %+v", want, result.Events)
+	}
+	for p, dir := range want {
+		if isDir, ok := got[p]; !ok || isDir != dir {
+			t.Errorf("Expected %s (dir %v) to be reported, got %+v", p, dir, result.Events)
+		}
+	}
+}
+
+func TestWatchDirectory_Filters(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	writeTestFile(t, filepath.Join(tempDir, ".gitignore"), "*.tmp\n")
+	cfg := newServerConfig(tempDir)
+	cfg.PathStyle = pathStyleVirtual
+
+	done := watchInBackground(context.Background(), cfg, `{"exclude": ["subdir"], "recursive": false, "timeout": "5s"}`)
+	writeTestFile(t, filepath.Join(tempDir, "scratch.tmp"), "ignored")
+	writeTestFile(t, filepath.Join(tempDir, "subdir", "excluded.txt"), "excluded")
+	writeTestFile(t, filepath.Join(tempDir, "emptydir", "too-deep.txt"), "not watched")
+	writeTestFile(t, filepath.Join(tempDir, "kept.txt"), "kept")
+
+	result := watchEvents(t, done)
+	if len(result.Events) != 1 || result.Events[0].Path != "/kept.txt" {
+		t.Errorf("Expected only /kept.txt, got %+v", result.Events)
+	}
+}
+
+func TestWatchDirectory_Timeout(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	start := time.Now()
+	result, err := callTool(watchDirectoryTool(newServerConfig(tempDir)), "watch_directory", `{"timeout": "100ms"}`)
+	if err != nil {
+		t.Fatalf("watch_directory failed: %v", err)
+	}
+	watch := result.StructuredContent.(watchResult)
+	if !watch.TimedOut || len(watch.Events) != 0 {
+		t.Errorf("Expected an empty timed out result, got %+v", watch)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected the call to wait for the timeout, returned after %s", elapsed)
+	}
+}
+
+func TestWatchDirectory_Cancel(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := watchInBackground(ctx, newServerConfig(tempDir), `{"timeout": "5s"}`)
+	cancel()
+
+	select {
+	case outcome := <-done:
+		if !errors.Is(outcome.err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", outcome.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch_directory ignored cancellation")
+	}
+}
+
+func TestWatchDirectory_Errors(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	handler := watchDirectoryTool(newServerConfig(tempDir))
+	tests := []struct {
+		name string
+		args string
+		want string
+	}{
+		{"bad timeout", `{"timeout": "soon"}`, "invalid timeout"},
+		{"timeout too long", `{"timeout": "1h"}`, "invalid timeout"},
+		{"zero max_events", `{"max_events": 0}`, "max_events must be positive"},
+		{"bad event", `{"events": ["rename"]}`, "invalid event"},
+		{"missing path", `{"path": "/missing"}`, "path does not exist"},
+		{"file path", `{"path": "/file1.txt"}`, "not a directory"},
+		{"outside root", `{"path": "/../.."}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := callTool(handler, "watch_directory", tt.args)
+			if err == nil || !contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestWatchDirectory_Progress(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	mcpServer.AddTools(watchDirectoryServerTool(newServerConfig(tempDir)))
+	session := &testSession{id: "watch-session", notifications: make(chan mcp.JSONRPCNotification, 10)}
+
+	done := make(chan mcp.JSONRPCMessage, 1)
+	go func() {
+		done <- callToolWithProgress(mcpServer, session, "watch_directory", map[string]any{"max_events": 2, "events": []string{"create"}, "timeout": "5s"})
+	}()
+	time.Sleep(200 * time.Millisecond)
+	writeTestFile(t, filepath.Join(tempDir, "a.txt"), "a")
+	writeTestFile(t, filepath.Join(tempDir, "b.txt"), "b")
+
+	select {
+	case response := <-done:
+		if _, ok := response.(mcp.JSONRPCResponse); !ok {
+			t.Fatalf("watch_directory failed: %+v", response)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("watch_directory did not return")
+	}
+
+	if len(session.notifications) != 2 {
+		t.Fatalf("Expected a progress notification per change, got %d", len(session.notifications))
+	}
+	for i := 1; i <= 2; i++ {
+		n := <-session.notifications
+		if n.Method != methodNotificationProgress || n.Params.AdditionalFields["progress"] != float64(i) || n.Params.AdditionalFields["total"] != float64(2) {
+			t.Errorf("Unexpected notification %s %v", n.Method, n.Params.AdditionalFields)
+		}
+	}
+}
+
+func TestDirectoryWatch_UnwatchableDirectory(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+	if err := os.MkdirAll(filepath.Join(tempDir, "subdir", "deeper"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	watch, err := newDirectoryWatch(tempDir, tempDir, defaultWalkOptions(), nil, map[string]bool{watchEventCreate: true})
+	if err != nil {
+		t.Fatalf("Failed to start watch: %v", err)
+	}
+	if watch.unwatched != 0 {
+		t.Errorf("Expected every directory to be watched, %d were not", watch.unwatched)
+	}
+
+	// A watcher that refuses new directories stands in for hitting the
+	// inotify watch limit; the new tree is still walked and reported
+	watch.close()
+	newDir := filepath.Join(tempDir, "new")
+	writeTestFile(t, filepath.Join(newDir, "inner", "f.txt"), "x")
+	entries, err := watch.addTree(newDir)
+	if err != nil {
+		t.Fatalf("Expected unwatchable directories to be skipped, got %v", err)
+	}
+	if watch.unwatched != 2 {
+		t.Errorf("Expected 2 unwatched directories, got %d", watch.unwatched)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected the entries inside the new directory, got %+v", entries)
+	}
+}
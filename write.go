@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Write modes accepted by write_file's "mode" argument
+const (
+	writeModeCreate    = "create"    // fail if the file already exists
+	writeModeOverwrite = "overwrite" // replace the file's contents
+	writeModeAppend    = "append"    // add to the end of the file
+)
+
+// defaultFilePerm is used for files that did not exist before
+const defaultFilePerm = 0644
+
+// errWriteDisabled is returned by mutating tools when the server is read-only
+var errWriteDisabled = errors.New("write access is disabled; start the server with -allow-write")
+
+// errModifyRoot is returned when a mutating tool targets the served root itself
+var errModifyRoot = errors.New("cannot modify the root directory itself")
+
+// writeResult is the structured content returned by write_file
+type writeResult struct {
+	Path         string `json:"path"`
+	BytesWritten int    `json:"bytes_written"`
+	Created      bool   `json:"created"` // the file did not exist before
+	Mode         string `json:"mode"`    // octal permission bits of the written file
+}
+
+// writeFileTool implements the write_file tool handler
+func writeFileTool(cfg *serverConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Log the tool call with arguments
+		logToolCall(request)
+
+		if !cfg.AllowWrite {
+			return nil, errWriteDisabled
+		}
+
+		// Parse the arguments
+		var args struct {
+			Path          string `json:"path"`
+			Content       string `json:"content"`
+			Mode          string `json:"mode"`
+			CreateParents bool   `json:"create_parents"`
+		}
+		args.Mode = writeModeOverwrite
+
+		if err := request.BindArguments(&args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %w", err)
+		}
+
+		if args.Path == "" {
+			return nil, fmt.Errorf("path is required")
+		}
+		switch args.Mode {
+		case writeModeCreate, writeModeOverwrite, writeModeAppend:
+		default:
+			return nil, fmt.Errorf("invalid mode %q: must be one of create, overwrite, append", args.Mode)
+		}
+
+		// Map the input path to actual filesystem path, enforcing root containment
+		target, err := resolveWritePath(cfg, args.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		if args.CreateParents {
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return nil, fmt.Errorf("failed to create parent directories: %w", err)
+			}
+		}
+
+		created, perm, err := atomicWriteFile(target, []byte(args.Content), args.Mode)
+		if err != nil {
+			return nil, err
+		}
+
+		formatter, _ := newPathFormatter(cfg.PathStyle, cfg.RootDir, ".")
+		result := writeResult{
+			Path:         formatter.format(relPath(cfg.RootDir, target)),
+			BytesWritten: len(args.Content),
+			Created:      created,
+			Mode:         fmt.Sprintf("%04o", perm),
+		}
+
+		log.Printf("[TOOL COMPLETED] %s - wrote %d bytes to %s (%s)", request.Params.Name, result.BytesWritten, args.Path, args.Mode)
+
+		return mcp.NewToolResultStructured(result, fmt.Sprintf("Wrote %d bytes to %s", result.BytesWritten, result.Path)), nil
+	}
+}
+
+// resolveWritePath resolves a path that is about to be modified. Symlinks are
+// resolved so that writes replace the link target rather than the link, and
+// the resolved location must be inside the root whatever the symlink policy.
+func resolveWritePath(cfg *serverConfig, input string) (string, error) {
+	target, err := resolvePath(cfg, input)
+	if err != nil {
+		return "", err
+	}
+	if target == cfg.RootDir {
+		return "", errModifyRoot
+	}
+	return realPathInRoot(cfg, target)
+}
+
+// resolveEntryPath resolves a path whose directory entry itself is about to be
+// moved, copied or removed. Only its parent is resolved through symlinks, so a
+// symlink is handled as a link rather than as what it points to.
+func resolveEntryPath(cfg *serverConfig, input string) (string, error) {
+	target, err := resolvePath(cfg, input)
+	if err != nil {
+		return "", err
+	}
+	if target == cfg.RootDir {
+		return "", errModifyRoot
+	}
+
+	parent, err := realPathInRoot(cfg, filepath.Dir(target))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(parent, filepath.Base(target)), nil
+}
+
+// realPathInRoot resolves the symlinks in p and checks that the result is
+// inside the root. The result is expressed under cfg.RootDir so that results
+// keep using root-relative paths even when the root itself is behind a symlink.
+func realPathInRoot(cfg *serverConfig, p string) (string, error) {
+	real, err := evalExistingSymlinks(p)
+	if err != nil {
+		return "", err
+	}
+	realRoot, err := filepath.EvalSymlinks(cfg.RootDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve root directory: %w", err)
+	}
+	if !withinRoot(realRoot, real) {
+		return "", errSymlinkEscape
+	}
+
+	if rel, err := filepath.Rel(realRoot, real); err == nil {
+		return filepath.Join(cfg.RootDir, rel), nil
+	}
+	return p, nil
+}
+
+// atomicWriteFile writes data to target through a temporary file in the same
+// directory followed by a rename, so readers never see a partial file. Existing
+// permissions are preserved. It reports whether the file was newly created and
+// the permissions it ended up with.
+func atomicWriteFile(target string, data []byte, mode string) (bool, os.FileMode, error) {
+	perm := os.FileMode(defaultFilePerm)
+	created := true
+
+	info, err := os.Stat(target)
+	switch {
+	case err == nil:
+		if !info.Mode().IsRegular() {
+			return false, 0, fmt.Errorf("path is not a regular file: %s", target)
+		}
+		if mode == writeModeCreate {
+			return false, 0, fmt.Errorf("file already exists: %s", target)
+		}
+		perm = info.Mode().Perm()
+		created = false
+	case !os.IsNotExist(err):
+		return false, 0, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	// Appending rewrites the whole file so the result still appears atomically
+	if mode == writeModeAppend && !created {
+		existing, err := os.ReadFile(target)
+		if err != nil {
+			return false, 0, fmt.Errorf("failed to read file: %w", err)
+		}
+		data = append(existing, data...)
+	}
+
+	dir := filepath.Dir(target)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return false, 0, fmt.Errorf("parent directory does not exist: %s (set create_parents to create it)", dir)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return false, 0, fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return false, 0, fmt.Errorf("failed to set permissions: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return false, 0, fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return false, 0, fmt.Errorf("failed to close temporary file: %w", err)
+	}
+
+	// Create-only writes link the temporary file into place, which fails
+	// atomically if another writer created the file in the meantime
+	if created && mode == writeModeCreate {
+		if err := os.Link(tmpName, target); err != nil {
+			if os.IsExist(err) {
+				return false, 0, fmt.Errorf("file already exists: %s", target)
+			}
+			return false, 0, fmt.Errorf("failed to create file: %w", err)
+		}
+		return true, perm, nil
+	}
+
+	if err := os.Rename(tmpName, target); err != nil {
+		return false, 0, fmt.Errorf("failed to replace file: %w", err)
+	}
+	return created, perm, nil
+}
+
+// writeFileServerTool defines the write_file tool
+func writeFileServerTool(cfg *serverConfig) server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "write_file",
+			Description: "Writes a file under the root atomically (create, overwrite or append)",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "File path to write (relative to the root, e.g. '/out/report.md')",
+					},
+					"content": map[string]interface{}{
+						"type":        "string",
+						"description": "Text to write",
+					},
+					"mode": map[string]interface{}{
+						"type":        "string",
+						"description": "'create' fails if the file exists, 'overwrite' replaces it, 'append' adds to the end",
+						"enum":        []string{writeModeCreate, writeModeOverwrite, writeModeAppend},
+						"default":     writeModeOverwrite,
+					},
+					"create_parents": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Create missing parent directories",
+						"default":     false,
+					},
+				},
+				Required: []string{"path", "content"},
+			},
+			RawOutputSchema: json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"path": {"type": "string"},
+		"bytes_written": {"type": "integer"},
+		"created": {"type": "boolean"},
+		"mode": {"type": "string", "description": "Octal permission bits"}
+	},
+	"required": ["path", "bytes_written", "created", "mode"]
+}`),
+		},
+		Handler: writeFileTool(cfg),
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeConfig returns a configuration for tempDir with writes enabled
+func writeConfig(tempDir string) *serverConfig {
+	cfg := newServerConfig(tempDir)
+	cfg.AllowWrite = true
+	return cfg
+}
+
+// writeFile calls write_file and returns its structured result
+func writeFile(t *testing.T, cfg *serverConfig, arguments string) writeResult {
+	t.Helper()
+
+	result, err := callTool(writeFileTool(cfg), "write_file", arguments)
+	if err != nil {
+		t.Fatalf("Handler returned error for %s: %v", arguments, err)
+	}
+
+	written, ok := result.StructuredContent.(writeResult)
+	if !ok {
+		t.Fatalf("StructuredContent is not writeResult, got %T", result.StructuredContent)
+	}
+	return written
+}
+
+func TestWriteFileTool_Modes(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := writeConfig(tempDir)
+	target := filepath.Join(tempDir, "notes.txt")
+
+	written := writeFile(t, cfg, `{"path": "/notes.txt", "content": "one\n", "mode": "create"}`)
+	if !written.Created || written.BytesWritten != 4 || written.Mode != "0644" {
+		t.Errorf("Unexpected create result %+v", written)
+	}
+
+	writeFile(t, cfg, `{"path": "/notes.txt", "content": "two\n", "mode": "append"}`)
+	if data, _ := os.ReadFile(target); string(data) != "one\ntwo\n" {
+		t.Errorf("Expected appended content, got %q", data)
+	}
+
+	written = writeFile(t, cfg, `{"path": "/notes.txt", "content": "three\n"}`)
+	if written.Created {
+		t.Error("Overwrite should not report the file as created")
+	}
+	if data, _ := os.ReadFile(target); string(data) != "three\n" {
+		t.Errorf("Expected overwritten content, got %q", data)
+	}
+
+	// No temporary files should be left behind
+	matches, _ := filepath.Glob(filepath.Join(tempDir, ".notes.txt.tmp-*"))
+	if len(matches) != 0 {
+		t.Errorf("Temporary files left behind: %v", matches)
+	}
+}
+
+func TestWriteFileTool_PreservesPermissions(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	target := filepath.Join(tempDir, "run.sh")
+	writeTestFile(t, target, "#!/bin/sh\n")
+	if err := os.Chmod(target, 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	written := writeFile(t, writeConfig(tempDir), `{"path": "/run.sh", "content": "#!/bin/sh\necho hi\n"}`)
+	if written.Mode != "0750" {
+		t.Errorf("Expected mode 0750 in result, got %s", written.Mode)
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0750 {
+		t.Errorf("Expected permissions 0750 to be preserved, got %o", info.Mode().Perm())
+	}
+}
+
+func TestWriteFileTool_CreateParents(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	cfg := writeConfig(tempDir)
+
+	_, err := callTool(writeFileTool(cfg), "write_file", `{"path": "/new/dir/a.txt", "content": "x"}`)
+	if err == nil || !contains(err.Error(), "parent directory does not exist") {
+		t.Fatalf("Expected missing parent error, got %v", err)
+	}
+
+	writeFile(t, cfg, `{"path": "/new/dir/a.txt", "content": "x", "create_parents": true}`)
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "new", "dir", "a.txt")); string(data) != "x" {
+		t.Errorf("Expected file in created directories, got %q", data)
+	}
+}
+
+func TestWriteFileTool_SymlinkWritesTarget(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	link := filepath.Join(tempDir, "link.txt")
+	if err := os.Symlink("file1.txt", link); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	writeFile(t, writeConfig(tempDir), `{"path": "/link.txt", "content": "via link"}`)
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Error("Expected the symlink itself to be left in place")
+	}
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "file1.txt")); string(data) != "via link" {
+		t.Errorf("Expected the link target to be written, got %q", data)
+	}
+}
+
+func TestWriteFileTool_Errors(t *testing.T) {
+	tempDir := createTempTestDir(t)
+	defer os.RemoveAll(tempDir)
+
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(tempDir, "escape")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	handler := writeFileTool(writeConfig(tempDir))
+
+	tests := []struct {
+		arguments string
+		errText   string
+	}{
+		{`{"path": "/file1.txt", "content": "x", "mode": "create"}`, "already exists"},
+		{`{"path": "/subdir", "content": "x"}`, "not a regular file"},
+		{`{"path": "/../outside.txt", "content": "x"}`, "outside root directory"},
+		{`{"path": "/escape/x.txt", "content": "x"}`, "outside root directory"},
+		{`{"path": "/", "content": "x"}`, "root directory itself"},
+		{`{"path": "/a.txt", "content": "x", "mode": "truncate"}`, "invalid mode"},
+		{`{"content": "x"}`, "path is required"},
+	}
+
+	for _, tt := range tests {
+		_, err := callTool(handler, "write_file", tt.arguments)
+		if err == nil {
+			t.Fatalf("Expected error for %s, but got none", tt.arguments)
+		}
+		if !contains(err.Error(), tt.errText) {
+			t.Errorf("Expected '%s' error for %s, got: %v", tt.errText, tt.arguments, err)
+		}
+	}
+
+	// The handler refuses to run when writes are disabled
+	_, err := callTool(writeFileTool(newServerConfig(tempDir)), "write_file", `{"path": "/a.txt", "content": "x"}`)
+	if err != errWriteDisabled {
+		t.Errorf("Expected errWriteDisabled, got %v", err)
+	}
+}